@@ -0,0 +1,46 @@
+package adapter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	whclient "github.com/ehganzlieb/willfahren/whClient"
+)
+
+/*
+whAttributes maps the attributes of a Willhaben advert to dto.Attributes. Only the floor and the free areas
+are mapped, as the search results carry no further attributes; the rest stays unknown and can be
+extracted from the description with domain.ImmoListing.ExtractDetails.
+*/
+func whAttributes(wha *whclient.WHAdvert) dto.Attributes {
+	var a dto.Attributes
+	if wha.Floor != nil {
+		floor := int(*wha.Floor)
+		a.Floor = &floor
+	}
+
+	area := parseArea(strings.Join(wha.Attributes[whclient.FreeAreaAttribute], ", "))
+	for _, label := range wha.Attributes[whclient.FreeAreaTypeAttribute] {
+		for _, part := range strings.Split(label, ",") {
+			if t, ok := dto.ParseOutdoorSpaceType(part); ok {
+				a.OutdoorSpaces = append(a.OutdoorSpaces, dto.OutdoorSpace{Type: t})
+			}
+		}
+	}
+	// the area is the total of all outdoor spaces, so it can only be assigned if there is one
+	if len(a.OutdoorSpaces) == 1 {
+		a.OutdoorSpaces[0].Area = area
+	}
+	return a
+}
+
+// parseArea parses an area like "12,5" or "12.5 m²", returning 0 if it cannot be parsed.
+func parseArea(s string) float32 {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "m²"))
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 32)
+	if err != nil {
+		return 0
+	}
+	return float32(f)
+}
@@ -10,20 +10,33 @@ WHClientDtoAdapter converts a whclient.WHAdvert to a dto.Apartment
 */
 func WHClientDtoAdapter(wha *whclient.WHAdvert) *dto.Apartment {
 
-	district, err := dto.DistrictFromPostCode(int(*wha.Postcode))
-	if err != nil {
-		//TODO: fault tolerance
-		panic(err)
+	// the stated district; postcodes like 1300 belong to no district, which leaves it nil.
+	// the district of the location is resolved by domain.ImmoListings.AnnotateLocatedDistricts
+	var district *dto.District
+	if wha.Postcode != nil {
+		district, _ = dto.DistrictFromPostCode(int(*wha.Postcode))
+	}
+	// the search results only tell the gross rent
+	var price dto.PriceBreakdown
+	if wha.Rent != nil {
+		price.TotalMonthly = dto.EUR(*wha.Rent)
+	}
+	// 0 if unknown
+	var rooms float32
+	if wha.Rooms != nil {
+		rooms = float32(*wha.Rooms)
 	}
 	return &dto.Apartment{
 		ID:          wha.ID,
 		Title:       wha.Title,
 		Description: wha.Description,
 		Area:        float32(*wha.Area),
-		Rooms:       0, //TODO: parse in whClient
-		Price:       float32(*wha.Rent),
+		Rooms:       rooms,
+		Price:       price,
 		District:    district,
 		Location:    wha.Coordinates,
 		URL:         *wha.URL,
+		Published:   wha.PublishTime,
+		Attributes:  whAttributes(wha),
 	}
 }
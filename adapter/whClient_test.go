@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	whclient "github.com/ehganzlieb/willfahren/whClient"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+recordedAdverts are two adverts as parsed from the recorded search response in whClient/out.txt,
+with the attributes the adapter reads.
+*/
+func recordedAdverts() []*whclient.WHAdvert {
+	u, _ := url.Parse("https://willhaben.at/iad/immobilien/d/mietwohnungen/wien/wien-1210-floridsdorf/moderne-2-zimmer-neubau-wohnung-mit-balkon-1948363437/")
+	postcode, area, rent, rooms := uint64(1210), uint64(40), 667.68, 2.0
+	loggia := &whclient.WHAdvert{
+		ID:          1948363437,
+		Title:       "Moderne 2-Zimmer-Neubau-Wohnung mit Balkon",
+		Postcode:    &postcode,
+		Area:        &area,
+		Rent:        &rent,
+		Rooms:       &rooms,
+		Coordinates: &dto.Coordinates{X: 16.39392, Y: 48.25818},
+		URL:         u,
+		Attributes: map[string][]string{
+			whclient.FreeAreaTypeAttribute: {"Balkon", "Loggia"},
+			whclient.FreeAreaAttribute:     {"4"},
+			"FREE_AREA_TYPE":               {"20, 30"},
+			"ESTATE_PREFERENCE":            {"15, 23, 24, 250, 27, 28, 4"},
+		},
+	}
+
+	floor, area2, rent2 := uint64(2), uint64(43), 500.0
+	balcony := &whclient.WHAdvert{
+		ID:          1287203409,
+		Title:       "Gemeinde Wohnung Wien 21.(reserviert)",
+		Postcode:    &postcode,
+		Area:        &area2,
+		Rent:        &rent2,
+		Rooms:       &rooms,
+		Floor:       &floor,
+		Coordinates: &dto.Coordinates{X: 16.39764, Y: 48.25315},
+		URL:         u,
+		Attributes: map[string][]string{
+			whclient.FreeAreaTypeAttribute: {"Balkon"},
+			whclient.FreeAreaAttribute:     {"7"},
+		},
+	}
+	return []*whclient.WHAdvert{loggia, balcony}
+}
+
+func TestWHClientDtoAdapter(t *testing.T) {
+	adverts := recordedAdverts()
+
+	a := WHClientDtoAdapter(adverts[0])
+	assert.Equal(t, uint64(1948363437), a.ID)
+	assert.Equal(t, float32(40), a.Area)
+	assert.Equal(t, float32(2), a.Rooms)
+	assert.Equal(t, dto.EUR(667.68), a.Price.TotalMonthly)
+	assert.Equal(t, 21, a.District.Number)
+	assert.Equal(t, adverts[0].Coordinates, a.Location)
+	// the total area of several outdoor spaces cannot be split between them
+	assert.Equal(t, []dto.OutdoorSpace{{Type: dto.OutdoorSpaceBalcony}, {Type: dto.OutdoorSpaceLoggia}}, a.OutdoorSpaces)
+	assert.Nil(t, a.Floor)
+	assert.Nil(t, a.Lift, "not in the search results")
+	assert.Nil(t, a.RentalTerm, "not in the search results")
+	assert.Nil(t, a.AvailableFrom, "not in the search results")
+
+	b := WHClientDtoAdapter(adverts[1])
+	assert.Equal(t, []dto.OutdoorSpace{{Type: dto.OutdoorSpaceBalcony, Area: 7}}, b.OutdoorSpaces)
+	if assert.NotNil(t, b.Floor) {
+		assert.Equal(t, 2, *b.Floor)
+	}
+}
+
+func TestWHClientDtoAdapterDistrict(t *testing.T) {
+	advert := func(postcode uint64) *whclient.WHAdvert {
+		area := uint64(50)
+		return &whclient.WHAdvert{ID: 1, Postcode: &postcode, Area: &area, URL: &url.URL{}}
+	}
+
+	a := WHClientDtoAdapter(advert(1160))
+	if assert.NotNil(t, a.District) {
+		assert.Equal(t, 16, a.District.Number)
+	}
+	assert.Nil(t, a.LocatedDistrict)
+	assert.Zero(t, a.Rooms, "unknown")
+
+	// the airport postcode belongs to no district
+	assert.Nil(t, WHClientDtoAdapter(advert(1300)).District)
+}
+
+func TestParseArea(t *testing.T) {
+	for s, area := range map[string]float32{"7": 7, "12,5": 12.5, "12.5 m²": 12.5, "": 0, "groß": 0} {
+		assert.Equal(t, area, parseArea(s), s)
+	}
+}
@@ -1,27 +1,80 @@
 package cache
 
 import (
+	"slices"
+	"sync"
+	"time"
+
 	"github.com/ehganzlieb/willfahren/adapter"
 	"github.com/ehganzlieb/willfahren/dto"
 	whclient "github.com/ehganzlieb/willfahren/whClient"
 )
 
+const MaxCrawls = 90 // crawls kept for History, e.g. three months of daily crawls
+
 // WHCache internally uses whclient but only dto externally
 type WHCache struct {
+	mu      sync.RWMutex
 	Adverts map[uint64]whclient.WHAdvert
+	crawls  []whCrawl
+}
+
+// whCrawl is the result of one crawl, kept for the history of the market.
+type whCrawl struct {
+	at      time.Time
+	adverts []whclient.WHAdvert
+}
+
+// Crawl is the set of apartments found by one crawl.
+type Crawl struct {
+	At         time.Time
+	Apartments []dto.Apartment
 }
 
 var whCache = WHCache{Adverts: make(map[uint64]whclient.WHAdvert)}
 
+// Ingest adds the adverts of a crawl made now, see IngestAt.
 func Ingest(wha []whclient.WHAdvert) {
+	IngestAt(wha, time.Now())
+}
+
+/*
+IngestAt adds the adverts of a crawl made at the given time, replacing older versions of the same adverts.
+The slice is copied, so the caller may reuse it. Only the last MaxCrawls crawls are kept for History.
+*/
+func IngestAt(wha []whclient.WHAdvert, at time.Time) {
+	whCache.mu.Lock()
+	defer whCache.mu.Unlock()
 	for _, v := range wha {
 		whCache.Adverts[v.ID] = v
 	}
+	whCache.crawls = append(whCache.crawls, whCrawl{at: at, adverts: slices.Clone(wha)})
+	if drop := len(whCache.crawls) - MaxCrawls; drop > 0 {
+		whCache.crawls = slices.Delete(whCache.crawls, 0, drop)
+	}
 }
+
+// All returns the latest version of every advert ingested so far.
 func All() []dto.Apartment {
-	apts := make([]dto.Apartment, len(whCache.Adverts))
-	for i, v := range whCache.Adverts {
-		apts[i] = *adapter.WHClientDtoAdapter(&v)
+	whCache.mu.RLock()
+	defer whCache.mu.RUnlock()
+	apts := make([]dto.Apartment, 0, len(whCache.Adverts))
+	for _, v := range whCache.Adverts {
+		apts = append(apts, *adapter.WHClientDtoAdapter(&v))
 	}
 	return apts
 }
+
+// History returns the apartments of the last MaxCrawls crawls, in the order they were ingested.
+func History() []Crawl {
+	whCache.mu.RLock()
+	defer whCache.mu.RUnlock()
+	history := make([]Crawl, len(whCache.crawls))
+	for i, c := range whCache.crawls {
+		history[i] = Crawl{At: c.at, Apartments: make([]dto.Apartment, len(c.adverts))}
+		for j := range c.adverts {
+			history[i].Apartments[j] = *adapter.WHClientDtoAdapter(&c.adverts[j])
+		}
+	}
+	return history
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/domain"
+	"github.com/ehganzlieb/willfahren/dto"
+	whclient "github.com/ehganzlieb/willfahren/whClient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWHCacheHistory(t *testing.T) {
+	whCache = WHCache{Adverts: make(map[uint64]whclient.WHAdvert)}
+	start := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+
+	crawl := []whclient.WHAdvert{advert(1948363437, "first")}
+	IngestAt(crawl, start)
+	// the caller reuses its slice for the next crawl
+	crawl[0] = advert(1287203409, "second")
+	IngestAt(crawl, start.Add(24*time.Hour))
+
+	history := History()
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, "first", history[0].Apartments[0].Title)
+		assert.Equal(t, "second", history[1].Apartments[0].Title)
+	}
+	assert.ElementsMatch(t, []string{"first", "second"}, titles(All()))
+
+	for day := range MaxCrawls {
+		IngestAt(nil, start.Add(time.Duration(day+2)*24*time.Hour))
+	}
+	history = History()
+	assert.Len(t, history, MaxCrawls)
+	assert.Equal(t, start.Add(2*24*time.Hour), history[0].At, "the oldest crawls are dropped")
+	assert.Len(t, All(), 2, "the latest version of every advert is kept")
+}
+
+func TestWHCacheMarket(t *testing.T) {
+	whCache = WHCache{Adverts: make(map[uint64]whclient.WHAdvert)}
+	rooms := []float64{2, 2, 3}
+	descriptions := []string{"Schöne Altbauwohnung mit hohen Räumen.", "Helle Wohnung im Neubau.", ""}
+	crawl := make([]whclient.WHAdvert, len(rooms))
+	for i := range crawl {
+		rent := 750.0 + 150*float64(i)
+		crawl[i] = advert(uint64(i+1), "Wohnung")
+		crawl[i].Description = descriptions[i]
+		crawl[i].Rooms = &rooms[i]
+		crawl[i].Rent = &rent
+	}
+	IngestAt(crawl, time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC))
+
+	m := domain.NewMarket(domain.NewImmoListings(All()))
+	assert.Equal(t, 3, m.Overall.Count)
+	assert.Equal(t, 2, m.Rooms["2"].Count)
+	assert.Equal(t, 1, m.Rooms["3"].Count)
+	// the building types are only known from the descriptions
+	assert.Equal(t, 1, m.BuildingTypes[dto.BuildingAltbau].Count)
+	assert.Equal(t, 1, m.BuildingTypes[dto.BuildingNeubau].Count)
+}
+
+func advert(id uint64, title string) whclient.WHAdvert {
+	area := uint64(50)
+	return whclient.WHAdvert{ID: id, Title: title, Area: &area, URL: &url.URL{}}
+}
+
+func titles(apts []dto.Apartment) []string {
+	res := make([]string, len(apts))
+	for i, a := range apts {
+		res[i] = a.Title
+	}
+	return res
+}
@@ -1,4 +1,185 @@
 package cache
 
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	wlclient "github.com/ehganzlieb/willfahren/wlClient"
+)
+
+const MinStopNameScore = 0.5 // stops matching a name query worse than this are not returned
+
+/*
+WLSnapshot is an immutable view of the transit network with indexes for the common lookups.
+Readers holding a snapshot keep seeing consistent data while the cache is refreshed.
+The snapshot and the lines and stops it references must not be modified.
+*/
+type WLSnapshot struct {
+	Lines    []dto.Line
+	Stops    map[dto.Line][]*dto.Stop
+	Routes   map[string]dto.LineRoutes // by line name
+	Versions map[string]wlclient.DatasetVersion
+	Loaded   time.Time
+
+	linesByName map[string]dto.Line
+	linesByType map[dto.LineType][]dto.Line
+	allStops    []*dto.Stop
+	stopIndex   *dto.SpatialIndex[*dto.Stop]
+}
+
+// StopMatch is a stop found by name together with how well it matched, from 0 to 1.
+type StopMatch struct {
+	Stop  *dto.Stop
+	Score float64
+}
+
+/*
+WLCache holds the transit network loaded from the Wiener Linien OGD datasets.
+It is safe for concurrent use: the network is replaced atomically by Refresh or Replace,
+while readers keep working on the snapshot they obtained before.
+*/
 type WLCache struct {
+	current atomic.Pointer[WLSnapshot]
+}
+
+var wlCache = NewWLCache()
+
+// NewWLCache returns a WLCache holding an empty network.
+func NewWLCache() *WLCache {
+	c := &WLCache{}
+	c.Replace(&wlclient.Network{})
+	return c
+}
+
+// Snapshot returns the current state of the cache.
+func (c *WLCache) Snapshot() *WLSnapshot {
+	return c.current.Load()
+}
+
+// Replace builds a new snapshot from the network and makes it the current one.
+func (c *WLCache) Replace(n *wlclient.Network) {
+	c.current.Store(newWLSnapshot(n))
+}
+
+/*
+Refresh loads the transit network with the given loader and replaces the cached one.
+The cache is left unchanged if loading fails.
+*/
+func (c *WLCache) Refresh(ctx context.Context, l *wlclient.Loader) error {
+	n, err := l.LoadNetwork(ctx)
+	if err != nil {
+		return err
+	}
+	c.Replace(n)
+	return nil
+}
+
+func newWLSnapshot(n *wlclient.Network) *WLSnapshot {
+	s := &WLSnapshot{
+		Lines:       n.Lines,
+		Stops:       n.Stops,
+		Routes:      n.Routes,
+		Versions:    n.Versions,
+		Loaded:      time.Now(),
+		linesByName: make(map[string]dto.Line),
+		linesByType: make(map[dto.LineType][]dto.Line),
+		stopIndex:   dto.NewSpatialIndex[*dto.Stop](0),
+	}
+	if s.Stops == nil {
+		s.Stops = make(map[dto.Line][]*dto.Stop)
+	}
+	if s.Routes == nil {
+		s.Routes = make(map[string]dto.LineRoutes)
+	}
+	for _, l := range s.Lines {
+		s.linesByName[l.Name] = l
+		s.linesByType[l.Type] = append(s.linesByType[l.Type], l)
+	}
+
+	seen := make(map[*dto.Stop]bool)
+	for _, l := range s.Lines {
+		for _, st := range s.Stops[l] {
+			if !seen[st] {
+				seen[st] = true
+				s.allStops = append(s.allStops, st)
+				s.stopIndex.Insert(st.Location, st)
+			}
+		}
+	}
+	return s
+}
+
+// Line returns the line with the given name.
+func (s *WLSnapshot) Line(name string) (dto.Line, bool) {
+	l, ok := s.linesByName[name]
+	return l, ok
+}
+
+// LinesOfType returns all lines of the given type.
+func (s *WLSnapshot) LinesOfType(lt dto.LineType) []dto.Line {
+	return s.linesByType[lt]
+}
+
+// StopsOfLine returns the stops of the line with the given name, or nil if there is no such line.
+func (s *WLSnapshot) StopsOfLine(name string) []*dto.Stop {
+	l, ok := s.linesByName[name]
+	if !ok {
+		return nil
+	}
+	return s.Stops[l]
+}
+
+// AllStops returns every stop of the network once.
+func (s *WLSnapshot) AllStops() []*dto.Stop {
+	return s.allStops
+}
+
+/*
+FindStops returns the stops whose name matches the query, best matches first.
+Matching is fuzzy (see dto.FuzzyScore), so "Schottentor" finds "Schottentor" as well as
+"Schottentor U". At most limit matches are returned, all of them if limit is not positive.
+*/
+func (s *WLSnapshot) FindStops(query string, limit int) []StopMatch {
+	matches := make([]StopMatch, 0)
+	for _, st := range s.allStops {
+		if score := dto.FuzzyScore(query, st.Name); score >= MinStopNameScore {
+			matches = append(matches, StopMatch{Stop: st, Score: score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b StopMatch) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// StopsInBox returns the stops inside the box given by its south-west and north-east corners.
+func (s *WLSnapshot) StopsInBox(sw, ne dto.Coordinates) []*dto.Stop {
+	found := s.stopIndex.InBox(sw, ne)
+	stops := make([]*dto.Stop, len(found))
+	for i, n := range found {
+		stops[i] = n.Item
+	}
+	return stops
+}
+
+// StopsNear returns the stops within radius meters of c, ordered by distance.
+func (s *WLSnapshot) StopsNear(c dto.Coordinates, radius float64) []dto.Neighbour[*dto.Stop] {
+	return s.stopIndex.Within(c, radius)
+}
+
+// RefreshWL refreshes the package-wide WLCache with the given loader.
+func RefreshWL(ctx context.Context, l *wlclient.Loader) error {
+	return wlCache.Refresh(ctx, l)
+}
+
+// WL returns the current snapshot of the package-wide WLCache.
+func WL() *WLSnapshot {
+	return wlCache.Snapshot()
 }
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	wlclient "github.com/ehganzlieb/willfahren/wlClient"
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshotNetwork(t *testing.T) *wlclient.Network {
+	lines, err := wlclient.ParseLinesCSV(wlclient.LinesDataset.Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stops, err := wlclient.ParseStopsCSV(wlclient.StopsDataset.Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wlclient.Network{Lines: lines, Stops: wlclient.AggregateStops(stops, lines)}
+}
+
+func TestWLCache(t *testing.T) {
+	c := NewWLCache()
+	assert.Empty(t, c.Snapshot().AllStops())
+
+	c.Replace(snapshotNetwork(t))
+	s := c.Snapshot()
+
+	u6, ok := s.Line("U6")
+	assert.True(t, ok)
+	assert.Equal(t, dto.LineTypeUBahn, u6.Type)
+	assert.Len(t, s.LinesOfType(dto.LineTypeUBahn), 5)
+	assert.NotEmpty(t, s.StopsOfLine("U6"))
+
+	matches := s.FindStops("Josefstadter Strase", 3)
+	if assert.NotEmpty(t, matches) {
+		assert.Contains(t, matches[0].Stop.Name, "Josefstädter Straße")
+	}
+
+	inBox := s.StopsInBox(dto.Coordinates{X: 16.36, Y: 48.20}, dto.Coordinates{X: 16.38, Y: 48.21})
+	assert.NotEmpty(t, inBox)
+	for _, st := range inBox {
+		assert.True(t, st.Location.X >= 16.36 && st.Location.X <= 16.38)
+	}
+
+	// readers keep their snapshot while the cache is replaced
+	u6Stops := slices.Clone(s.StopsOfLine("U6"))
+	allStops := len(s.AllStops())
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				snap := c.Snapshot()
+				if l, ok := snap.Line("U6"); ok {
+					assert.NotNil(t, snap.Stops[l])
+				}
+			}
+		}()
+	}
+	u1 := dto.Line{Name: "U1", Type: dto.LineTypeUBahn}
+	stephansplatz := &dto.Stop{Name: "Stephansplatz", Location: dto.Coordinates{X: 16.3725, Y: 48.2085}, Lines: &[]dto.Line{u1}}
+	c.Replace(&wlclient.Network{Lines: []dto.Line{u1}, Stops: map[dto.Line][]*dto.Stop{u1: {stephansplatz}}})
+	wg.Wait()
+
+	// the old snapshot still holds the network from before the swap
+	_, ok = s.Line("U6")
+	assert.True(t, ok)
+	assert.Equal(t, u6Stops, s.StopsOfLine("U6"))
+	assert.Len(t, s.AllStops(), allStops)
+	assert.NotEmpty(t, s.FindStops("Josefstädter Straße", 1))
+
+	// while the new one only holds the new network
+	now := c.Snapshot()
+	_, ok = now.Line("U6")
+	assert.False(t, ok)
+	assert.Equal(t, []*dto.Stop{stephansplatz}, now.StopsOfLine("U1"))
+	assert.Equal(t, []*dto.Stop{stephansplatz}, now.AllStops())
+	assert.Empty(t, now.FindStops("Josefstädter Straße", 1))
+}
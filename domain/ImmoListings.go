@@ -34,28 +34,44 @@ func MergeFilters(filters ...ImmoListingsFilter) ImmoListingsFilter {
 	}
 }
 
+// AnyOf returns a filter function that keeps ImmoListings passing at least one of the given filters.
+func AnyOf(filters ...ImmoListingsFilter) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		for _, f := range filters {
+			if f(il) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 /*
 ApplyFilter applies the given filter to the ImmoListings.
-The filter is applied by deleting all elements from the ImmoListings
-that do not match the filter. The original ImmoListings is not modified.
-The modified ImmoListings is returned.
+It returns a new ImmoListings holding only the elements that
+match the filter; the original ImmoListings is not modified.
+Only the matching elements are copied; to filter without
+copying, use View.
 */
 func (il ImmoListings) ApplyFilter(filter ImmoListingsFilter) ImmoListings {
-	var ls []ImmoListing = []ImmoListing(il)
-	var lc []ImmoListing = slices.Clone(ls)
-	invFilter := InvertImmoListingsFilter(filter)
-	return slices.DeleteFunc(lc, invFilter)
+	res := make(ImmoListings, 0)
+	for _, l := range il {
+		if filter(l) {
+			res = append(res, l)
+		}
+	}
+	return res
 }
 
 /*
 FilterDistricts returns a filter function that filters ImmoListings
 based on their district. The filter function takes a slice of
 dto.District objects and returns true if the ImmoListing's district
-is in the slice, false otherwise.
+is in the slice, false otherwise or if the listing states no district.
 */
 func FilterDistricts(districts []dto.District) ImmoListingsFilter {
 	return func(il ImmoListing) bool {
-		return slices.ContainsFunc(districts, func(d dto.District) bool {
+		return il.District != nil && slices.ContainsFunc(districts, func(d dto.District) bool {
 			return il.District.PostCode() == d.PostCode()
 		})
 	}
@@ -73,7 +89,8 @@ greater than or equal to minRooms. If both minRooms and
 maxRooms are 0, it returns a filter function that always
 returns true. If minRooms and maxRooms are both non-zero, it
 filters listings with a number of rooms greater than or equal
-to minRooms and less than or equal to maxRooms.
+to minRooms and less than or equal to maxRooms. Unless both are 0,
+listings whose number of rooms is unknown (0) are removed.
 */
 func FilterRooms(minRooms, maxRooms int) ImmoListingsFilter {
 	if minRooms == 0 && maxRooms == 0 {
@@ -83,7 +100,7 @@ func FilterRooms(minRooms, maxRooms int) ImmoListingsFilter {
 	}
 	if minRooms == 0 {
 		return func(il ImmoListing) bool {
-			return il.Rooms <= float32(maxRooms)
+			return il.Rooms != 0 && il.Rooms <= float32(maxRooms)
 		}
 	}
 	if maxRooms == 0 {
@@ -96,16 +113,28 @@ func FilterRooms(minRooms, maxRooms int) ImmoListingsFilter {
 	}
 }
 
+/*
+MonthlyCost returns the total monthly cost of the listing in euros (see dto.PriceBreakdown.Monthly).
+ok is false if the cost is unknown, zero or not in euros.
+*/
+func (il ImmoListing) MonthlyCost() (cost float32, ok bool) {
+	m, err := il.Price.Monthly()
+	if err != nil || m.Currency != dto.CurrencyEUR || m.Cents <= 0 {
+		return 0, false
+	}
+	return float32(m.Float()), true
+}
+
 /*
 FilterPrice returns a filter function that filters ImmoListings
-based on their price. If minPrice is 0, it filters listings
+based on their total monthly cost in euros (see MonthlyCost). If minPrice is 0, it filters listings
 with a price less than or equal to maxPrice. If maxPrice is 0,
 it filters listings with a price greater than or equal to
 minPrice. If both minPrice and maxPrice are 0, it returns a
 filter function that always returns true. If minPrice and
 maxPrice are both non-zero, it filters listings with a price
 greater than or equal to minPrice and less than or equal to
-maxPrice.
+maxPrice. Unless both are 0, listings whose cost is unknown are removed.
 */
 func FilterPrice(minPrice, maxPrice float32) ImmoListingsFilter {
 	if minPrice == 0 && maxPrice == 0 {
@@ -115,16 +144,19 @@ func FilterPrice(minPrice, maxPrice float32) ImmoListingsFilter {
 	}
 	if minPrice == 0 {
 		return func(il ImmoListing) bool {
-			return il.Price <= maxPrice
+			cost, ok := il.MonthlyCost()
+			return ok && cost <= maxPrice
 		}
 	}
 	if maxPrice == 0 {
 		return func(il ImmoListing) bool {
-			return il.Price >= minPrice
+			cost, ok := il.MonthlyCost()
+			return ok && cost >= minPrice
 		}
 	}
 	return func(il ImmoListing) bool {
-		return il.Price >= minPrice && il.Price <= maxPrice
+		cost, ok := il.MonthlyCost()
+		return ok && cost >= minPrice && cost <= maxPrice
 	}
 }
 
@@ -137,7 +169,7 @@ minArea. If both minArea and maxArea are 0, it returns a
 filter function that always returns true. If minArea and
 maxArea are both non-zero, it filters listings with an area
 greater than or equal to minArea and less than or equal to
-maxArea.
+maxArea. Unless both are 0, listings whose area is unknown (0) are removed.
 */
 func FilterArea(minArea, maxArea float32) ImmoListingsFilter {
 	if minArea == 0 && maxArea == 0 {
@@ -147,7 +179,7 @@ func FilterArea(minArea, maxArea float32) ImmoListingsFilter {
 	}
 	if minArea == 0 {
 		return func(il ImmoListing) bool {
-			return il.Area <= maxArea
+			return il.Area != 0 && il.Area <= maxArea
 		}
 	}
 	if maxArea == 0 {
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterPriceUnknown(t *testing.T) {
+	listings := ImmoListings{
+		{ID: 1, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(900)}},
+		{ID: 2},
+		{ID: 3, Price: dto.PriceBreakdown{TotalMonthly: dto.Money{Cents: 90000, Currency: "CHF"}}},
+		{ID: 4, Price: dto.PriceBreakdown{NetRent: dto.EUR(600), OperatingCosts: dto.EUR(150)}},
+	}
+	_, ok := listings[1].MonthlyCost()
+	assert.False(t, ok)
+	cost, ok := listings[3].MonthlyCost()
+	assert.True(t, ok)
+	assert.InDelta(t, 750, cost, 1e-3)
+
+	// unknown and foreign prices never pass a bound, the same as with the explained filter
+	for _, bounds := range [][2]float32{{0, 1000}, {500, 0}, {500, 1000}} {
+		assert.Equal(t, []uint64{1, 4}, ids(listings.ApplyFilter(FilterPrice(bounds[0], bounds[1]))), bounds)
+		for _, l := range listings {
+			assert.Equal(t, FilterPrice(bounds[0], bounds[1])(l), ExplainPrice(bounds[0], bounds[1])(l).Passed, l.ID)
+		}
+	}
+	assert.Len(t, listings.ApplyFilter(FilterPrice(0, 0)), 4)
+}
+
+func TestFilterRoomsAreaUnknown(t *testing.T) {
+	listings := ImmoListings{{ID: 1, Rooms: 2, Area: 50}, {ID: 2}}
+	assert.Equal(t, []uint64{1}, ids(listings.ApplyFilter(FilterRooms(0, 3))))
+	assert.Equal(t, []uint64{1}, ids(listings.ApplyFilter(FilterArea(0, 60))))
+	for _, l := range listings {
+		assert.Equal(t, FilterRooms(0, 3)(l), ExplainRooms(0, 3)(l).Passed, l.ID)
+		assert.Equal(t, FilterArea(0, 60)(l), ExplainArea(0, 60)(l).Passed, l.ID)
+	}
+	assert.Len(t, listings.ApplyFilter(FilterRooms(0, 0)), 2)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"regexp"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const BarrierFreeMaxDistance = 500.0 // m, default distance for FilterNearBarrierFree
+
+// ElevatorOutages are the current elevator outages by normalized station name.
+type ElevatorOutages map[string][]dto.ElevatorOutage
+
+// NewElevatorOutages groups the outages as returned by wlclient.FetchElevatorOutages by station.
+func NewElevatorOutages(outages []dto.ElevatorOutage) ElevatorOutages {
+	o := make(ElevatorOutages)
+	for _, out := range outages {
+		name := dto.NormalizeName(out.Station)
+		o[name] = append(o[name], out)
+	}
+	return o
+}
+
+// At returns the outages at the given stop.
+func (o ElevatorOutages) At(s *dto.Stop) []dto.ElevatorOutage {
+	return o[dto.NormalizeName(s.Name)]
+}
+
+/*
+BarrierFree reports whether the stop is known to be step-free and no elevator outage is reported for it.
+A stop with an elevator outage is not considered barrier-free even if it has further elevators,
+as they usually serve different platforms. outages may be nil.
+*/
+func BarrierFree(s *dto.Stop, outages ElevatorOutages) bool {
+	if s.Accessibility == nil || !s.Accessibility.StepFree {
+		return false
+	}
+	return len(outages.At(s)) == 0
+}
+
+/*
+FilterNearBarrierFree returns a filter function that keeps ImmoListings with a barrier-free
+stop (see BarrierFree) of one of the given line types within maxDistance meters. Stops whose
+accessibility is unknown never count, whatever their line type.
+If no line types are given, DefaultNearestStopLineTypes are used. Listings without location are removed.
+*/
+func FilterNearBarrierFree(si *StopIndex, maxDistance float64, outages ElevatorOutages, lineTypes ...dto.LineType) ImmoListingsFilter {
+	if len(lineTypes) == 0 {
+		lineTypes = DefaultNearestStopLineTypes
+	}
+	return func(il ImmoListing) bool {
+		if il.Location == nil {
+			return false
+		}
+		for _, lt := range lineTypes {
+			for _, n := range si.Within(*il.Location, lt, maxDistance) {
+				if BarrierFree(n.Item, outages) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+var (
+	noLiftPattern = regexp.MustCompile(`(?i)\b(kein(en)?|ohne|nicht mit)\s+(personen)?(lift|aufzug|fahrstuhl)|\b(lift|aufzug|fahrstuhl)\s*:?\s*(nein|nicht vorhanden|keiner)`)
+	liftPattern   = regexp.MustCompile(`(?i)(lift|aufzug|fahrstuhl)`)
+)
+
+/*
+AdvertisesLift tells whether the listing has a lift, from its Lift attribute if the source provides it,
+otherwise by looking for a lift in the title and description.
+known is false if a lift is neither mentioned nor explicitly denied ("kein Lift", "ohne Aufzug").
+*/
+func (il ImmoListing) AdvertisesLift() (hasLift, known bool) {
+	if il.Lift != nil {
+		return *il.Lift, true
+	}
+	text := il.Title + "\n" + il.Description
+	switch {
+	case noLiftPattern.MatchString(text):
+		return false, true
+	case liftPattern.MatchString(text):
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// LiftScore rates the listing 1 if it advertises a lift, 0 if it denies having one and 0.5 if it does not tell.
+func (il ImmoListing) LiftScore() float64 {
+	switch hasLift, known := il.AdvertisesLift(); {
+	case !known:
+		return 0.5
+	case hasLift:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// FilterLift returns a filter function that keeps ImmoListings with a LiftScore of at least minScore.
+func FilterLift(minScore float64) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.LiftScore() >= minScore
+	}
+}
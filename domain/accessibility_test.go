@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiftScore(t *testing.T) {
+	for text, score := range map[string]float64{
+		"Altbau mit Lift, 3. Stock":            1,
+		"Neubau, Personenaufzug vorhanden":     1,
+		"4. Stock ohne Lift":                   0,
+		"Leider kein Aufzug im Haus":           0,
+		"Lift: nein":                           0,
+		"Helle Wohnung mit Balkon, ruhig":      0.5,
+		"Dachgeschoss, Fahrstuhl bis 5. Stock": 1,
+	} {
+		il := ImmoListing{Description: text}
+		assert.Equal(t, score, il.LiftScore(), text)
+	}
+}
+
+func TestFilterNearBarrierFree(t *testing.T) {
+	u6 := dto.Line{Name: "U6", Type: dto.LineTypeUBahn}
+	station := &dto.Stop{
+		Name:          "Josefstädter Straße",
+		Location:      dto.Coordinates{X: 16.3387, Y: 48.2114},
+		Lines:         &[]dto.Line{u6},
+		Accessibility: &dto.Accessibility{StepFree: true},
+	}
+	tram := &dto.Stop{Name: "Albertgasse", Location: dto.Coordinates{X: 16.3405, Y: 48.2115}, Lines: &[]dto.Line{{Name: "33", Type: dto.LineTypeTram}}}
+	si := NewStopIndex(map[dto.Line][]*dto.Stop{u6: {station}, (*tram.Lines)[0]: {tram}})
+
+	near := ImmoListing{Location: &dto.Coordinates{X: 16.3400, Y: 48.2120}}
+	far := ImmoListing{Location: &dto.Coordinates{X: 16.3700, Y: 48.2120}}
+	filter := FilterNearBarrierFree(si, BarrierFreeMaxDistance, nil)
+	assert.True(t, filter(near))
+	assert.False(t, filter(far))
+	assert.False(t, filter(ImmoListing{}))
+	assert.False(t, BarrierFree(&dto.Stop{Name: "Westbahnhof", Lines: &[]dto.Line{u6}}, nil), "U-Bahn stop of unknown accessibility")
+
+	outages := NewElevatorOutages([]dto.ElevatorOutage{{Station: "Josefstaedter Strasse"}, {Station: "Josefstädter Straße"}})
+	assert.False(t, FilterNearBarrierFree(si, BarrierFreeMaxDistance, outages)(near))
+	assert.False(t, BarrierFree(station, outages))
+	assert.False(t, BarrierFree(tram, nil), "accessibility unknown")
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+/*
+FilterOutdoorSpace returns a filter function that keeps ImmoListings with an outdoor space of one
+of the given types of at least minArea m². If no types are given, any outdoor space counts.
+If minArea is 0, spaces of unknown size count as well.
+*/
+func FilterOutdoorSpace(minArea float32, types ...dto.OutdoorSpaceType) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return slices.ContainsFunc(il.OutdoorSpaces, func(os dto.OutdoorSpace) bool {
+			return (len(types) == 0 || slices.Contains(types, os.Type)) && os.Area >= minArea
+		})
+	}
+}
+
+// FilterCellar returns a filter function that keeps ImmoListings with a cellar compartment.
+func FilterCellar() ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.Cellar != nil && *il.Cellar
+	}
+}
+
+// FilterParking returns a filter function that keeps ImmoListings with one of the given kinds of parking.
+func FilterParking(types ...dto.ParkingType) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return slices.Contains(types, il.Parking)
+	}
+}
+
+// FilterFurnishing returns a filter function that keeps ImmoListings with one of the given furnishing levels.
+func FilterFurnishing(levels ...dto.Furnishing) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return slices.Contains(levels, il.Furnishing)
+	}
+}
+
+// FilterHeating returns a filter function that keeps ImmoListings with one of the given heating types.
+func FilterHeating(types ...dto.HeatingType) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return slices.Contains(types, il.Heating)
+	}
+}
+
+// FilterEnergyClass returns a filter function that keeps ImmoListings with an energy class of worst or better.
+func FilterEnergyClass(worst dto.EnergyClass) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.EnergyClass.BetterThanOrEqual(worst)
+	}
+}
+
+/*
+FilterYearBuilt returns a filter function that keeps ImmoListings built between minYear and maxYear.
+A bound of 0 is open, as with FilterRooms. Listings with unknown year of construction are removed
+unless both bounds are 0.
+*/
+func FilterYearBuilt(minYear, maxYear int) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		if minYear == 0 && maxYear == 0 {
+			return true
+		}
+		return il.YearBuilt != 0 &&
+			(minYear == 0 || il.YearBuilt >= minYear) &&
+			(maxYear == 0 || il.YearBuilt <= maxYear)
+	}
+}
+
+// FilterBuildingTypes returns a filter function that keeps ImmoListings in one of the given building types.
+func FilterBuildingTypes(types ...dto.BuildingType) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return slices.Contains(types, il.BuildingType)
+	}
+}
+
+// FilterAvailableBy returns a filter function that keeps ImmoListings available at or before t.
+func FilterAvailableBy(t time.Time) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.AvailableFrom != nil && !il.AvailableFrom.After(t)
+	}
+}
+
+/*
+FilterRentalTerm returns a filter function that keeps ImmoListings with an unlimited lease or, if
+minMonths is not 0, a limited lease of at least minMonths months. Limited leases of unknown
+duration are removed unless minMonths is 0 and limited leases are accepted.
+*/
+func FilterRentalTerm(acceptLimited bool, minMonths int) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		switch {
+		case il.RentalTerm == nil:
+			return false
+		case !il.RentalTerm.Limited:
+			return true
+		case !acceptLimited:
+			return false
+		default:
+			return minMonths == 0 || il.RentalTerm.Months >= minMonths
+		}
+	}
+}
+
+// FilterPetsAllowed returns a filter function that keeps ImmoListings where pets are allowed.
+func FilterPetsAllowed() ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.PetsAllowed != nil && *il.PetsAllowed
+	}
+}
+
+// FilterCondition returns a filter function that keeps ImmoListings in one of the given conditions.
+func FilterCondition(conditions ...dto.Condition) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return slices.Contains(conditions, il.Condition)
+	}
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeFilters(t *testing.T) {
+	yes, no := true, false
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	full := ImmoListing{ID: 1, Attributes: dto.Attributes{
+		OutdoorSpaces: []dto.OutdoorSpace{{Type: dto.OutdoorSpaceBalcony, Area: 6}},
+		Lift:          &no,
+		Cellar:        &yes,
+		Parking:       dto.ParkingGarage,
+		Furnishing:    dto.PartlyFurnished,
+		Heating:       dto.HeatingDistrict,
+		EnergyClass:   dto.EnergyClassB,
+		YearBuilt:     1905,
+		BuildingType:  dto.BuildingAltbau,
+		AvailableFrom: &may,
+		RentalTerm:    &dto.RentalTerm{Limited: true, Months: 36},
+		PetsAllowed:   &yes,
+		Condition:     dto.ConditionRenovated,
+	}}
+	unknown := ImmoListing{ID: 2, Description: "Wohnung mit Lift"}
+
+	for name, tc := range map[string]struct {
+		filter        ImmoListingsFilter
+		full, unknown bool
+	}{
+		"any outdoor space":   {FilterOutdoorSpace(0), true, false},
+		"large balcony":       {FilterOutdoorSpace(10, dto.OutdoorSpaceBalcony), false, false},
+		"terrace":             {FilterOutdoorSpace(0, dto.OutdoorSpaceTerrace), false, false},
+		"cellar":              {FilterCellar(), true, false},
+		"garage":              {FilterParking(dto.ParkingGarage), true, false},
+		"furnished":           {FilterFurnishing(dto.Furnished), false, false},
+		"district heating":    {FilterHeating(dto.HeatingDistrict, dto.HeatingHeatPump), true, false},
+		"energy class C":      {FilterEnergyClass(dto.EnergyClassC), true, false},
+		"energy class A":      {FilterEnergyClass(dto.EnergyClassA), false, false},
+		"built before 1919":   {FilterYearBuilt(0, 1918), true, false},
+		"any year":            {FilterYearBuilt(0, 0), true, true},
+		"Altbau":              {FilterBuildingTypes(dto.BuildingAltbau), true, false},
+		"available by June":   {FilterAvailableBy(may.AddDate(0, 1, 0)), true, false},
+		"available by April":  {FilterAvailableBy(may.AddDate(0, -1, 0)), false, false},
+		"unlimited only":      {FilterRentalTerm(false, 0), false, false},
+		"at least 3 years":    {FilterRentalTerm(true, 36), true, false},
+		"at least 5 years":    {FilterRentalTerm(true, 60), false, false},
+		"pets":                {FilterPetsAllowed(), true, false},
+		"renovated":           {FilterCondition(dto.ConditionRenovated, dto.ConditionFirstOccupancy), true, false},
+		"lift from attribute": {FilterLift(1), false, true},
+	} {
+		assert.Equal(t, tc.full, tc.filter(full), name)
+		assert.Equal(t, tc.unknown, tc.filter(unknown), name)
+	}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	WalkingSpeed       = 1.3   // m/s, average walking speed
+	TransitSpeed       = 6.0   // m/s, average transit speed including stops and transfers
+	TransitDetour      = 1.3   // factor between straight-line and track distance
+	WalkingDetour      = 1.25  // factor between straight-line and walking distance
+	MaxWalkingDistance = 800.0 // m, above this distance the estimate assumes public transport is taken
+
+	TransitOverhead      = 6 * time.Minute  // average time spent getting to the stop and waiting
+	NightTransitOverhead = 15 * time.Minute // same as TransitOverhead, but with night service intervals
+)
+
+/*
+TravelTimeFunc computes the travel time between two coordinates when leaving at departAt.
+It returns an error if no travel time can be computed.
+*/
+type TravelTimeFunc func(from, to dto.Coordinates, departAt time.Time) (time.Duration, error)
+
+/*
+EstimateTravelTime estimates the travel time between two coordinates without any knowledge of the transit network.
+It is a straight-line guess, not a transit travel time: lines, timetables and transfers are ignored.
+For travel times along the transit network, use TransitGraph.TravelTime.
+
+Short distances are walked. For longer distances, the time is estimated from the straight-line distance
+using an average transit speed plus a fixed overhead for getting to the stop and waiting, which is higher
+at night. The walking time is returned if it is shorter than the transit estimate.
+The returned error is always nil.
+*/
+func EstimateTravelTime(from, to dto.Coordinates, departAt time.Time) (time.Duration, error) {
+	distance := from.Distance(to, dto.DistanceFormulaDefault)
+	walking := time.Duration(distance * WalkingDetour / WalkingSpeed * float64(time.Second))
+	if distance <= MaxWalkingDistance {
+		return walking, nil
+	}
+
+	overhead := TransitOverhead
+	if hour := departAt.Hour(); hour >= 1 && hour < 5 {
+		overhead = NightTransitOverhead
+	}
+	transit := overhead + time.Duration(distance*TransitDetour/TransitSpeed*float64(time.Second))
+	return min(walking, transit), nil
+}
+
+/*
+CommuteTarget describes a place that has to be reached from a listing within a given time budget.
+Targets are told apart by Name, Location and DepartAt together, so unnamed targets do not get mixed up.
+*/
+type CommuteTarget struct {
+	Name        string // used to label the commute attached to a listing
+	Location    dto.Coordinates
+	DepartAt    time.Time
+	MaxDuration time.Duration
+}
+
+// isFor tells whether the commute was computed for the target.
+func (t CommuteTarget) isFor(c dto.Commute) bool {
+	return c.Target == t.Name && c.Location == t.Location && c.DepartAt.Equal(t.DepartAt)
+}
+
+/*
+commute returns the commute from the ImmoListing to the target.
+If the listing already carries a commute for the target, that one is returned,
+otherwise it is computed with computeCommute.
+*/
+func (il ImmoListing) commute(travelTime TravelTimeFunc, target CommuteTarget) (dto.Commute, bool) {
+	for _, c := range il.Commutes {
+		if target.isFor(c) {
+			return c, true
+		}
+	}
+	return computeCommute(travelTime, il.Location, target)
+}
+
+/*
+computeCommute computes the commute from location to the target using travelTime.
+The second return value is false if location is nil or the travel time could not be computed.
+*/
+func computeCommute(travelTime TravelTimeFunc, location *dto.Coordinates, target CommuteTarget) (dto.Commute, bool) {
+	if location == nil {
+		return dto.Commute{}, false
+	}
+	d, err := travelTime(*location, target.Location, target.DepartAt)
+	if err != nil {
+		return dto.Commute{}, false
+	}
+	return dto.Commute{Target: target.Name, Location: target.Location, DepartAt: target.DepartAt, Duration: d}, true
+}
+
+/*
+FilterCommute returns a filter function that filters ImmoListings
+based on their commute to a single target, computed with travelTime,
+e.g. EstimateTravelTime or TransitGraph.TravelTime. The filter function
+returns true if the target can be reached within maxDuration when
+leaving at departAt. Listings without location never pass.
+*/
+func FilterCommute(travelTime TravelTimeFunc, target dto.Coordinates, departAt time.Time, maxDuration time.Duration) ImmoListingsFilter {
+	return FilterCommutes(travelTime, CommuteTarget{
+		Location:    target,
+		DepartAt:    departAt,
+		MaxDuration: maxDuration,
+	})
+}
+
+/*
+FilterCommutes returns a filter function that filters ImmoListings
+based on their commute to several targets, e.g. the workplaces of
+both partners. The filter function returns true if every target can
+be reached within its own MaxDuration. Commutes that have already been
+attached with AnnotateCommutes for the same target are reused, others
+are computed with travelTime. If no targets are given, it returns a
+filter function that always returns true.
+*/
+func FilterCommutes(travelTime TravelTimeFunc, targets ...CommuteTarget) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		for _, t := range targets {
+			c, ok := il.commute(travelTime, t)
+			if !ok || c.Duration > t.MaxDuration {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+/*
+AnnotateCommutes computes the commute from every ImmoListing to each of the targets with travelTime
+and attaches it to the listing's Commutes, replacing earlier commutes to the same target or, for
+named targets, to a target with the same name. Listings for which no commute can be computed are
+left without a commute for that target.
+The original ImmoListings is not modified, the annotated copy is returned.
+*/
+func (il ImmoListings) AnnotateCommutes(travelTime TravelTimeFunc, targets ...CommuteTarget) ImmoListings {
+	lc := slices.Clone(il)
+	for i := range lc {
+		commutes := slices.Clone(lc[i].Commutes)
+		for _, t := range targets {
+			commutes = slices.DeleteFunc(commutes, func(c dto.Commute) bool {
+				return t.isFor(c) || (t.Name != "" && c.Target == t.Name)
+			})
+			if c, ok := computeCommute(travelTime, lc[i].Location, t); ok {
+				commutes = append(commutes, c)
+			}
+		}
+		lc[i].Commutes = commutes
+	}
+	return lc
+}
+
+/*
+WithinCommute annotates the ImmoListings with their commutes to the targets and
+returns only those listings that reach every target within its budget.
+The original ImmoListings is not modified.
+*/
+func (il ImmoListings) WithinCommute(travelTime TravelTimeFunc, targets ...CommuteTarget) ImmoListings {
+	return il.AnnotateCommutes(travelTime, targets...).ApplyFilter(FilterCommutes(travelTime, targets...))
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	stephansplatz = dto.Coordinates{X: 16.3725, Y: 48.2085}
+	floridsdorf   = dto.Coordinates{X: 16.4003, Y: 48.2567}
+	karlsplatz    = dto.Coordinates{X: 16.3695, Y: 48.2004}
+	eightAM       = time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+)
+
+// fixedTravelTime returns the duration set for the destination and counts its calls.
+type fixedTravelTime struct {
+	durations map[dto.Coordinates]time.Duration
+	calls     int
+}
+
+func (f *fixedTravelTime) travelTime(_, to dto.Coordinates, _ time.Time) (time.Duration, error) {
+	f.calls++
+	d, ok := f.durations[to]
+	if !ok {
+		return 0, errors.New("destination not reachable")
+	}
+	return d, nil
+}
+
+func TestEstimateTravelTime(t *testing.T) {
+	// about 90 m apart: walked
+	d, err := EstimateTravelTime(stephansplatz, dto.Coordinates{X: 16.3737, Y: 48.2085}, eightAM)
+	assert.NoError(t, err)
+	assert.InDelta(t, 90*WalkingDetour/WalkingSpeed, d.Seconds(), 2)
+
+	// about 5.8 km apart: transit, with a longer wait at night
+	day, _ := EstimateTravelTime(stephansplatz, floridsdorf, eightAM)
+	night, _ := EstimateTravelTime(stephansplatz, floridsdorf, eightAM.Add(-5*time.Hour))
+	assert.True(t, day > TransitOverhead)
+	assert.Equal(t, NightTransitOverhead-TransitOverhead, night-day)
+}
+
+func TestFilterCommutes(t *testing.T) {
+	tt := &fixedTravelTime{durations: map[dto.Coordinates]time.Duration{
+		stephansplatz: 20 * time.Minute,
+		floridsdorf:   40 * time.Minute,
+	}}
+	listing := ImmoListing{ID: 1, Location: &dto.Coordinates{X: 16.35, Y: 48.2}}
+	unreachable := dto.Coordinates{X: 0, Y: 0}
+
+	for name, tc := range map[string]struct {
+		targets []CommuteTarget
+		want    bool
+	}{
+		"no targets":   {nil, true},
+		"within":       {[]CommuteTarget{{Location: stephansplatz, MaxDuration: 20 * time.Minute}}, true},
+		"too long":     {[]CommuteTarget{{Location: floridsdorf, MaxDuration: 30 * time.Minute}}, false},
+		"unreachable":  {[]CommuteTarget{{Location: unreachable, MaxDuration: time.Hour}}, false},
+		"all targets":  {[]CommuteTarget{{Location: stephansplatz, MaxDuration: 30 * time.Minute}, {Location: floridsdorf, MaxDuration: 45 * time.Minute}}, true},
+		"one too long": {[]CommuteTarget{{Location: stephansplatz, MaxDuration: 30 * time.Minute}, {Location: floridsdorf, MaxDuration: 30 * time.Minute}}, false},
+	} {
+		assert.Equal(t, tc.want, FilterCommutes(tt.travelTime, tc.targets...)(listing), name)
+	}
+
+	assert.False(t, FilterCommute(tt.travelTime, stephansplatz, eightAM, time.Hour)(ImmoListing{ID: 2}), "listing without location")
+}
+
+func TestAnnotateCommutes(t *testing.T) {
+	tt := &fixedTravelTime{durations: map[dto.Coordinates]time.Duration{
+		stephansplatz: 20 * time.Minute,
+		floridsdorf:   40 * time.Minute,
+		karlsplatz:    50 * time.Minute,
+	}}
+	il := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.35, Y: 48.2}},
+		{ID: 2},
+	}
+	// two unnamed targets must not be mixed up
+	toCentre := CommuteTarget{Location: stephansplatz, DepartAt: eightAM, MaxDuration: 30 * time.Minute}
+	toFloridsdorf := CommuteTarget{Location: floridsdorf, DepartAt: eightAM, MaxDuration: 30 * time.Minute}
+
+	annotated := il.AnnotateCommutes(tt.travelTime, toCentre, toFloridsdorf)
+	assert.Empty(t, il[0].Commutes, "original not modified")
+	assert.Empty(t, annotated[1].Commutes, "listing without location")
+	assert.Equal(t, []dto.Commute{
+		{Location: stephansplatz, DepartAt: eightAM, Duration: 20 * time.Minute},
+		{Location: floridsdorf, DepartAt: eightAM, Duration: 40 * time.Minute},
+	}, annotated[0].Commutes)
+
+	// annotated commutes are reused, not recomputed
+	tt.calls = 0
+	assert.True(t, FilterCommutes(tt.travelTime, toCentre)(annotated[0]))
+	assert.False(t, FilterCommutes(tt.travelTime, toFloridsdorf)(annotated[0]))
+	assert.Zero(t, tt.calls)
+
+	// a moved target or a different departure time is recomputed
+	moved := toCentre
+	moved.Location = karlsplatz
+	assert.False(t, FilterCommutes(tt.travelTime, moved)(annotated[0]))
+	later := toCentre
+	later.DepartAt = eightAM.Add(time.Hour)
+	assert.True(t, FilterCommutes(tt.travelTime, later)(annotated[0]))
+	assert.Equal(t, 2, tt.calls)
+
+	// re-annotating a named target replaces its commute
+	work := CommuteTarget{Name: "work", Location: stephansplatz, MaxDuration: 30 * time.Minute}
+	annotated = il.AnnotateCommutes(tt.travelTime, work)
+	work.Location = floridsdorf
+	annotated = annotated.AnnotateCommutes(tt.travelTime, work)
+	assert.Equal(t, []dto.Commute{{Target: "work", Location: floridsdorf, Duration: 40 * time.Minute}}, annotated[0].Commutes)
+}
+
+func TestWithinCommute(t *testing.T) {
+	tt := &fixedTravelTime{durations: map[dto.Coordinates]time.Duration{stephansplatz: 20 * time.Minute}}
+	il := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.35, Y: 48.2}},
+		{ID: 2},
+	}
+	res := il.WithinCommute(tt.travelTime, CommuteTarget{Name: "work", Location: stephansplatz, MaxDuration: 30 * time.Minute})
+	assert.Equal(t, []uint64{1}, ids(res))
+	assert.Len(t, res[0].Commutes, 1)
+}
@@ -0,0 +1,373 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	MinExtractionConfidence = 0.6 // default confidence for ApplyDetails
+	snippetContext          = 30  // runes of context on either side of a match
+)
+
+// Extracted is a value found in a listing description, with how sure the extractor is about it and where it was found.
+type Extracted[T any] struct {
+	Value      T
+	Confidence float64 // between 0 and 1
+	Snippet    string  // the matched text with some context
+}
+
+/*
+DescriptionDetails are the attributes ExtractDetails found in a description. A nil field was not mentioned.
+A value of false or zero means the description explicitly denies it, e.g. "keine Haustiere" or "ablösefrei".
+*/
+type DescriptionDetails struct {
+	RentalTerm     *Extracted[dto.RentalTerm]
+	CommissionFree *Extracted[bool]
+	TransferFee    *Extracted[dto.Money] // Ablöse for furniture or kitchen paid to the previous tenant
+	AvailableFrom  *Extracted[time.Time]
+	PetsAllowed    *Extracted[bool]
+	SharedFlat     *Extracted[bool] // suitable for a shared flat (WG-geeignet)
+	Furnishing     *Extracted[dto.Furnishing]
+	BuildingType   *Extracted[dto.BuildingType]
+	Cellar         *Extracted[bool]
+}
+
+// extractionRule maps the matches of a pattern to a value; ok is false if the match cannot be interpreted.
+type extractionRule[T any] struct {
+	pattern    *regexp.Regexp
+	confidence float64
+	value      func(m []string, now time.Time) (T, bool)
+}
+
+func constant[T any](v T) func([]string, time.Time) (T, bool) {
+	return func([]string, time.Time) (T, bool) { return v, true }
+}
+
+// negated is the optional negation captured as first group by patterns using unlessNegated, e.g. "kein" in "kein Altbau".
+const negated = `(\b(?:kein(?:e[nmrs]?)?|nicht|ohne)\s+)?`
+
+// unlessNegated is like constant, but rejects matches whose first group, see negated, is set.
+func unlessNegated[T any](v T) func([]string, time.Time) (T, bool) {
+	return func(m []string, _ time.Time) (T, bool) { return v, m[1] == "" }
+}
+
+// umlaut patterns, as descriptions are written with and without umlauts
+const (
+	ae = `(?:ä|ae|a)`
+	oe = `(?:ö|oe|o)`
+	ue = `(?:ü|ue|u)`
+)
+
+// numberWords are the German number words used for lease terms, like "befristet auf drei Jahre".
+var numberWords = map[string]int{
+	"ein": 1, "einen": 1, "zwei": 2, "drei": 3, "vier": 4, "funf": 5, "fünf": 5,
+	"sechs": 6, "sieben": 7, "acht": 8, "neun": 9, "zehn": 10, "zwolf": 12, "zwölf": 12,
+}
+
+const numberPattern = `(\d+|ein|einen|zwei|drei|vier|f` + ue + `nf|sechs|sieben|acht|neun|zehn|zw` + oe + `lf)`
+
+// termMonths converts a number (digits or word) and a unit ("Jahre", "Monate") to months.
+func termMonths(number, unit string) (int, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		var ok bool
+		if n, ok = numberWords[strings.ToLower(number)]; !ok {
+			return 0, false
+		}
+	}
+	if strings.HasPrefix(strings.ToLower(unit), "j") {
+		n *= 12
+	}
+	return n, n > 0
+}
+
+var rentalTermRules = []extractionRule[dto.RentalTerm]{
+	{regexp.MustCompile(`(?i)\bunbefristet`), 0.95, constant(dto.RentalTerm{})},
+	{regexp.MustCompile(`(?i)\b(?:nicht|keine?)\s+(?:befristet|befristung)|\bohne\s+befristung`), 0.95, constant(dto.RentalTerm{})},
+	{regexp.MustCompile(`(?i)\bbefrist\w*\s+(?:mietvertrag\s+)?(?:auf|f` + ue + `r)\s+` + numberPattern + `\s+(jahre?n?|monate?n?)\b`), 0.95,
+		func(m []string, _ time.Time) (dto.RentalTerm, bool) {
+			months, ok := termMonths(m[1], m[2])
+			return dto.RentalTerm{Limited: true, Months: months}, ok
+		}},
+	{regexp.MustCompile(`(?i)\b` + numberPattern + `\s+(jahre?n?|monate?n?)\s+befristet`), 0.9,
+		func(m []string, _ time.Time) (dto.RentalTerm, bool) {
+			months, ok := termMonths(m[1], m[2])
+			return dto.RentalTerm{Limited: true, Months: months}, ok
+		}},
+	{regexp.MustCompile(`(?i)\bbefristung\s*:?\s*` + numberPattern + `\s+(jahre?n?|monate?n?)\b`), 0.9,
+		func(m []string, _ time.Time) (dto.RentalTerm, bool) {
+			months, ok := termMonths(m[1], m[2])
+			return dto.RentalTerm{Limited: true, Months: months}, ok
+		}},
+	{regexp.MustCompile(`(?i)` + negated + `\bbefrist`), 0.7, unlessNegated(dto.RentalTerm{Limited: true})},
+}
+
+var commissionRules = []extractionRule[bool]{
+	{regexp.MustCompile(`(?i)\bprovisionsfrei|\b(?:ohne|keine)\s+(?:makler)?provision|\bprovision\s*:?\s*(?:keine|entf` + ae + `llt)`), 0.95, constant(true)},
+	// "Provision: 0,-" or "Provision: 0 €", but not "Provision: 0,5 Monatsmieten"
+	{regexp.MustCompile(`(?i)\bprovision\s*:?\s*(?:(?:€|eur(?:o)?)\s*)?0(?:,-|,00?)?(?:\s*(?:€|eur(?:o)?\b))?(?:$|[\s!;)]|[.,](?:\s|$))`), 0.9, constant(true)},
+	{regexp.MustCompile(`(?i)\bprovision\s*:?\s*(?:\d|zwei|drei|ein)`), 0.8, constant(false)},
+	{regexp.MustCompile(`(?i)\bprovisionspflichtig`), 0.9, constant(false)},
+}
+
+var transferFeeRules = []extractionRule[dto.Money]{
+	{regexp.MustCompile(`(?i)\bkeine\s+abl` + oe + `se|\babl` + oe + `sefrei|\babl` + oe + `se\s*:?\s*keine`), 0.9, constant(dto.EUR(0))},
+	{regexp.MustCompile(`(?i)\babl` + oe + `se\w*\s*(?:f` + ue + `r\s+(?:die\s+|das\s+|den\s+)?\S+\s*)?(?::|von|in\s+h` + oe + `he\s+von)?\s*(?:€|eur(?:o)?)?\s*(\d{1,3}(?:\.\d{3})+|\d+)(?:,(\d{2}|-))?`), 0.85,
+		func(m []string, _ time.Time) (dto.Money, bool) {
+			euros, err := strconv.ParseInt(strings.ReplaceAll(m[1], ".", ""), 10, 64)
+			if err != nil || euros == 0 {
+				return dto.Money{}, false
+			}
+			cents, _ := strconv.ParseInt(m[2], 10, 64)
+			return dto.Money{Cents: euros*100 + cents, Currency: dto.CurrencyEUR}, true
+		}},
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "jaen": time.January, "jän": time.January, "feb": time.February, "mär": time.March,
+	"maer": time.March, "mar": time.March, "apr": time.April, "mai": time.May, "jun": time.June, "jul": time.July,
+	"aug": time.August, "sep": time.September, "okt": time.October, "nov": time.November, "dez": time.December,
+}
+
+const monthPattern = `(j` + ae + `n(?:ner)?|januar|feb(?:ruar)?|m` + ae + `r(?:z)?|apr(?:il)?|mai|jun[i]?|jul[i]?|aug(?:ust)?|sep(?:t(?:ember)?)?|okt(?:ober)?|nov(?:ember)?|dez(?:ember)?)\b\.?`
+
+/*
+availablePrefix and availableSuffix are the words that introduce a date of availability, as in "Bezug ab 1.4."
+or "ab 1.4. bezugsfertig". Dates without them, e.g. of a viewing, are ignored.
+*/
+const (
+	availablePrefix = `(?i)\b(?:bezugsfertig|beziehbar|verf` + ue + `gbar|frei|bezug|einzug|mietbeginn|(?:weiter)?vermietung)\s+(?:ab|per|am|mit)\s+(?:dem\s+|(?:sofort|jetzt)\s+oder\s+)?`
+	availableSuffix = `\s*(?:bezugsfertig|beziehbar|verf` + ue + `gbar|frei|zu\s+vergeben|zu\s+vermieten)\b`
+)
+
+// availableDateRules returns the rules for a date pattern preceded by availablePrefix or followed by availableSuffix.
+func availableDateRules(date string, confidence float64, value func([]string, time.Time) (time.Time, bool)) []extractionRule[time.Time] {
+	return []extractionRule[time.Time]{
+		{regexp.MustCompile(availablePrefix + date), confidence, value},
+		{regexp.MustCompile(`(?i)\b(?:ab|per)\s+` + date + availableSuffix), confidence, value},
+	}
+}
+
+// parseMonth maps a month name or abbreviation like "März", "Maerz" or "Okt" to its month.
+func parseMonth(s string) (time.Month, bool) {
+	s = strings.ToLower(s)
+	for prefix, m := range months {
+		if strings.HasPrefix(s, prefix) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+/*
+nextDate returns the date with the given day and month; if year is 0 it is the next such date
+not more than a month before now, as a description written in December saying "Bezug ab 1.1." means the coming year.
+*/
+func nextDate(year int, month time.Month, day int, now time.Time) (time.Time, bool) {
+	if month < time.January || month > time.December || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	if year == 0 {
+		year = now.Year()
+		if time.Date(year, month, day, 0, 0, 0, 0, now.Location()).Before(now.AddDate(0, -1, 0)) {
+			year++
+		}
+	} else if year < 100 {
+		year += 2000
+	}
+	t := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	return t, t.Day() == day
+}
+
+var availableRules = slices.Concat(
+	[]extractionRule[time.Time]{
+		{regexp.MustCompile(`(?i)\b(?:ab\s+sofort|sofort\s+(?:verf` + ue + `gbar|beziehbar|bezugsfertig)|ab\s+jetzt)`), 0.9,
+			func(_ []string, now time.Time) (time.Time, bool) { return now, true }},
+	},
+	availableDateRules(`(\d{1,2})\.\s*(\d{1,2})\.(\d{4}|\d{2})?`, 0.85,
+		func(m []string, now time.Time) (time.Time, bool) {
+			day, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			year, _ := strconv.Atoi(m[3])
+			return nextDate(year, time.Month(month), day, now)
+		}),
+	availableDateRules(`(\d{1,2})\.\s*`+monthPattern+`(?:\s+(\d{4}))?`, 0.85,
+		func(m []string, now time.Time) (time.Time, bool) {
+			day, _ := strconv.Atoi(m[1])
+			month, ok := parseMonth(m[2])
+			year, _ := strconv.Atoi(m[3])
+			if !ok {
+				return time.Time{}, false
+			}
+			return nextDate(year, month, day, now)
+		}),
+	availableDateRules(`(?:anfang\s+)?`+monthPattern+`(?:\s+(\d{4}))?`, 0.6,
+		func(m []string, now time.Time) (time.Time, bool) {
+			month, ok := parseMonth(m[1])
+			year, _ := strconv.Atoi(m[2])
+			if !ok {
+				return time.Time{}, false
+			}
+			return nextDate(year, month, 1, now)
+		}),
+)
+
+var petRules = []extractionRule[bool]{
+	{regexp.MustCompile(`(?i)\bkeine\s+(?:haus)?tiere|\b(?:haus)?tier(?:e|haltung)\s+(?:sind\s+|ist\s+)?(?:nicht|leider\s+nicht)\s+(?:erlaubt|gestattet|m` + oe + `glich|erw` + ue + `nscht)|\bohne\s+(?:haus)?tiere`), 0.9, constant(false)},
+	{regexp.MustCompile(`(?i)\b(?:haus)?tier(?:e|haltung)\s+(?:sind\s+|ist\s+)?(?:erlaubt|gestattet|willkommen|m` + oe + `glich|kein\s+problem)|\btierfreundlich|\bhaustierfreundlich`), 0.9, constant(true)},
+	{regexp.MustCompile(`(?i)\b(?:haus)?tier(?:e|haltung)\s+(?:nach|auf)\s+(?:absprache|anfrage|r` + ue + `cksprache)`), 0.6, constant(true)},
+}
+
+var sharedFlatRules = []extractionRule[bool]{
+	{regexp.MustCompile(`(?i)\b(?:nicht|keine?)\s+(?:f` + ue + `r\s+(?:eine\s+)?)?wgs?(?:\b|-)(?:geeignet|tauglich)?|\bwg\s*-?\s*(?:nicht\s+(?:m` + oe + `glich|erlaubt|geeignet)|ungeeignet)`), 0.9, constant(false)},
+	{regexp.MustCompile(`(?i)\bwg\s*-?\s*(?:geeignet|tauglich|f` + ae + `hig|m` + oe + `glich)|\bf` + ue + `r\s+(?:eine\s+)?wg\s+(?:geeignet|ideal|bestens\s+geeignet)|\bideal\s+f` + ue + `r\s+(?:eine\s+)?wg\b|\bals\s+wg\b`), 0.9, constant(true)},
+	{regexp.MustCompile(`(?i)\b(?:wohngemeinschaft|studenten-?wg)`), 0.6, constant(true)},
+}
+
+var furnishingRules = []extractionRule[dto.Furnishing]{
+	{regexp.MustCompile(`(?i)\b(un|teil|voll|nicht\s+|teilweise\s+)?m` + oe + `bliert`), 0.8,
+		func(m []string, _ time.Time) (dto.Furnishing, bool) {
+			switch strings.ToLower(strings.TrimSpace(m[1])) {
+			case "un", "nicht":
+				return dto.Unfurnished, true
+			case "teil", "teilweise":
+				return dto.PartlyFurnished, true
+			default:
+				return dto.Furnished, true
+			}
+		}},
+}
+
+var buildingTypeRules = []extractionRule[dto.BuildingType]{
+	{regexp.MustCompile(`(?i)` + negated + `(?:\b(?:gr` + ue + `nderzeit|jahrhundertwende)?-?altbau|\bgr` + ue + `nderzeithaus)`), 0.8,
+		unlessNegated(dto.BuildingAltbau)},
+	{regexp.MustCompile(`(?i)` + negated + `(?:\bgenossenschaft|\bgef` + oe + `rderte\s+mietwohnung)`), 0.8,
+		unlessNegated(dto.BuildingGenossenschaft)},
+	{regexp.MustCompile(`(?i)` + negated + `\bneubau`), 0.7, unlessNegated(dto.BuildingNeubau)},
+}
+
+var cellarRules = []extractionRule[bool]{
+	{regexp.MustCompile(`(?i)\bkein(?:en)?\s+keller|\bohne\s+keller`), 0.9, constant(false)},
+	{regexp.MustCompile(`(?i)\bkellerabteil|\bkellerersatzraum|\b(?:mit|inkl\.?|inklusive|samt)\s+keller`), 0.85, constant(true)},
+}
+
+/*
+extract returns the value of the most confident match of the rules in text, or nil if none matches.
+Of equally confident matches the first rule and the first occurrence win, so rules are ordered
+with negations first.
+*/
+func extract[T any](text string, now time.Time, rules []extractionRule[T]) *Extracted[T] {
+	var best *Extracted[T]
+	for _, r := range rules {
+		if best != nil && r.confidence <= best.Confidence {
+			continue
+		}
+		for _, loc := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			if v, ok := r.value(m, now); ok {
+				best = &Extracted[T]{Value: v, Confidence: r.confidence, Snippet: snippet(text, loc[0], loc[1])}
+				break
+			}
+		}
+	}
+	return best
+}
+
+// snippet returns text[start:end] with up to snippetContext runes of context on the same line on either side.
+func snippet(text string, start, end int) string {
+	from := start
+	for i := 0; i < snippetContext && from > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(text[:from])
+		if text[from-size] == '\n' {
+			break
+		}
+		from -= size
+	}
+	to := end
+	for i := 0; i < snippetContext && to < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[to:])
+		if text[to] == '\n' {
+			break
+		}
+		to += size
+	}
+	s := strings.TrimSpace(text[from:to])
+	if from > 0 && text[from-1] != '\n' {
+		s = "…" + s
+	}
+	if to < len(text) && text[to] != '\n' {
+		s += "…"
+	}
+	return s
+}
+
+/*
+ExtractDetails looks for the rental term, commission, transfer fee (Ablöse), availability, pets,
+suitability for a shared flat, furnishing, building type and cellar in a German listing description.
+now is the time the description was written, used for "ab sofort" and dates without year.
+The extraction is rule-based and works offline; see Extracted.Confidence for how sure it is.
+*/
+func ExtractDetails(text string, now time.Time) DescriptionDetails {
+	return DescriptionDetails{
+		RentalTerm:     extract(text, now, rentalTermRules),
+		CommissionFree: extract(text, now, commissionRules),
+		TransferFee:    extract(text, now, transferFeeRules),
+		AvailableFrom:  extract(text, now, availableRules),
+		PetsAllowed:    extract(text, now, petRules),
+		SharedFlat:     extract(text, now, sharedFlatRules),
+		Furnishing:     extract(text, now, furnishingRules),
+		BuildingType:   extract(text, now, buildingTypeRules),
+		Cellar:         extract(text, now, cellarRules),
+	}
+}
+
+// ExtractDetails extracts the details from the title and description of the listing, see ExtractDetails.
+func (il ImmoListing) ExtractDetails(now time.Time) DescriptionDetails {
+	return ExtractDetails(il.Title+"\n"+il.Description, now)
+}
+
+/*
+ApplyDetails fills the attributes of the listing the source left unknown with the details extracted
+from its description with at least minConfidence. Attributes given by the source are never overwritten.
+A transfer fee is added to the one-off fees if the source states none.
+*/
+func (il *ImmoListing) ApplyDetails(d DescriptionDetails, minConfidence float64) {
+	confident := func(c float64) bool { return c >= minConfidence }
+	if d.RentalTerm != nil && confident(d.RentalTerm.Confidence) && il.RentalTerm == nil {
+		term := d.RentalTerm.Value
+		il.RentalTerm = &term
+	}
+	if d.TransferFee != nil && confident(d.TransferFee.Confidence) && il.Price.OneOffFees.IsZero() {
+		il.Price.OneOffFees = d.TransferFee.Value
+	}
+	if d.AvailableFrom != nil && confident(d.AvailableFrom.Confidence) && il.AvailableFrom == nil {
+		t := d.AvailableFrom.Value
+		il.AvailableFrom = &t
+	}
+	if d.PetsAllowed != nil && confident(d.PetsAllowed.Confidence) && il.PetsAllowed == nil {
+		pets := d.PetsAllowed.Value
+		il.PetsAllowed = &pets
+	}
+	if d.Furnishing != nil && confident(d.Furnishing.Confidence) && il.Furnishing == dto.FurnishingUnknown {
+		il.Furnishing = d.Furnishing.Value
+	}
+	if d.BuildingType != nil && confident(d.BuildingType.Confidence) && il.BuildingType == dto.BuildingUnknown {
+		il.BuildingType = d.BuildingType.Value
+	}
+	if d.Cellar != nil && confident(d.Cellar.Confidence) && il.Cellar == nil {
+		cellar := d.Cellar.Value
+		il.Cellar = &cellar
+	}
+}
@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+// labelled is a description from the corpus with the details it states; nil fields are not mentioned.
+type labelled struct {
+	text           string
+	rentalTerm     *dto.RentalTerm
+	commissionFree *bool
+	transferFee    *dto.Money
+	availableFrom  *time.Time
+	petsAllowed    *bool
+	sharedFlat     *bool
+	furnishing     *dto.Furnishing
+	buildingType   *dto.BuildingType
+	cellar         *bool
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// written is the time the corpus descriptions were written.
+var written = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func date(year int, month time.Month, day int) *time.Time {
+	return ptr(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+var descriptionCorpus = []labelled{
+	{
+		text:           "Schöne 2-Zimmer-Altbauwohnung, befristet auf 3 Jahre, provisionsfrei. Ab 1.3. bezugsfertig.",
+		rentalTerm:     &dto.RentalTerm{Limited: true, Months: 36},
+		commissionFree: ptr(true),
+		availableFrom:  date(2024, time.March, 1),
+		buildingType:   ptr(dto.BuildingAltbau),
+	},
+	{
+		text:        "Unbefristeter Mietvertrag. Ablöse € 5.000 für die Einbauküche. Haustiere erlaubt, WG-geeignet.",
+		rentalTerm:  &dto.RentalTerm{},
+		transferFee: ptr(dto.EUR(5000)),
+		petsAllowed: ptr(true),
+		sharedFlat:  ptr(true),
+	},
+	{
+		text:           "Neubau-Erstbezug mit Kellerabteil. Keine Haustiere! Provision: 2 Bruttomonatsmieten. Verfügbar ab sofort.",
+		commissionFree: ptr(false),
+		availableFrom:  &written,
+		petsAllowed:    ptr(false),
+		buildingType:   ptr(dto.BuildingNeubau),
+		cellar:         ptr(true),
+	},
+	{
+		text:        "Vollmöbliert, 5 Jahre befristet, Ablöse: EUR 2.500,- für Möbel. Nicht WG-geeignet.",
+		rentalTerm:  &dto.RentalTerm{Limited: true, Months: 60},
+		transferFee: ptr(dto.EUR(2500)),
+		sharedFlat:  ptr(false),
+		furnishing:  ptr(dto.Furnished),
+	},
+	{
+		text:          "Genossenschaftswohnung, unmöbliert, Bezug ab 01.02.2024. Haustiere nach Absprache.",
+		availableFrom: date(2024, time.February, 1),
+		petsAllowed:   ptr(true),
+		furnishing:    ptr(dto.Unfurnished),
+		buildingType:  ptr(dto.BuildingGenossenschaft),
+	},
+	{
+		text:           "Befristung: 36 Monate. Keine Ablöse, ohne Provision. Tierhaltung nicht gestattet. Teilmöbliert.",
+		rentalTerm:     &dto.RentalTerm{Limited: true, Months: 36},
+		commissionFree: ptr(true),
+		transferFee:    ptr(dto.EUR(0)),
+		petsAllowed:    ptr(false),
+		furnishing:     ptr(dto.PartlyFurnished),
+	},
+	{
+		text:           "Hauptmiete inkl. Betriebskosten, Provision: 0,-",
+		commissionFree: ptr(true),
+	},
+	{
+		text:           "Kaution: drei Monatsmieten. Provision: 0 €.",
+		commissionFree: ptr(true),
+	},
+	{
+		text:           "Provision: 0,5 Bruttomonatsmieten zzgl. USt.",
+		commissionFree: ptr(false),
+	},
+	{
+		text:          "Der Mietvertrag ist auf drei Jahre befristet. Die Wohnung ist ab 1. Dezember frei, kein Keller.",
+		rentalTerm:    &dto.RentalTerm{Limited: true, Months: 36},
+		availableFrom: date(2024, time.December, 1),
+		cellar:        ptr(false),
+	},
+	{
+		text:         "Gründerzeithaus im 7. Bezirk, 3. Stock ohne Lift. Befristeter Mietvertrag. Keine WG!",
+		rentalTerm:   &dto.RentalTerm{Limited: true},
+		sharedFlat:   ptr(false),
+		buildingType: ptr(dto.BuildingAltbau),
+	},
+	{
+		// a date in early January written in mid January is meant for the same month
+		text:          "Moebliert, per 10.1. zu vergeben, Besichtigung am 20.1. möglich.",
+		availableFrom: date(2024, time.January, 10),
+		furnishing:    ptr(dto.Furnished),
+	},
+	{
+		// a date more than a month ago is meant for next year
+		text:          "Helle Wohnung, frei ab 1.12., Gesamtmiete 1.300 €.",
+		availableFrom: date(2024, time.December, 1),
+	},
+	{
+		text:          "Bezugsfertig ab Anfang April, ideal für eine WG mit Balkon. Tiere sind leider nicht erlaubt.",
+		availableFrom: date(2024, time.April, 1),
+		petsAllowed:   ptr(false),
+		sharedFlat:    ptr(true),
+	},
+	{
+		text: "Helle Wohnung in ruhiger Lage, 3. Stock, 2 Zimmer, Nähe U6.",
+	},
+	{
+		text:       "Der Mietvertrag ist nicht befristet.",
+		rentalTerm: &dto.RentalTerm{},
+	},
+	{
+		text:       "Keine Befristung, Kaution drei Bruttomieten.",
+		rentalTerm: &dto.RentalTerm{},
+	},
+	{
+		text:         "Neubau-Wohnung, kein Altbau.",
+		buildingType: ptr(dto.BuildingNeubau),
+	},
+	{
+		// the date of the viewing is not the date of availability
+		text:          "Besichtigung ab 12.3., Bezug ab 1.4.",
+		availableFrom: date(2024, time.April, 1),
+	},
+	{
+		text: "Besichtigung ab 12.3. nach Vereinbarung.",
+	},
+	{
+		text:          "Zur Weitervermietung ab 1.12.2025 steht eine ruhige, gepflegte, helle Neubauwohnung zur Verfügung.",
+		availableFrom: date(2025, time.December, 1),
+		buildingType:  ptr(dto.BuildingNeubau),
+	},
+}
+
+// assertExtracted checks that the field was found if and only if it is labelled, with the labelled value.
+func assertExtracted[T any](t *testing.T, text, field string, want *T, got *Extracted[T]) {
+	if want == nil {
+		assert.Nil(t, got, "%s: unexpected %s", text, field)
+		return
+	}
+	if assert.NotNil(t, got, "%s: missing %s", text, field) {
+		assert.Equal(t, *want, got.Value, "%s: %s", text, field)
+		assert.Greater(t, got.Confidence, 0.0)
+		assert.LessOrEqual(t, got.Confidence, 1.0)
+		assert.NotEmpty(t, got.Snippet)
+	}
+}
+
+func TestExtractDetailsCorpus(t *testing.T) {
+	for _, l := range descriptionCorpus {
+		d := ExtractDetails(l.text, written)
+		assertExtracted(t, l.text, "rental term", l.rentalTerm, d.RentalTerm)
+		assertExtracted(t, l.text, "commission", l.commissionFree, d.CommissionFree)
+		assertExtracted(t, l.text, "transfer fee", l.transferFee, d.TransferFee)
+		assertExtracted(t, l.text, "availability", l.availableFrom, d.AvailableFrom)
+		assertExtracted(t, l.text, "pets", l.petsAllowed, d.PetsAllowed)
+		assertExtracted(t, l.text, "shared flat", l.sharedFlat, d.SharedFlat)
+		assertExtracted(t, l.text, "furnishing", l.furnishing, d.Furnishing)
+		assertExtracted(t, l.text, "building type", l.buildingType, d.BuildingType)
+		assertExtracted(t, l.text, "cellar", l.cellar, d.Cellar)
+	}
+}
+
+func TestExtractDetailsSnippet(t *testing.T) {
+	text := "Wunderschöne, ruhig gelegene Wohnung im Innenhof. Der Mietvertrag ist befristet auf 3 Jahre mit Option auf Verlängerung.\nProvisionsfrei!"
+	d := ExtractDetails(text, written)
+	if assert.NotNil(t, d.RentalTerm) {
+		assert.Equal(t, "…Innenhof. Der Mietvertrag ist befristet auf 3 Jahre mit Option auf Verlängerung.", d.RentalTerm.Snippet)
+	}
+	if assert.NotNil(t, d.CommissionFree) {
+		assert.Equal(t, "Provisionsfrei!", d.CommissionFree.Snippet)
+	}
+}
+
+func TestApplyDetails(t *testing.T) {
+	no := false
+	il := ImmoListing{
+		Description: "Befristet auf 5 Jahre, Haustiere erlaubt, Ablöse 3.000 €, Bezug ab Mai.",
+		Attributes:  dto.Attributes{PetsAllowed: &no},
+	}
+	d := il.ExtractDetails(written)
+	il.ApplyDetails(d, MinExtractionConfidence)
+	assert.Equal(t, &dto.RentalTerm{Limited: true, Months: 60}, il.RentalTerm)
+	assert.False(t, *il.PetsAllowed, "attributes from the source are kept")
+	assert.Equal(t, dto.EUR(3000), il.Price.OneOffFees)
+	assert.Equal(t, date(2024, time.May, 1), il.AvailableFrom)
+
+	// "Bezug ab Mai" is less certain than an exact date
+	il = ImmoListing{Description: "Bezug ab Mai"}
+	il.ApplyDetails(il.ExtractDetails(written), 0.8)
+	assert.Nil(t, il.AvailableFrom)
+}
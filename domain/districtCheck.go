@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"slices"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+// DistrictMismatch is a listing whose stated district differs from the district its location lies in.
+type DistrictMismatch struct {
+	Listing ImmoListing
+	Stated  *dto.District
+	Located *dto.District
+}
+
+/*
+AnnotateLocatedDistricts sets the LocatedDistrict of every ImmoListing to the district its
+location lies in. Listings without location or outside all boundaries get none.
+The stated District is kept. The original ImmoListings is not modified, the annotated copy is returned.
+*/
+func (il ImmoListings) AnnotateLocatedDistricts(boundaries *dto.DistrictBoundaries) ImmoListings {
+	lc := slices.Clone(il)
+	for i := range lc {
+		lc[i].LocatedDistrict = nil
+		if lc[i].Location == nil {
+			continue
+		}
+		if d, err := boundaries.DistrictFromCoordinates(*lc[i].Location); err == nil {
+			lc[i].LocatedDistrict = d
+		}
+	}
+	return lc
+}
+
+/*
+DistrictConsistent reports whether the stated district of the listing matches the district
+its location lies in, see AnnotateLocatedDistricts. Listings lacking either are considered
+consistent, as there is nothing to contradict them.
+*/
+func (il ImmoListing) DistrictConsistent() bool {
+	if il.District == nil || il.LocatedDistrict == nil {
+		return true
+	}
+	return il.LocatedDistrict.Number == il.District.Number
+}
+
+// FilterDistrictConsistent returns a filter function that removes listings whose stated district contradicts their location.
+func FilterDistrictConsistent() ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.DistrictConsistent()
+	}
+}
+
+// DistrictMismatches returns the listings whose stated district contradicts their location.
+func (il ImmoListings) DistrictMismatches() []DistrictMismatch {
+	mismatches := make([]DistrictMismatch, 0)
+	for _, l := range il {
+		if l.DistrictConsistent() {
+			continue
+		}
+		mismatches = append(mismatches, DistrictMismatch{Listing: l, Stated: l.District, Located: l.LocatedDistrict})
+	}
+	return mismatches
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistrictMismatches(t *testing.T) {
+	// simplified shapes, not the real district borders
+	boundaries := dto.NewDistrictBoundaries(map[int]dto.MultiPolygon{
+		1: {{{X: 16.36, Y: 48.20}, {X: 16.38, Y: 48.20}, {X: 16.38, Y: 48.22}, {X: 16.36, Y: 48.22}}},
+		2: {{{X: 16.38, Y: 48.20}, {X: 16.42, Y: 48.20}, {X: 16.42, Y: 48.24}, {X: 16.38, Y: 48.24}}},
+	})
+
+	first, _ := dto.DistrictByNumber(1)
+	second, _ := dto.DistrictByNumber(2)
+	listings := ImmoListings{
+		{ID: 1, District: first, Location: &dto.Coordinates{X: 16.37, Y: 48.21}},
+		{ID: 2, District: first, Location: &dto.Coordinates{X: 16.40, Y: 48.23}},
+		{ID: 3, District: second},
+		{ID: 4, District: second, Location: &dto.Coordinates{X: 16.57, Y: 48.12}},
+		{ID: 5, Location: &dto.Coordinates{X: 16.37, Y: 48.21}},
+	}
+	assert.Empty(t, listings.DistrictMismatches(), "not located yet")
+
+	located := listings.AnnotateLocatedDistricts(boundaries)
+	assert.Nil(t, listings[0].LocatedDistrict, "original not modified")
+	assert.Equal(t, 1, located[0].District.Number, "the stated district is kept")
+	assert.Equal(t, 2, located[1].LocatedDistrict.Number)
+	assert.Nil(t, located[2].LocatedDistrict, "no location")
+	assert.Nil(t, located[3].LocatedDistrict, "outside all boundaries")
+
+	mismatches := located.DistrictMismatches()
+	if assert.Len(t, mismatches, 1) {
+		assert.Equal(t, uint64(2), mismatches[0].Listing.ID)
+		assert.Equal(t, 1, mismatches[0].Stated.Number)
+		assert.Equal(t, 2, mismatches[0].Located.Number)
+	}
+	assert.Equal(t, []uint64{1, 3, 4, 5}, ids(located.ApplyFilter(FilterDistrictConsistent())), "nothing to contradict without stated district")
+}
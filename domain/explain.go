@@ -0,0 +1,393 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+/*
+Explanation is the decision of an ExplainedFilter for one listing. Score tells how well the listing
+meets the criterion: 1 if it passed, otherwise between 0 and 1 depending on how close it came, so
+that near misses can be told apart from clear failures. Soft criteria never exclude a listing;
+they only contribute their Weight to the Score of the group they are in.
+*/
+type Explanation struct {
+	Criterion string
+	Passed    bool
+	Soft      bool
+	Weight    float64 // weight in the group score; 1 for hard criteria
+	Score     float64
+	Margin    float64 // how far the value lies outside the bounds, in the unit of the criterion, 0 if passed
+	Detail    string  // e.g. "price € 1.520,00 > max € 1.400,00"
+	Children  []Explanation
+}
+
+// ExplainedFilter is a filter that explains its decision, see Explanation.
+type ExplainedFilter func(ImmoListing) Explanation
+
+// Filter returns the plain filter function, which keeps the ImmoListings the explained filter passes.
+func (f ExplainedFilter) Filter() ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return f(il).Passed
+	}
+}
+
+/*
+Named turns a plain filter into an ExplainedFilter with the given name. As the filter only tells
+pass or fail, the score is 1 or 0 and the detail just names the outcome.
+*/
+func Named(name string, filter ImmoListingsFilter) ExplainedFilter {
+	return func(il ImmoListing) Explanation {
+		if filter(il) {
+			return Explanation{Criterion: name, Passed: true, Weight: 1, Score: 1, Detail: name + " passed"}
+		}
+		return Explanation{Criterion: name, Weight: 1, Detail: name + " failed"}
+	}
+}
+
+/*
+NamedRange returns an ExplainedFilter that checks value against [minValue, maxValue], where a bound
+of 0 is open as with FilterPrice. format renders values in the detail. A listing outside the range
+scores 1 minus its relative distance to the violated bound, so a price 5 % above the maximum scores 0.95.
+Listings where value is unknown fail with score 0.
+*/
+func NamedRange(name string, value func(ImmoListing) (float64, bool), minValue, maxValue float64, format func(float64) string) ExplainedFilter {
+	return func(il ImmoListing) Explanation {
+		e := Explanation{Criterion: name, Weight: 1}
+		v, ok := value(il)
+		switch {
+		case !ok:
+			e.Detail = name + " unknown"
+		case minValue != 0 && v < minValue:
+			e.Margin = minValue - v
+			e.Score = math.Max(0, 1-e.Margin/minValue)
+			e.Detail = fmt.Sprintf("%s %s < min %s", name, format(v), format(minValue))
+		case maxValue != 0 && v > maxValue:
+			e.Margin = v - maxValue
+			e.Score = math.Max(0, 1-e.Margin/maxValue)
+			e.Detail = fmt.Sprintf("%s %s > max %s", name, format(v), format(maxValue))
+		default:
+			e.Passed, e.Score = true, 1
+			e.Detail = fmt.Sprintf("%s %s within %s", name, format(v), formatRange(minValue, maxValue, format))
+		}
+		return e
+	}
+}
+
+func formatRange(minValue, maxValue float64, format func(float64) string) string {
+	switch {
+	case minValue == 0 && maxValue == 0:
+		return "any"
+	case minValue == 0:
+		return "max " + format(maxValue)
+	case maxValue == 0:
+		return "min " + format(minValue)
+	}
+	return format(minValue) + "–" + format(maxValue)
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ExplainPrice is the explained version of FilterPrice; both fail listings whose cost is unknown.
+func ExplainPrice(minPrice, maxPrice float32) ExplainedFilter {
+	return NamedRange("price", func(il ImmoListing) (float64, bool) {
+		p, ok := il.MonthlyCost()
+		return float64(p), ok
+	}, float64(minPrice), float64(maxPrice), func(v float64) string {
+		return dto.EUR(v).String()
+	})
+}
+
+// ExplainRooms is the explained version of FilterRooms.
+func ExplainRooms(minRooms, maxRooms int) ExplainedFilter {
+	return NamedRange("rooms", func(il ImmoListing) (float64, bool) {
+		return float64(il.Rooms), il.Rooms != 0
+	}, float64(minRooms), float64(maxRooms), formatNumber)
+}
+
+// ExplainArea is the explained version of FilterArea.
+func ExplainArea(minArea, maxArea float32) ExplainedFilter {
+	return NamedRange("area", func(il ImmoListing) (float64, bool) {
+		return float64(il.Area), il.Area != 0
+	}, float64(minArea), float64(maxArea), func(v float64) string {
+		return formatNumber(math.Round(v*10)/10) + " m²"
+	})
+}
+
+// ExplainDistricts is the explained version of FilterDistricts.
+func ExplainDistricts(districts []dto.District) ExplainedFilter {
+	numbers := make([]string, len(districts))
+	for i, d := range districts {
+		numbers[i] = strconv.Itoa(d.Number)
+	}
+	list := strings.Join(numbers, ", ")
+	return func(il ImmoListing) Explanation {
+		e := Explanation{Criterion: "district", Weight: 1}
+		switch {
+		case il.District == nil:
+			e.Detail = "district unknown"
+		case slices.ContainsFunc(districts, func(d dto.District) bool { return d.Number == il.District.Number }):
+			e.Passed, e.Score = true, 1
+			e.Detail = fmt.Sprintf("district %d in %s", il.District.Number, list)
+		default:
+			e.Detail = fmt.Sprintf("district %d not in %s", il.District.Number, list)
+		}
+		return e
+	}
+}
+
+/*
+ExplainCommute is the explained version of FilterCommutes for a single target. The margin is
+in minutes above target.MaxDuration.
+*/
+func ExplainCommute(travelTime TravelTimeFunc, target CommuteTarget) ExplainedFilter {
+	name := "commute"
+	if target.Name != "" {
+		name += " to " + target.Name
+	}
+	return func(il ImmoListing) Explanation {
+		e := Explanation{Criterion: name, Weight: 1}
+		c, ok := il.commute(travelTime, target)
+		switch {
+		case !ok:
+			e.Detail = name + " unknown"
+		case c.Duration > target.MaxDuration:
+			e.Margin = (c.Duration - target.MaxDuration).Minutes()
+			if target.MaxDuration > 0 {
+				e.Score = math.Max(0, 1-e.Margin/target.MaxDuration.Minutes())
+			}
+			e.Detail = fmt.Sprintf("%s %s > max %s", name, formatMinutes(c.Duration), formatMinutes(target.MaxDuration))
+		default:
+			e.Passed, e.Score = true, 1
+			e.Detail = fmt.Sprintf("%s %s within max %s", name, formatMinutes(c.Duration), formatMinutes(target.MaxDuration))
+		}
+		return e
+	}
+}
+
+// ExplainCommutes is the explained version of FilterCommutes, passing if every target passes ExplainCommute.
+func ExplainCommutes(travelTime TravelTimeFunc, targets ...CommuteTarget) ExplainedFilter {
+	filters := make([]ExplainedFilter, len(targets))
+	for i, t := range targets {
+		filters[i] = ExplainCommute(travelTime, t)
+	}
+	return ExplainAll("commutes", filters...)
+}
+
+func formatMinutes(d time.Duration) string {
+	return formatNumber(math.Round(d.Minutes())) + " min"
+}
+
+// ExplainLines is the explained version of FilterLines.
+func ExplainLines(stopMap map[dto.Line][]*dto.Stop, lineNames []string, maxDistance float64, distance dto.DistanceFunc) ExplainedFilter {
+	return explainNearStops("near "+strings.Join(lineNames, ", "), linesStops(stopMap, func(l dto.Line) bool {
+		return slices.Contains(lineNames, l.Name)
+	}), maxDistance, distance)
+}
+
+// ExplainLineTypes is the explained version of FilterLineTypes.
+func ExplainLineTypes(stopMap map[dto.Line][]*dto.Stop, lineTypes []dto.LineType, maxDistance float64, distance dto.DistanceFunc) ExplainedFilter {
+	names := make([]string, len(lineTypes))
+	for i, t := range lineTypes {
+		names[i] = t.String()
+	}
+	return explainNearStops("near "+strings.Join(names, ", "), linesStops(stopMap, func(l dto.Line) bool {
+		return slices.Contains(lineTypes, l.Type)
+	}), maxDistance, distance)
+}
+
+/*
+explainNearStops is the explained version of filterNearStops. The detail names the nearest stop; stops
+up to twice maxDistance away are looked at, as farther ones score 0 anyway. The margin is in meters.
+*/
+func explainNearStops(name string, stops []*dto.Stop, maxDistance float64, distance dto.DistanceFunc) ExplainedFilter {
+	index := indexStops(stops)
+	return func(il ImmoListing) Explanation {
+		e := Explanation{Criterion: name, Weight: 1}
+		if il.Location == nil {
+			e.Detail = name + ": location unknown"
+			return e
+		}
+		var nearest *dto.Stop
+		var err error
+		nearestDistance := math.Inf(1)
+		for _, n := range index.Within(*il.Location, 2*maxDistance*prefilterSlack) {
+			d, dErr := distance(n.Location, *il.Location)
+			if dErr != nil {
+				err = dErr
+				continue
+			}
+			if d < nearestDistance {
+				nearest, nearestDistance = n.Item, d
+			}
+		}
+		switch {
+		case nearest == nil && err != nil:
+			e.Detail = fmt.Sprintf("%s: %v", name, err)
+		case nearest == nil:
+			e.Detail = fmt.Sprintf("%s: no stop within %s", name, formatMeters(2*maxDistance))
+		case nearestDistance > maxDistance:
+			e.Margin = nearestDistance - maxDistance
+			if maxDistance > 0 {
+				e.Score = math.Max(0, 1-e.Margin/maxDistance)
+			}
+			e.Detail = fmt.Sprintf("%s: %s %s > max %s", name, nearest.Name, formatMeters(nearestDistance), formatMeters(maxDistance))
+		default:
+			e.Passed, e.Score = true, 1
+			e.Detail = fmt.Sprintf("%s: %s %s within max %s", name, nearest.Name, formatMeters(nearestDistance), formatMeters(maxDistance))
+		}
+		return e
+	}
+}
+
+func formatMeters(v float64) string {
+	return formatNumber(math.Round(v)) + " m"
+}
+
+/*
+Soft makes a criterion a preference: it never excludes a listing, but adds its score with the given
+weight to the score of the enclosing ExplainAll.
+*/
+func Soft(weight float64, f ExplainedFilter) ExplainedFilter {
+	return func(il ImmoListing) Explanation {
+		e := f(il)
+		e.Soft, e.Weight = true, weight
+		return e
+	}
+}
+
+/*
+ExplainAll returns an ExplainedFilter that passes if all hard criteria pass. Its score is the weighted
+mean of the scores of all criteria, soft ones included, so among passing listings those meeting
+more preferences score higher, and among failing ones those that narrowly missed.
+*/
+func ExplainAll(name string, filters ...ExplainedFilter) ExplainedFilter {
+	return func(il ImmoListing) Explanation {
+		e := Explanation{Criterion: name, Passed: true, Weight: 1, Children: make([]Explanation, len(filters))}
+		var weights, score float64
+		var failed []string
+		for i, f := range filters {
+			c := f(il)
+			e.Children[i] = c
+			weights += c.Weight
+			score += c.Weight * c.Score
+			if !c.Passed && !c.Soft {
+				e.Passed = false
+				failed = append(failed, c.Detail)
+			}
+		}
+		e.Score = 1
+		if weights > 0 {
+			e.Score = score / weights
+		}
+		if e.Passed {
+			e.Detail = name + " passed"
+		} else {
+			e.Detail = strings.Join(failed, "; ")
+		}
+		return e
+	}
+}
+
+/*
+ExplainAny returns an ExplainedFilter that passes if any of the criteria passes; soft criteria are
+treated as hard ones here. Its score is the best score of the criteria.
+*/
+func ExplainAny(name string, filters ...ExplainedFilter) ExplainedFilter {
+	return func(il ImmoListing) Explanation {
+		e := Explanation{Criterion: name, Weight: 1, Children: make([]Explanation, len(filters))}
+		var details []string
+		for i, f := range filters {
+			c := f(il)
+			e.Children[i] = c
+			if c.Passed && !e.Passed {
+				e.Passed = true
+				e.Detail = c.Detail
+			}
+			e.Score = math.Max(e.Score, c.Score)
+			details = append(details, c.Detail)
+		}
+		if !e.Passed {
+			e.Detail = "none of: " + strings.Join(details, " / ")
+		}
+		return e
+	}
+}
+
+// ExplainNot returns an ExplainedFilter that passes if f fails.
+func ExplainNot(name string, f ExplainedFilter) ExplainedFilter {
+	return func(il ImmoListing) Explanation {
+		c := f(il)
+		e := Explanation{Criterion: name, Passed: !c.Passed, Weight: 1, Children: []Explanation{c}}
+		if e.Passed {
+			e.Score = 1
+			e.Detail = "not " + c.Detail
+		} else {
+			e.Detail = fmt.Sprintf("%s failed: %s", name, c.Detail)
+		}
+		return e
+	}
+}
+
+/*
+Failed returns the innermost criteria that made the listing fail, i.e. the failed hard criteria
+without children, for showing why a listing was excluded.
+*/
+func (e Explanation) Failed() []Explanation {
+	if e.Passed || e.Soft {
+		return nil
+	}
+	if len(e.Children) == 0 {
+		return []Explanation{e}
+	}
+	var failed []Explanation
+	for _, c := range e.Children {
+		failed = append(failed, c.Failed()...)
+	}
+	if len(failed) == 0 {
+		// e.g. ExplainNot, whose child passed
+		return []Explanation{e}
+	}
+	return failed
+}
+
+// ExplainedListing is a listing with the explanation of a filter's decision on it.
+type ExplainedListing struct {
+	Listing     ImmoListing
+	Explanation Explanation
+}
+
+// Explain applies the explained filter to all ImmoListings, rejected ones included, in order.
+func (il ImmoListings) Explain(f ExplainedFilter) []ExplainedListing {
+	res := make([]ExplainedListing, len(il))
+	for i, l := range il {
+		res[i] = ExplainedListing{Listing: l, Explanation: f(l)}
+	}
+	return res
+}
+
+/*
+NearMisses returns the ImmoListings the filter rejects with a score of at least minScore, best first,
+e.g. apartments only a few euros over budget.
+*/
+func (il ImmoListings) NearMisses(f ExplainedFilter, minScore float64) []ExplainedListing {
+	var res []ExplainedListing
+	for _, el := range il.Explain(f) {
+		if !el.Explanation.Passed && el.Explanation.Score >= minScore {
+			res = append(res, el)
+		}
+	}
+	slices.SortStableFunc(res, func(a, b ExplainedListing) int {
+		return cmp.Compare(b.Explanation.Score, a.Explanation.Score)
+	})
+	return res
+}
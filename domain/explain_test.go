@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnyOf(t *testing.T) {
+	f := AnyOf(FilterRooms(4, 0), FilterPrice(0, 1000))
+	assert.True(t, f(ImmoListing{Rooms: 4}))
+	assert.True(t, f(ImmoListing{Rooms: 2, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(900)}}))
+	assert.False(t, f(ImmoListing{Rooms: 2, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1100)}}))
+	assert.False(t, AnyOf()(ImmoListing{}))
+}
+
+func TestExplainPrice(t *testing.T) {
+	e := ExplainPrice(0, 1400)(ImmoListing{Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1520)}})
+	assert.False(t, e.Passed)
+	assert.Equal(t, "price € 1.520,00 > max € 1.400,00", e.Detail)
+	assert.InDelta(t, 120, e.Margin, 1e-9)
+	assert.InDelta(t, 1-120.0/1400, e.Score, 1e-9)
+
+	e = ExplainPrice(800, 1400)(ImmoListing{Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1200)}})
+	assert.True(t, e.Passed)
+	assert.Equal(t, "price € 1.200,00 within € 800,00–€ 1.400,00", e.Detail)
+
+	e = ExplainRooms(3, 0)(ImmoListing{})
+	assert.False(t, e.Passed)
+	assert.Equal(t, "rooms unknown", e.Detail)
+	assert.Zero(t, e.Score)
+}
+
+func TestExplainAll(t *testing.T) {
+	seventh, _ := dto.DistrictByNumber(7)
+	eighth, _ := dto.DistrictByNumber(8)
+	tenth, _ := dto.DistrictByNumber(10)
+	yes := true
+	filter := ExplainAll("search",
+		ExplainDistricts([]dto.District{*seventh, *eighth}),
+		ExplainPrice(0, 1400),
+		ExplainAny("size", ExplainRooms(3, 0), ExplainArea(70, 0)),
+		Soft(2, Named("pets", FilterPetsAllowed())),
+	)
+	listings := ImmoListings{
+		{ID: 1, District: eighth, Rooms: 3, Area: 75, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1350)},
+			Attributes: dto.Attributes{PetsAllowed: &yes}},
+		{ID: 2, District: seventh, Rooms: 2, Area: 72, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1300)}},
+		{ID: 3, District: seventh, Rooms: 3, Area: 80, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1450)}},
+		{ID: 4, District: tenth, Rooms: 2, Area: 50, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(2100)}},
+	}
+
+	explained := listings.Explain(filter)
+	assert.True(t, explained[0].Explanation.Passed)
+	assert.Equal(t, 1.0, explained[0].Explanation.Score)
+	// the missing soft criterion does not exclude, but lowers the score: 3 of 5 weight units
+	assert.True(t, explained[1].Explanation.Passed)
+	assert.InDelta(t, 3.0/5, explained[1].Explanation.Score, 1e-9)
+
+	assert.False(t, explained[3].Explanation.Passed)
+	assert.Equal(t, "district 10 not in 7, 8; price € 2.100,00 > max € 1.400,00; none of: rooms 2 < min 3 / area 50 m² < min 70 m²",
+		explained[3].Explanation.Detail)
+	failed := explained[3].Explanation.Failed()
+	if assert.Len(t, failed, 4) {
+		assert.Equal(t, []string{"district", "price", "rooms", "area"},
+			[]string{failed[0].Criterion, failed[1].Criterion, failed[2].Criterion, failed[3].Criterion})
+	}
+
+	assert.Equal(t, []uint64{1, 2}, ids(listings.ApplyFilter(filter.Filter())))
+
+	misses := listings.NearMisses(filter, 0.5)
+	if assert.Len(t, misses, 1) {
+		assert.Equal(t, uint64(3), misses[0].Listing.ID)
+		assert.Equal(t, "price € 1.450,00 > max € 1.400,00", misses[0].Explanation.Detail)
+	}
+}
+
+func TestExplainNot(t *testing.T) {
+	f := ExplainNot("not Altbau", Named("Altbau", FilterBuildingTypes(dto.BuildingAltbau)))
+	e := f(ImmoListing{Attributes: dto.Attributes{BuildingType: dto.BuildingAltbau}})
+	assert.False(t, e.Passed)
+	if assert.Len(t, e.Failed(), 1) {
+		assert.Equal(t, "not Altbau", e.Failed()[0].Criterion)
+	}
+	assert.Equal(t, "not Altbau failed: Altbau passed", e.Detail)
+	assert.True(t, f(ImmoListing{}).Passed)
+}
+
+func TestExplainCommutes(t *testing.T) {
+	office := CommuteTarget{Name: "office", Location: dto.Coordinates{X: 16.3725, Y: 48.2085}, DepartAt: tuesday, MaxDuration: 20 * time.Minute}
+	listings := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.37, Y: 48.21}},
+		{ID: 2, Location: &dto.Coordinates{X: 16.5, Y: 48.3}},
+		{ID: 3},
+	}
+	f := ExplainCommutes(EstimateTravelTime, office)
+	for _, l := range listings {
+		assert.Equal(t, FilterCommutes(EstimateTravelTime, office)(l), f(l).Passed, l.ID)
+	}
+
+	e := ExplainCommute(EstimateTravelTime, office)(listings[1])
+	assert.Equal(t, "commute to office", e.Criterion)
+	assert.Regexp(t, `^commute to office \d+ min > max 20 min$`, e.Detail)
+	assert.Greater(t, e.Margin, 0.0)
+	assert.Equal(t, "commute to office unknown", ExplainCommute(EstimateTravelTime, office)(listings[2]).Detail)
+	assert.True(t, ExplainCommutes(EstimateTravelTime)(listings[2]).Passed, "no targets")
+}
+
+func TestExplainLines(t *testing.T) {
+	stopMap, _ := nearestStopsFixture()
+	listings := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.3400, Y: 48.2090}},
+		{ID: 2, Location: &dto.Coordinates{X: 16.3389, Y: 48.2146}}, // 400 m north of Josefstädter Straße
+		{ID: 3, Location: &dto.Coordinates{X: 16.5, Y: 48.3}},
+		{ID: 4},
+	}
+	lines := ExplainLines(stopMap, []string{"U6"}, 300, dto.DistanceFormulaDefault.Func())
+	types := ExplainLineTypes(stopMap, []dto.LineType{dto.LineTypeTram}, 300, dto.DistanceFormulaDefault.Func())
+	for _, l := range listings {
+		assert.Equal(t, FilterLines(stopMap, []string{"U6"}, 300, dto.DistanceFormulaDefault.Func())(l), lines(l).Passed, l.ID)
+		assert.Equal(t, FilterLineTypes(stopMap, []dto.LineType{dto.LineTypeTram}, 300, dto.DistanceFormulaDefault.Func())(l), types(l).Passed, l.ID)
+	}
+
+	e := lines(listings[0])
+	assert.True(t, e.Passed)
+	assert.Regexp(t, `^near U6: Josefstädter Straße \d+ m within max 300 m$`, e.Detail)
+	e = lines(listings[1])
+	assert.False(t, e.Passed)
+	assert.Greater(t, e.Margin, 0.0)
+	assert.Greater(t, e.Score, 0.0)
+	assert.Equal(t, "near U6: no stop within 600 m", lines(listings[2]).Detail)
+	assert.Equal(t, "near U6: location unknown", lines(listings[3]).Detail)
+	assert.Equal(t, "near U6: not near the walking network", ExplainLines(stopMap, []string{"U6"}, 300, unreachable)(listings[0]).Detail)
+}
+
+func ids(ls ImmoListings) []uint64 {
+	res := make([]uint64, len(ls))
+	for i, l := range ls {
+		res[i] = l.ID
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const IsochroneCircleSegments = 24 // number of segments used to approximate the walking circles of an isochrone
+
+// DefaultIsochroneBudgets are the travel time budgets used if none are given to Isochrones.
+var DefaultIsochroneBudgets = []time.Duration{10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 45 * time.Minute}
+
+/*
+Isochrone is the area reachable from an origin within a travel time budget.
+
+It is the union of its polygons: a walking circle around the origin and one around
+every stop reached within the budget, sized by the walking time left on arrival.
+The polygons may overlap.
+*/
+type Isochrone struct {
+	Origin   dto.Coordinates
+	Budget   time.Duration
+	Polygons []dto.Polygon
+}
+
+// Contains reports whether the coordinates lie inside the isochrone.
+func (iso Isochrone) Contains(c dto.Coordinates) bool {
+	return slices.ContainsFunc(iso.Polygons, func(p dto.Polygon) bool {
+		return p.Contains(c)
+	})
+}
+
+// walkingCircle is the area that can be walked to from center in the remaining time.
+type walkingCircle struct {
+	center dto.Coordinates
+	radius float64
+}
+
+// covers reports whether the walking circle completely contains the other one.
+func (wc walkingCircle) covers(other walkingCircle) bool {
+	return wc.center.Distance(other.center, dto.DistanceFormulaDefault)+other.radius <= wc.radius
+}
+
+/*
+Isochrones computes one Isochrone per budget around origin when leaving at departAt.
+If no budgets are given, DefaultIsochroneBudgets are used. The result is ordered like the budgets.
+*/
+func (g *TransitGraph) Isochrones(origin dto.Coordinates, departAt time.Time, budgets ...time.Duration) []Isochrone {
+	if len(budgets) == 0 {
+		budgets = DefaultIsochroneBudgets
+	}
+	r := g.reach(origin, departAt)
+
+	isochrones := make([]Isochrone, 0, len(budgets))
+	for _, budget := range budgets {
+		circles := []walkingCircle{{center: origin, radius: walkingRadius(budget)}}
+		for i, t := range r {
+			if t >= budget {
+				continue
+			}
+			circles = append(circles, walkingCircle{center: g.nodes[i].stop.Location, radius: walkingRadius(budget - t)})
+		}
+
+		// drop circles that are covered by larger ones to keep the polygons small
+		slices.SortFunc(circles, func(a, b walkingCircle) int {
+			return cmp.Compare(b.radius, a.radius)
+		})
+		kept := make([]walkingCircle, 0, len(circles))
+		for _, c := range circles {
+			if !slices.ContainsFunc(kept, func(k walkingCircle) bool { return k.covers(c) }) {
+				kept = append(kept, c)
+			}
+		}
+
+		iso := Isochrone{Origin: origin, Budget: budget, Polygons: make([]dto.Polygon, len(kept))}
+		for i, c := range kept {
+			iso.Polygons[i] = dto.Circle(c.center, c.radius, IsochroneCircleSegments)
+		}
+		isochrones = append(isochrones, iso)
+	}
+	return isochrones
+}
+
+// walkingRadius returns the straight-line distance that can be walked in the given time.
+func walkingRadius(d time.Duration) float64 {
+	return d.Seconds() * WalkingSpeed / WalkingDetour
+}
+
+/*
+FilterIsochrone returns a filter function that filters ImmoListings
+based on their location. The filter function returns true if the
+ImmoListing lies inside the isochrone. Listings without location
+never pass.
+*/
+func FilterIsochrone(iso Isochrone) ImmoListingsFilter {
+	return func(il ImmoListing) bool {
+		return il.Location != nil && iso.Contains(*il.Location)
+	}
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// geoJSONRing converts a polygon into a closed GeoJSON linear ring of [longitude, latitude] positions.
+func geoJSONRing(p dto.Polygon) [][2]float64 {
+	ring := make([][2]float64, 0, len(p)+1)
+	for _, c := range p {
+		ring = append(ring, [2]float64{c.X, c.Y})
+	}
+	if len(p) > 0 {
+		ring = append(ring, [2]float64{p[0].X, p[0].Y})
+	}
+	return ring
+}
+
+/*
+geoJSONFeatures converts the isochrone into one Polygon feature per polygon. The polygons overlap,
+which GeoJSON forbids within a MultiPolygon, so they are exported separately and tagged with the
+budget and origin of their isochrone.
+*/
+func (iso Isochrone) geoJSONFeatures() []geoJSONFeature {
+	features := make([]geoJSONFeature, len(iso.Polygons))
+	for i, p := range iso.Polygons {
+		features[i] = geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{geoJSONRing(p)}},
+			Properties: map[string]any{
+				"budgetMinutes": iso.Budget.Minutes(),
+				"origin":        [2]float64{iso.Origin.X, iso.Origin.Y},
+			},
+		}
+	}
+	return features
+}
+
+/*
+IsochronesGeoJSON exports the isochrones as a GeoJSON FeatureCollection with one Polygon feature per polygon
+of an isochrone, in the order of the isochrones. The features of an isochrone share its budgetMinutes property.
+*/
+func IsochronesGeoJSON(isochrones []Isochrone) ([]byte, error) {
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0, len(isochrones))}
+	for _, iso := range isochrones {
+		fc.Features = append(fc.Features, iso.geoJSONFeatures()...)
+	}
+	return json.Marshal(fc)
+}
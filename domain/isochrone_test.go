@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsochrones(t *testing.T) {
+	stopMap, stops := testNetwork()
+	g := NewTransitGraph(stopMap, nil)
+	a := stops[0].Location
+	// 5 m north of C and D
+	nearC := dto.Coordinates{X: 16.34, Y: 48.20005}
+	nearD := dto.Coordinates{X: 16.36, Y: 48.20005}
+	far := dto.Coordinates{X: 16.5, Y: 48.3}
+
+	// C is reached after the wait and one ride of two hops, D after two rides, see TestTransitGraphTravelTime
+	isos := g.Isochrones(a, tuesday, 10*time.Minute, 20*time.Minute)
+	if !assert.Len(t, isos, 2) {
+		return
+	}
+	assert.Equal(t, 10*time.Minute, isos[0].Budget)
+	assert.Equal(t, 20*time.Minute, isos[1].Budget)
+
+	for _, iso := range isos {
+		assert.Equal(t, a, iso.Origin)
+		assert.True(t, iso.Contains(a))
+		assert.True(t, iso.Contains(nearC))
+		assert.False(t, iso.Contains(far))
+	}
+	assert.False(t, isos[0].Contains(nearD))
+	assert.True(t, isos[1].Contains(nearD))
+
+	// the origin's circle, and per reached stop one that is not covered by a larger one
+	assert.True(t, len(isos[1].Polygons) <= len(stops))
+	for _, p := range isos[1].Polygons {
+		assert.Len(t, p, IsochroneCircleSegments)
+	}
+
+	assert.Len(t, g.Isochrones(a, tuesday), len(DefaultIsochroneBudgets))
+}
+
+func TestIsochronesDropCoveredCircles(t *testing.T) {
+	// within 45 minutes, the walking circle around the origin covers the one around a stop 100 m away
+	stop := &dto.Stop{Name: "A", Location: dto.Coordinates{X: 16.3013, Y: 48.2}}
+	g := NewTransitGraph(map[dto.Line][]*dto.Stop{testU1: {stop}}, nil)
+	isos := g.Isochrones(dto.Coordinates{X: 16.3, Y: 48.2}, tuesday, 45*time.Minute)
+	assert.Len(t, isos[0].Polygons, 1)
+}
+
+func TestFilterIsochrone(t *testing.T) {
+	iso := Isochrone{Polygons: []dto.Polygon{dto.Circle(dto.Coordinates{X: 16.3, Y: 48.2}, 500, IsochroneCircleSegments)}}
+	il := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.301, Y: 48.2}},
+		{ID: 2, Location: &dto.Coordinates{X: 16.32, Y: 48.2}},
+		{ID: 3},
+	}
+	assert.Equal(t, []uint64{1}, ids(il.ApplyFilter(FilterIsochrone(iso))))
+}
+
+func TestIsochronesGeoJSON(t *testing.T) {
+	origin := dto.Coordinates{X: 16.3, Y: 48.2}
+	isos := []Isochrone{
+		{Origin: origin, Budget: 10 * time.Minute, Polygons: []dto.Polygon{{{X: 16.3, Y: 48.2}, {X: 16.31, Y: 48.2}, {X: 16.31, Y: 48.21}}}},
+		{Origin: origin, Budget: 20 * time.Minute, Polygons: []dto.Polygon{dto.Circle(origin, 100, 4), dto.Circle(origin, 50, 4)}},
+	}
+	data, err := IsochronesGeoJSON(isos)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var fc struct {
+		Type     string
+		Features []struct {
+			Type     string
+			Geometry struct {
+				Type        string
+				Coordinates [][][2]float64
+			}
+			Properties map[string]any
+		}
+	}
+	if !assert.NoError(t, json.Unmarshal(data, &fc)) {
+		return
+	}
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	// the overlapping circles of the second isochrone are separate polygons, they would be invalid as a MultiPolygon
+	if !assert.Len(t, fc.Features, 3) {
+		return
+	}
+	first := fc.Features[0]
+	assert.Equal(t, "Feature", first.Type)
+	assert.Equal(t, "Polygon", first.Geometry.Type)
+	assert.Equal(t, 10.0, first.Properties["budgetMinutes"])
+	assert.Equal(t, []any{16.3, 48.2}, first.Properties["origin"])
+	// one closed ring of [longitude, latitude] positions
+	assert.Equal(t, [][][2]float64{{{16.3, 48.2}, {16.31, 48.2}, {16.31, 48.21}, {16.3, 48.2}}}, first.Geometry.Coordinates)
+
+	for _, f := range fc.Features[1:] {
+		assert.Equal(t, "Polygon", f.Geometry.Type)
+		assert.Equal(t, 20.0, f.Properties["budgetMinutes"])
+		if assert.Len(t, f.Geometry.Coordinates, 1) {
+			ring := f.Geometry.Coordinates[0]
+			assert.Len(t, ring, 5)
+			assert.Equal(t, ring[0], ring[4])
+		}
+	}
+}
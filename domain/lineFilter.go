@@ -1,19 +1,139 @@
 package domain
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/ehganzlieb/willfahren/dto"
 )
 
-func FilterStops(stops []dto.Stop, maxdistance float64) ImmoListingsFilter {
+// prefilterSlack widens the Haversine radius used to look up candidate stops, as other distance functions may yield slightly shorter distances.
+const prefilterSlack = 1.01
+
+/*
+FilterStops returns a filter function that filters ImmoListings
+based on their distance to the given stops. The filter function
+returns true if any stop is within maxdistance meters of the
+ImmoListing, measured with the given distance function. Listings
+without location never pass.
+*/
+func FilterStops(stops []dto.Stop, maxdistance float64, distance dto.DistanceFunc) ImmoListingsFilter {
+	sp := make([]*dto.Stop, len(stops))
+	for i := range stops {
+		sp[i] = &stops[i]
+	}
+	return filterNearStops(sp, maxdistance, distance)
+}
+
+/*
+filterNearStops returns a filter function that returns true if any of the stops is within
+maxDistance meters of the ImmoListing. The stops are put into a dto.SpatialIndex once, so
+that every listing only has to be compared with the stops around it. Stops the distance
+function cannot measure the distance to are ignored.
+*/
+func filterNearStops(stops []*dto.Stop, maxDistance float64, distance dto.DistanceFunc) ImmoListingsFilter {
+	index := indexStops(stops)
 	return func(il ImmoListing) bool {
-		for _, stop := range stops {
-			if il.Location == nil {
-				return false
-			}
-			if stop.Location.Distance(*il.Location, dto.DistanceFormulaDefault) <= maxdistance { //TODO: parametrize
+		if il.Location == nil {
+			return false
+		}
+		for _, n := range index.Within(*il.Location, maxDistance*prefilterSlack) {
+			if d, err := distance(n.Location, *il.Location); err == nil && d <= maxDistance {
 				return true
 			}
 		}
 		return false
 	}
 }
+
+// indexStops puts the stops into a dto.SpatialIndex, each once.
+func indexStops(stops []*dto.Stop) *dto.SpatialIndex[*dto.Stop] {
+	index := dto.NewSpatialIndex[*dto.Stop](0)
+	seen := make(map[*dto.Stop]bool)
+	for _, s := range stops {
+		if !seen[s] {
+			seen[s] = true
+			index.Insert(s.Location, s)
+		}
+	}
+	return index
+}
+
+// linesStops returns the stops of the lines in the stop map that match.
+func linesStops(stopMap map[dto.Line][]*dto.Stop, match func(dto.Line) bool) []*dto.Stop {
+	stops := make([]*dto.Stop, 0)
+	for line, ls := range stopMap {
+		if match(line) {
+			stops = append(stops, ls...)
+		}
+	}
+	return stops
+}
+
+/*
+FilterLines returns a filter function that filters ImmoListings
+based on their distance to the stops of the given lines, e.g.
+"within 500 m of any stop on U4 or U6". The lines are given by
+name and looked up in the stop map as returned by
+wlclient.AggregateStops. The filter function returns true if any
+stop of these lines is within maxDistance meters of the
+ImmoListing, measured with the given distance function. Unknown
+line names are ignored, so if none of the names is known, no
+listing passes.
+*/
+func FilterLines(stopMap map[dto.Line][]*dto.Stop, lineNames []string, maxDistance float64, distance dto.DistanceFunc) ImmoListingsFilter {
+	return filterNearStops(linesStops(stopMap, func(l dto.Line) bool {
+		return slices.Contains(lineNames, l.Name)
+	}), maxDistance, distance)
+}
+
+/*
+FilterLineTypes returns a filter function that filters ImmoListings
+based on their distance to the stops of all lines of the given
+types, e.g. "within 300 m of any tram". The filter function returns
+true if any such stop is within maxDistance meters of the
+ImmoListing, measured with the given distance function.
+*/
+func FilterLineTypes(stopMap map[dto.Line][]*dto.Stop, lineTypes []dto.LineType, maxDistance float64, distance dto.DistanceFunc) ImmoListingsFilter {
+	return filterNearStops(linesStops(stopMap, func(l dto.Line) bool {
+		return slices.Contains(lineTypes, l.Type)
+	}), maxDistance, distance)
+}
+
+/*
+FilterNightService returns a filter function that filters ImmoListings
+based on whether they are served by a night bus, i.e. a stop of a
+line of type dto.LineTypeNightBus is within maxDistance meters.
+*/
+func FilterNightService(stopMap map[dto.Line][]*dto.Stop, maxDistance float64, distance dto.DistanceFunc) ImmoListingsFilter {
+	return FilterLineTypes(stopMap, []dto.LineType{dto.LineTypeNightBus}, maxDistance, distance)
+}
+
+/*
+FilterLineSection returns a filter function that filters ImmoListings
+based on their distance to the stops of a line between two stops,
+e.g. "anywhere along the U1 between Karlsplatz and Kagran". The stops
+are given by name and looked up in the routes of the line, as built by
+wlclient.BuildRoutes. The filter function returns true if any stop of
+the section, including both ends, is within maxDistance meters of the
+ImmoListing. An error is returned if a stop cannot be found or the line
+has no routes.
+*/
+func FilterLineSection(routes map[string]dto.LineRoutes, line, from, to string, maxDistance float64, distance dto.DistanceFunc) (ImmoListingsFilter, error) {
+	lr, ok := routes[line]
+	if !ok {
+		return nil, fmt.Errorf("line %s has no routes", line)
+	}
+	a, b := lr.StopByName(from), lr.StopByName(to)
+	if a == nil {
+		return nil, fmt.Errorf("line %s has no stop %s", line, from)
+	}
+	if b == nil {
+		return nil, fmt.Errorf("line %s has no stop %s", line, to)
+	}
+	stops, err := lr.StopsBetween(a, b)
+	if err != nil {
+		return nil, fmt.Errorf("line %s: %w", line, err)
+	}
+	return filterNearStops(stops, maxDistance, distance), nil
+}
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomListings(n int, r *rand.Rand) ImmoListings {
+	il := make(ImmoListings, n)
+	for i := range il {
+		il[i] = ImmoListing{ID: uint64(i), Location: &dto.Coordinates{X: 16.18 + r.Float64()*0.4, Y: 48.12 + r.Float64()*0.2}}
+	}
+	return il
+}
+
+func randomStops(n int, r *rand.Rand) []dto.Stop {
+	stops := make([]dto.Stop, n)
+	for i := range stops {
+		stops[i] = dto.Stop{Location: dto.Coordinates{X: 16.18 + r.Float64()*0.4, Y: 48.12 + r.Float64()*0.2}, Lines: &[]dto.Line{}}
+	}
+	return stops
+}
+
+func BenchmarkFilterStops(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	stops := randomStops(8000, r)
+	listings := randomListings(1000, r)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		listings.ApplyFilter(FilterStops(stops, 300, dto.DistanceFormulaDefault.Func()))
+	}
+}
+
+// north returns the point the given Haversine distance in meters north of c.
+func north(c dto.Coordinates, meters float64) dto.Coordinates {
+	return dto.Coordinates{X: c.X, Y: c.Y + meters/(6371000*math.Pi/180)}
+}
+
+// shortcutNetwork is a walking network whose walks are a fixed share of the Haversine distance.
+type shortcutNetwork float64
+
+func (s shortcutNetwork) WalkingDistance(from, to dto.Coordinates) (float64, error) {
+	return from.HaversineDistance(to) * float64(s), nil
+}
+
+// unreachable is a distance function that cannot measure any distance, like a walking network off the map.
+func unreachable(from, to dto.Coordinates) (float64, error) {
+	return 0, errors.New("not near the walking network")
+}
+
+func TestFilterStops(t *testing.T) {
+	origin := dto.Coordinates{X: 16.35, Y: 48.2}
+	listing := ImmoListing{ID: 1, Location: &origin}
+	haversine, manhattan := dto.DistanceFormulaHaversine.Func(), dto.DistanceFormulaManhattan.Func()
+	shortcut := shortcutNetwork(0.97).WalkingDistance
+
+	for name, tc := range map[string]struct {
+		distance float64 // Haversine distance of the stop
+		measure  dto.DistanceFunc
+		want     bool
+	}{
+		"within":               {299, haversine, true},
+		"just at the limit":    {299.99, haversine, true},
+		"beyond":               {301, haversine, false},
+		"longer by formula":    {295, manhattan, true},
+		"beyond by formula":    {301, manhattan, false},
+		"shorter within slack": {300 * (prefilterSlack - 0.005), shortcut, true},
+		"shorter beyond slack": {300 * (prefilterSlack + 0.01), shortcut, false},
+		"shorter, no shortcut": {300 * (prefilterSlack - 0.005), haversine, false},
+		"not measurable":       {100, unreachable, false},
+	} {
+		stops := []dto.Stop{{Name: "S", Location: north(origin, tc.distance)}}
+		assert.Equal(t, tc.want, FilterStops(stops, 300, tc.measure)(listing), name)
+	}
+
+	assert.False(t, FilterStops([]dto.Stop{{Location: origin}}, 300, dto.DistanceFormulaDefault.Func())(ImmoListing{ID: 2}), "listing without location")
+	assert.False(t, FilterStops(nil, 300, dto.DistanceFormulaDefault.Func())(listing), "no stops")
+}
+
+func lineFilterFixture() (map[dto.Line][]*dto.Stop, dto.Coordinates) {
+	origin := dto.Coordinates{X: 16.35, Y: 48.2}
+	u4 := dto.Line{Name: "U4", Type: dto.LineTypeUBahn}
+	u6 := dto.Line{Name: "U6", Type: dto.LineTypeUBahn}
+	t49 := dto.Line{Name: "49", Type: dto.LineTypeTram}
+	n49 := dto.Line{Name: "N49", Type: dto.LineTypeNightBus}
+	return map[dto.Line][]*dto.Stop{
+		u4:  {{Name: "U4 near", Location: north(origin, 200)}},
+		u6:  {{Name: "U6 far", Location: north(origin, 900)}},
+		t49: {{Name: "49", Location: north(origin, 400)}},
+		n49: {{Name: "N49", Location: north(origin, 600)}},
+	}, origin
+}
+
+func TestFilterLines(t *testing.T) {
+	stopMap, origin := lineFilterFixture()
+	listing := ImmoListing{ID: 1, Location: &origin}
+
+	for name, tc := range map[string]struct {
+		lines       []string
+		maxDistance float64
+		want        bool
+	}{
+		"near line":          {[]string{"U4"}, 300, true},
+		"far line":           {[]string{"U6"}, 300, false},
+		"any of the lines":   {[]string{"U6", "U4"}, 300, true},
+		"far line in reach":  {[]string{"U6"}, 1000, true},
+		"unknown line":       {[]string{"U7"}, 1000, false},
+		"unknown and known":  {[]string{"U7", "49"}, 500, true},
+		"no lines":           {nil, 1000, false},
+		"type is irrelevant": {[]string{"N49"}, 700, true},
+	} {
+		assert.Equal(t, tc.want, FilterLines(stopMap, tc.lines, tc.maxDistance, dto.DistanceFormulaDefault.Func())(listing), name)
+	}
+}
+
+func TestFilterLineTypes(t *testing.T) {
+	stopMap, origin := lineFilterFixture()
+	listing := ImmoListing{ID: 1, Location: &origin}
+
+	for name, tc := range map[string]struct {
+		lineTypes   []dto.LineType
+		maxDistance float64
+		want        bool
+	}{
+		"U-Bahn":         {[]dto.LineType{dto.LineTypeUBahn}, 300, true},
+		"tram too far":   {[]dto.LineType{dto.LineTypeTram}, 300, false},
+		"tram":           {[]dto.LineType{dto.LineTypeTram}, 400, true},
+		"any of types":   {[]dto.LineType{dto.LineTypeBus, dto.LineTypeTram}, 500, true},
+		"no such line":   {[]dto.LineType{dto.LineTypeSBahn}, 5000, false},
+		"no types given": {nil, 5000, false},
+	} {
+		assert.Equal(t, tc.want, FilterLineTypes(stopMap, tc.lineTypes, tc.maxDistance, dto.DistanceFormulaDefault.Func())(listing), name)
+	}
+
+	assert.False(t, FilterNightService(stopMap, 500, dto.DistanceFormulaDefault.Func())(listing))
+	assert.True(t, FilterNightService(stopMap, 600, dto.DistanceFormulaDefault.Func())(listing))
+	assert.False(t, FilterNightService(stopMap, 600, dto.DistanceFormulaDefault.Func())(ImmoListing{ID: 2}), "listing without location")
+}
+
+func TestFilterLineSection(t *testing.T) {
+	origin := dto.Coordinates{X: 16.35, Y: 48.2}
+	stops := []*dto.Stop{
+		{Name: "A", Location: north(origin, 0)},
+		{Name: "B", Location: north(origin, 1000)},
+		{Name: "C", Location: north(origin, 2000)},
+		{Name: "D", Location: north(origin, 3000)},
+	}
+	shape := []dto.Coordinates{stops[0].Location, stops[3].Location}
+	routes := map[string]dto.LineRoutes{"U1": dto.NewRoutes(shape, nil, stops)}
+	near := func(i int) ImmoListing {
+		c := dto.Coordinates{X: stops[i].Location.X + 0.001, Y: stops[i].Location.Y}
+		return ImmoListing{ID: uint64(i), Location: &c}
+	}
+
+	for name, tc := range map[string]struct {
+		from, to string
+		pass     []int
+	}{
+		"forward":     {"B", "C", []int{1, 2}},
+		"backward":    {"C", "A", []int{0, 1, 2}},
+		"single stop": {"D", "D", []int{3}},
+		"whole line":  {"A", "D", []int{0, 1, 2, 3}},
+		"adjacent":    {"A", "B", []int{0, 1}},
+	} {
+		f, err := FilterLineSection(routes, "U1", tc.from, tc.to, 100, dto.DistanceFormulaDefault.Func())
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		for i := range stops {
+			assert.Equal(t, slices.Contains(tc.pass, i), f(near(i)), "%s: stop %d", name, i)
+		}
+	}
+
+	_, err := FilterLineSection(routes, "U1", "A", "X", 100, dto.DistanceFormulaDefault.Func())
+	assert.Error(t, err, "unknown stop")
+	_, err = FilterLineSection(routes, "U2", "A", "B", 100, dto.DistanceFormulaDefault.Func())
+	assert.Error(t, err, "line without routes")
+}
@@ -0,0 +1,285 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	MinMarketSample = 5 // groups with fewer listings are not used to judge a listing, see Market.Compare
+	RoomBucketMax   = 4 // apartments with this many rooms or more share the last room bucket
+)
+
+/*
+NewImmoListings converts apartments, e.g. from cache.All, to ImmoListings.
+The attributes the source left unknown, like the building type, are filled from the descriptions,
+see ImmoListing.ApplyDetails with MinExtractionConfidence. A description is read as of the time the
+apartment was published, or now if that is unknown.
+*/
+func NewImmoListings(apts []dto.Apartment) ImmoListings {
+	now := time.Now()
+	il := make(ImmoListings, len(apts))
+	for i, a := range apts {
+		il[i] = ImmoListing(a)
+		written := now
+		if a.Published != nil {
+			written = *a.Published
+		}
+		il[i].ApplyDetails(il[i].ExtractDetails(written), MinExtractionConfidence)
+	}
+	return il
+}
+
+// PriceStats summarises the monthly rent per m² of a group of listings, in euros.
+type PriceStats struct {
+	Count                    int
+	Min, Q1, Median, Q3, Max float64
+}
+
+/*
+quantile returns the q-quantile of the sorted values, interpolating linearly between
+the closest ranks (as R's default and numpy's "linear" method).
+*/
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// newPriceStats computes the statistics of the values, which are sorted in place.
+func newPriceStats(values []float64) PriceStats {
+	if len(values) == 0 {
+		return PriceStats{}
+	}
+	slices.Sort(values)
+	return PriceStats{
+		Count:  len(values),
+		Min:    values[0],
+		Q1:     quantile(values, 0.25),
+		Median: quantile(values, 0.5),
+		Q3:     quantile(values, 0.75),
+		Max:    values[len(values)-1],
+	}
+}
+
+// pricePerSquareMeter returns the monthly cost per m², ok is false if price or area are unknown.
+var pricePerSquareMeter = PricePerSquareMeter(0).Value
+
+// PriceStats computes the statistics of the rent per m² of the ImmoListings; listings without price or area are skipped.
+func (il ImmoListings) PriceStats() PriceStats {
+	values := make([]float64, 0, len(il))
+	for _, l := range il {
+		if v, ok := pricePerSquareMeter(l); ok {
+			values = append(values, v)
+		}
+	}
+	return newPriceStats(values)
+}
+
+// Grouping assigns a listing to a group like its district; ok is false if the listing belongs to none.
+type Grouping[K comparable] func(ImmoListing) (key K, ok bool)
+
+// GroupByDistrict groups listings by district number.
+func GroupByDistrict(il ImmoListing) (int, bool) {
+	if il.District == nil {
+		return 0, false
+	}
+	return il.District.Number, true
+}
+
+// GroupByNeighbourhood groups listings by the name of the Grätzl they lie in, see ImmoListings.AnnotateNeighbourhoods.
+func GroupByNeighbourhood(il ImmoListing) (string, bool) {
+	if il.Neighbourhood == nil {
+		return "", false
+	}
+	return il.Neighbourhood.Name, true
+}
+
+// GroupByRooms groups listings into room buckets "1", "2", "3" and "4+"; half rooms are rounded down.
+func GroupByRooms(il ImmoListing) (string, bool) {
+	rooms := int(il.Rooms)
+	switch {
+	case rooms < 1:
+		return "", false
+	case rooms >= RoomBucketMax:
+		return strconv.Itoa(RoomBucketMax) + "+", true
+	}
+	return strconv.Itoa(rooms), true
+}
+
+// GroupByBuildingType groups listings by building type; listings of unknown type belong to no group.
+func GroupByBuildingType(il ImmoListing) (dto.BuildingType, bool) {
+	return il.BuildingType, il.BuildingType != dto.BuildingUnknown
+}
+
+// PriceStatsBy computes the statistics of the rent per m² for every group of the ImmoListings.
+func PriceStatsBy[K comparable](il ImmoListings, group Grouping[K]) map[K]PriceStats {
+	values := make(map[K][]float64)
+	for _, l := range il {
+		key, ok := group(l)
+		if !ok {
+			continue
+		}
+		if v, ok := pricePerSquareMeter(l); ok {
+			values[key] = append(values[key], v)
+		}
+	}
+	stats := make(map[K]PriceStats, len(values))
+	for key, vs := range values {
+		stats[key] = newPriceStats(vs)
+	}
+	return stats
+}
+
+// Market holds the rent per m² statistics of a set of listings, overall and by group.
+type Market struct {
+	Overall        PriceStats
+	Districts      map[int]PriceStats
+	Neighbourhoods map[string]PriceStats
+	Rooms          map[string]PriceStats
+	BuildingTypes  map[dto.BuildingType]PriceStats
+}
+
+// NewMarket computes the market statistics of the ImmoListings.
+func NewMarket(il ImmoListings) Market {
+	return Market{
+		Overall:        il.PriceStats(),
+		Districts:      PriceStatsBy(il, GroupByDistrict),
+		Neighbourhoods: PriceStatsBy(il, GroupByNeighbourhood),
+		Rooms:          PriceStatsBy(il, GroupByRooms),
+		BuildingTypes:  PriceStatsBy(il, GroupByBuildingType),
+	}
+}
+
+// MarketComparison tells how the rent per m² of a listing compares with the listings around it.
+type MarketComparison struct {
+	Group               string // the group compared with, e.g. "district 7" or "Brunnenviertel"
+	Stats               PriceStats
+	PricePerSquareMeter float64
+	Ratio               float64 // of the listing's rent per m² to the median of the group
+	Cheap               bool    // below the first quartile
+	Expensive           bool    // above the third quartile
+}
+
+/*
+Compare compares the rent per m² of the listing with the most specific group with at least
+MinMarketSample listings: its Grätzl, then its district, then the whole market. ok is false if the
+listing has no price or area or the market is too small.
+*/
+func (m Market) Compare(il ImmoListing) (MarketComparison, bool) {
+	v, ok := pricePerSquareMeter(il)
+	if !ok {
+		return MarketComparison{}, false
+	}
+	type candidate struct {
+		name  string
+		stats PriceStats
+	}
+	var candidates []candidate
+	if n, ok := GroupByNeighbourhood(il); ok {
+		candidates = append(candidates, candidate{n, m.Neighbourhoods[n]})
+	}
+	if d, ok := GroupByDistrict(il); ok {
+		candidates = append(candidates, candidate{fmt.Sprintf("district %d", d), m.Districts[d]})
+	}
+	candidates = append(candidates, candidate{"all", m.Overall})
+	for _, c := range candidates {
+		if c.stats.Count < MinMarketSample || c.stats.Median == 0 {
+			continue
+		}
+		return MarketComparison{
+			Group:               c.name,
+			Stats:               c.stats,
+			PricePerSquareMeter: v,
+			Ratio:               v / c.stats.Median,
+			Cheap:               v < c.stats.Q1,
+			Expensive:           v > c.stats.Q3,
+		}, true
+	}
+	return MarketComparison{}, false
+}
+
+// CrawlListings are the listings found by one crawl, e.g. from cache.History.
+type CrawlListings struct {
+	At       time.Time
+	Listings ImmoListings
+}
+
+// PricePoint is the rent per m² of a group at the time of a crawl.
+type PricePoint struct {
+	At    time.Time
+	Stats PriceStats
+}
+
+/*
+PriceSeries computes the rent per m² statistics of every group for each crawl, giving a time series
+per group ordered by crawl time. Crawls in which a group has no listings are left out of its series.
+*/
+func PriceSeries[K comparable](crawls []CrawlListings, group Grouping[K]) map[K][]PricePoint {
+	sorted := slices.Clone(crawls)
+	slices.SortStableFunc(sorted, func(a, b CrawlListings) int {
+		return a.At.Compare(b.At)
+	})
+	series := make(map[K][]PricePoint)
+	for _, c := range sorted {
+		for key, stats := range PriceStatsBy(c.Listings, group) {
+			series[key] = append(series[key], PricePoint{At: c.At, Stats: stats})
+		}
+	}
+	return series
+}
+
+/*
+WriteDistrictOverview writes a table of the rent per m² by district, in district order, followed by
+the whole market. Districts without listings are listed with a count of 0.
+*/
+func WriteDistrictOverview(w io.Writer, m Market) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Nr\tDistrict\tCount\tQ1 €/m²\tMedian €/m²\tQ3 €/m²\t")
+	row := func(nr, name string, s PriceStats) {
+		if s.Count == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t0\t–\t–\t–\t\n", nr, name)
+			return
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t\n", nr, name, s.Count, formatRate(s.Q1), formatRate(s.Median), formatRate(s.Q3))
+	}
+	numbers := make([]int, 0, len(m.Districts))
+	for n := 1; ; n++ {
+		if _, err := dto.DistrictByNumber(n); err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	for n := range m.Districts {
+		if !slices.Contains(numbers, n) {
+			numbers = append(numbers, n)
+		}
+	}
+	slices.SortFunc(numbers, cmp.Compare)
+	for _, n := range numbers {
+		name := ""
+		if d, err := dto.DistrictByNumber(n); err == nil {
+			name = d.Name
+		}
+		row(strconv.Itoa(n), name, m.Districts[n])
+	}
+	row("", "Wien", m.Overall)
+	return tw.Flush()
+}
+
+// formatRate formats a rent per m² the Austrian way, like "15,40".
+func formatRate(v float64) string {
+	s := dto.EUR(v).String()
+	return s[len("€ "):]
+}
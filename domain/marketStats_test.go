@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func marketFixture() ImmoListings {
+	seventh, _ := dto.DistrictByNumber(7)
+	eighth, _ := dto.DistrictByNumber(8)
+	listing := func(id uint64, d *dto.District, price float64, area, rooms float32, bt dto.BuildingType) ImmoListing {
+		return ImmoListing{ID: id, District: d, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(price)},
+			Area: area, Rooms: rooms, Attributes: dto.Attributes{BuildingType: bt}}
+	}
+	return ImmoListings{
+		listing(1, seventh, 500, 50, 2, dto.BuildingAltbau),   // 10 €/m²
+		listing(2, seventh, 600, 50, 2, dto.BuildingAltbau),   // 12
+		listing(3, seventh, 700, 50, 2.5, dto.BuildingNeubau), // 14
+		listing(4, seventh, 1000, 50, 1, dto.BuildingNeubau),  // 20
+		listing(5, seventh, 1200, 50, 5, dto.BuildingUnknown), // 24
+		listing(6, eighth, 600, 40, 3, dto.BuildingAltbau),    // 15
+		listing(7, eighth, 850, 50, 4, dto.BuildingUnknown),   // 17
+		listing(8, eighth, 0, 50, 2, dto.BuildingAltbau),      // no price
+		listing(9, nil, 900, 60, 0, dto.BuildingUnknown),      // 15, no district
+	}
+}
+
+func TestPriceStats(t *testing.T) {
+	m := NewMarket(marketFixture())
+
+	// 10 12 14 15 15 17 20 24: quartiles at positions 1.75, 3.5 and 5.25
+	assert.Equal(t, PriceStats{Count: 8, Min: 10, Q1: 13.5, Median: 15, Q3: 17.75, Max: 24}, m.Overall)
+	assert.Equal(t, PriceStats{Count: 5, Min: 10, Q1: 12, Median: 14, Q3: 20, Max: 24}, m.Districts[7])
+	assert.Equal(t, PriceStats{Count: 2, Min: 15, Q1: 15.5, Median: 16, Q3: 16.5, Max: 17}, m.Districts[8])
+	assert.Len(t, m.Districts, 2)
+
+	assert.Equal(t, 1, m.Rooms["1"].Count)
+	assert.Equal(t, PriceStats{Count: 3, Min: 10, Q1: 11, Median: 12, Q3: 13, Max: 14}, m.Rooms["2"])
+	assert.Equal(t, PriceStats{Count: 2, Min: 17, Q1: 18.75, Median: 20.5, Q3: 22.25, Max: 24}, m.Rooms["4+"])
+	assert.Equal(t, 3, m.BuildingTypes[dto.BuildingAltbau].Count)
+	assert.InDelta(t, 12, m.BuildingTypes[dto.BuildingAltbau].Median, 1e-9)
+	assert.Len(t, m.BuildingTypes, 2)
+
+	assert.Equal(t, PriceStats{}, ImmoListings{}.PriceStats())
+}
+
+func TestMarketCompare(t *testing.T) {
+	m := NewMarket(marketFixture())
+	seventh, _ := dto.DistrictByNumber(7)
+	eighth, _ := dto.DistrictByNumber(8)
+
+	c, ok := m.Compare(ImmoListing{District: seventh, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(550)}, Area: 50})
+	if assert.True(t, ok) {
+		assert.Equal(t, "district 7", c.Group)
+		assert.InDelta(t, 11.0/14, c.Ratio, 1e-9)
+		assert.True(t, c.Cheap)
+		assert.False(t, c.Expensive)
+	}
+
+	// too few listings in the 8th, compared with the whole market
+	c, ok = m.Compare(ImmoListing{District: eighth, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1000)}, Area: 50})
+	if assert.True(t, ok) {
+		assert.Equal(t, "all", c.Group)
+		assert.InDelta(t, 20.0/15, c.Ratio, 1e-9)
+		assert.True(t, c.Expensive)
+	}
+
+	_, ok = m.Compare(ImmoListing{District: eighth, Area: 50})
+	assert.False(t, ok)
+	_, ok = NewMarket(nil).Compare(ImmoListing{Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1000)}, Area: 50})
+	assert.False(t, ok)
+}
+
+func TestPriceSeries(t *testing.T) {
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := march.AddDate(0, 1, 0)
+	all := marketFixture()
+	series := PriceSeries([]CrawlListings{
+		{At: april, Listings: all},
+		{At: march, Listings: all[:3]},
+	}, GroupByDistrict)
+
+	if assert.Len(t, series[7], 2) {
+		assert.Equal(t, march, series[7][0].At)
+		assert.Equal(t, 3, series[7][0].Stats.Count)
+		assert.InDelta(t, 12, series[7][0].Stats.Median, 1e-9)
+		assert.Equal(t, april, series[7][1].At)
+		assert.InDelta(t, 14, series[7][1].Stats.Median, 1e-9)
+	}
+	// the 8th only appears in the April crawl
+	if assert.Len(t, series[8], 1) {
+		assert.Equal(t, april, series[8][0].At)
+	}
+}
+
+func TestWriteDistrictOverview(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, WriteDistrictOverview(&b, NewMarket(marketFixture())))
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	assert.Len(t, lines, 1+23+1)
+	assert.Equal(t, []string{"Nr", "District", "Count", "Q1", "€/m²", "Median", "€/m²", "Q3", "€/m²"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"7", "Neubau", "5", "12,00", "14,00", "20,00"}, strings.Fields(lines[7]))
+	assert.Equal(t, []string{"8", "Josefstadt", "2", "15,50", "16,00", "16,50"}, strings.Fields(lines[8]))
+	assert.Equal(t, []string{"9", "Alsergrund", "0", "–", "–", "–"}, strings.Fields(lines[9]))
+	assert.Equal(t, []string{"Wien", "8", "13,50", "15,00", "17,75"}, strings.Fields(lines[24]))
+}
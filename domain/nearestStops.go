@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"slices"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const NearestStopMaxDistance = 2000.0 // m, stops farther away are not considered near an apartment
+
+// DefaultNearestStopLineTypes are the line types annotated by AnnotateNearestStops if none are given.
+var DefaultNearestStopLineTypes = []dto.LineType{dto.LineTypeUBahn, dto.LineTypeTram, dto.LineTypeBus, dto.LineTypeSBahn}
+
+/*
+StopIndex holds one dto.SpatialIndex of stops per line type, so that the nearest stop of
+a line type can be found without looking at every stop. Stops served by several line types
+are contained in the index of each of them.
+*/
+type StopIndex struct {
+	byType map[dto.LineType]*dto.SpatialIndex[*dto.Stop]
+}
+
+// NewStopIndex builds a StopIndex from the stops per line as returned by wlclient.AggregateStops.
+func NewStopIndex(stopMap map[dto.Line][]*dto.Stop) *StopIndex {
+	si := &StopIndex{byType: make(map[dto.LineType]*dto.SpatialIndex[*dto.Stop])}
+	seen := make(map[dto.LineType]map[*dto.Stop]bool)
+	for line, stops := range stopMap {
+		index, ok := si.byType[line.Type]
+		if !ok {
+			index = dto.NewSpatialIndex[*dto.Stop](0)
+			si.byType[line.Type] = index
+			seen[line.Type] = make(map[*dto.Stop]bool)
+		}
+		for _, s := range stops {
+			if !seen[line.Type][s] {
+				seen[line.Type][s] = true
+				index.Insert(s.Location, s)
+			}
+		}
+	}
+	return si
+}
+
+/*
+Nearest returns the stop of the given line type nearest to c.
+The second return value is false if there is no such stop within maxDistance meters.
+*/
+func (si *StopIndex) Nearest(c dto.Coordinates, lt dto.LineType, maxDistance float64) (dto.NearestStop, bool) {
+	index, ok := si.byType[lt]
+	if !ok {
+		return dto.NearestStop{}, false
+	}
+	nearest := index.Nearest(c, 1, maxDistance)
+	if len(nearest) == 0 {
+		return dto.NearestStop{}, false
+	}
+	return dto.NearestStop{
+		LineType: lt,
+		Stop:     nearest[0].Item,
+		Distance: nearest[0].Distance,
+		Lines:    linesOfType(nearest[0].Item, lt),
+	}, true
+}
+
+// Within returns all stops of the given line type within radius meters of c, ordered by distance.
+func (si *StopIndex) Within(c dto.Coordinates, lt dto.LineType, radius float64) []dto.Neighbour[*dto.Stop] {
+	index, ok := si.byType[lt]
+	if !ok {
+		return nil
+	}
+	return index.Within(c, radius)
+}
+
+// linesOfType returns the lines of the given type serving the stop.
+func linesOfType(s *dto.Stop, lt dto.LineType) []dto.Line {
+	lines := make([]dto.Line, 0)
+	if s.Lines == nil {
+		return lines
+	}
+	for _, l := range *s.Lines {
+		if l.Type == lt && !slices.Contains(lines, l) {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+/*
+AnnotateNearestStops attaches the nearest stop of each of the given line types to every
+ImmoListing, together with its distance and the lines of that type serving it.
+If no line types are given, DefaultNearestStopLineTypes are used. Line types without a stop
+within NearestStopMaxDistance are left out, and listings without location get no annotations.
+The original ImmoListings is not modified, the annotated copy is returned.
+*/
+func (il ImmoListings) AnnotateNearestStops(si *StopIndex, lineTypes ...dto.LineType) ImmoListings {
+	if len(lineTypes) == 0 {
+		lineTypes = DefaultNearestStopLineTypes
+	}
+	lc := slices.Clone(il)
+	for i := range lc {
+		lc[i].NearestStops = nil
+		if lc[i].Location == nil {
+			continue
+		}
+		for _, lt := range lineTypes {
+			if ns, ok := si.Nearest(*lc[i].Location, lt, NearestStopMaxDistance); ok {
+				lc[i].NearestStops = append(lc[i].NearestStops, ns)
+			}
+		}
+	}
+	return lc
+}
+
+// NearestStop returns the annotated nearest stop of the given line type, if the listing has one.
+func (il ImmoListing) NearestStop(lt dto.LineType) (dto.NearestStop, bool) {
+	for _, ns := range il.NearestStops {
+		if ns.LineType == lt {
+			return ns, true
+		}
+	}
+	return dto.NearestStop{}, false
+}
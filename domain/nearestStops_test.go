@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func nearestStopsFixture() (map[dto.Line][]*dto.Stop, []*dto.Stop) {
+	u6 := dto.Line{Name: "U6", Type: dto.LineTypeUBahn}
+	u3 := dto.Line{Name: "U3", Type: dto.LineTypeUBahn}
+	t2 := dto.Line{Name: "2", Type: dto.LineTypeTram}
+	t5 := dto.Line{Name: "5", Type: dto.LineTypeTram}
+	b13 := dto.Line{Name: "13A", Type: dto.LineTypeBus}
+
+	stops := []*dto.Stop{
+		{Name: "Josefstädter Straße", Location: dto.Coordinates{X: 16.3389, Y: 48.2110}, Lines: &[]dto.Line{u6, t2, t5}},
+		{Name: "Westbahnhof", Location: dto.Coordinates{X: 16.3377, Y: 48.1966}, Lines: &[]dto.Line{u6, u3, t5}},
+		{Name: "Lerchenfelder Straße", Location: dto.Coordinates{X: 16.3419, Y: 48.2080}, Lines: &[]dto.Line{t2}},
+		{Name: "Piaristengasse", Location: dto.Coordinates{X: 16.3500, Y: 48.2090}, Lines: &[]dto.Line{b13}},
+	}
+	return map[dto.Line][]*dto.Stop{
+		u6:  {stops[0], stops[1]},
+		u3:  {stops[1]},
+		t2:  {stops[0], stops[2]},
+		t5:  {stops[0], stops[1]},
+		b13: {stops[3]},
+	}, stops
+}
+
+func TestStopIndexNearest(t *testing.T) {
+	stopMap, stops := nearestStopsFixture()
+	si := NewStopIndex(stopMap)
+	c := dto.Coordinates{X: 16.3400, Y: 48.2090}
+
+	ns, ok := si.Nearest(c, dto.LineTypeUBahn, NearestStopMaxDistance)
+	if assert.True(t, ok) {
+		assert.Same(t, stops[0], ns.Stop)
+		assert.Equal(t, dto.LineTypeUBahn, ns.LineType)
+		assert.InDelta(t, c.HaversineDistance(stops[0].Location), ns.Distance, 0.01)
+		assert.Equal(t, []dto.Line{{Name: "U6", Type: dto.LineTypeUBahn}}, ns.Lines)
+	}
+
+	// only the tram lines of the stop, each once
+	ns, ok = si.Nearest(c, dto.LineTypeTram, NearestStopMaxDistance)
+	if assert.True(t, ok) {
+		assert.Same(t, stops[2], ns.Stop)
+	}
+	ns, _ = si.Nearest(stops[0].Location, dto.LineTypeTram, NearestStopMaxDistance)
+	assert.Equal(t, []dto.Line{{Name: "2", Type: dto.LineTypeTram}, {Name: "5", Type: dto.LineTypeTram}}, ns.Lines)
+
+	_, ok = si.Nearest(c, dto.LineTypeUBahn, 100)
+	assert.False(t, ok, "no stop within the distance")
+	_, ok = si.Nearest(c, dto.LineTypeSBahn, NearestStopMaxDistance)
+	assert.False(t, ok, "no stop of the type")
+
+	within := si.Within(c, dto.LineTypeUBahn, NearestStopMaxDistance)
+	if assert.Len(t, within, 2, "Westbahnhof is indexed once though served by two U-Bahn lines") {
+		assert.Same(t, stops[0], within[0].Item)
+		assert.Same(t, stops[1], within[1].Item)
+	}
+	assert.Nil(t, si.Within(c, dto.LineTypeSBahn, NearestStopMaxDistance))
+}
+
+func TestEmptyStopIndex(t *testing.T) {
+	si := NewStopIndex(nil)
+	_, ok := si.Nearest(dto.Coordinates{X: 16.34, Y: 48.21}, dto.LineTypeUBahn, NearestStopMaxDistance)
+	assert.False(t, ok)
+	assert.Empty(t, si.Within(dto.Coordinates{X: 16.34, Y: 48.21}, dto.LineTypeUBahn, NearestStopMaxDistance))
+
+	il := ImmoListings{{ID: 1, Location: &dto.Coordinates{X: 16.34, Y: 48.21}}}
+	assert.Empty(t, il.AnnotateNearestStops(si)[0].NearestStops)
+}
+
+func TestAnnotateNearestStops(t *testing.T) {
+	stopMap, stops := nearestStopsFixture()
+	si := NewStopIndex(stopMap)
+	il := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.3400, Y: 48.2090}},
+		{ID: 2},
+		{ID: 3, Location: &dto.Coordinates{X: 16.5, Y: 48.3}},
+		{ID: 4, Location: &dto.Coordinates{X: 16.3400, Y: 48.2090}, NearestStops: []dto.NearestStop{{LineType: dto.LineTypeSBahn}}},
+	}
+
+	annotated := il.AnnotateNearestStops(si)
+	assert.Nil(t, il[0].NearestStops, "original not modified")
+
+	// in the order of DefaultNearestStopLineTypes, without S-Bahn, which has no stop
+	var types []dto.LineType
+	for _, ns := range annotated[0].NearestStops {
+		types = append(types, ns.LineType)
+	}
+	assert.Equal(t, []dto.LineType{dto.LineTypeUBahn, dto.LineTypeTram, dto.LineTypeBus}, types)
+	bus, ok := annotated[0].NearestStop(dto.LineTypeBus)
+	if assert.True(t, ok) {
+		assert.Same(t, stops[3], bus.Stop)
+	}
+	_, ok = annotated[0].NearestStop(dto.LineTypeSBahn)
+	assert.False(t, ok)
+
+	assert.Empty(t, annotated[1].NearestStops, "listing without location")
+	assert.Empty(t, annotated[2].NearestStops, "no stop within NearestStopMaxDistance")
+	assert.Equal(t, annotated[0].NearestStops, annotated[3].NearestStops, "earlier annotations are replaced")
+
+	onlyTram := il.AnnotateNearestStops(si, dto.LineTypeTram)
+	if assert.Len(t, onlyTram[0].NearestStops, 1) {
+		assert.Equal(t, dto.LineTypeTram, onlyTram[0].NearestStops[0].LineType)
+	}
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"slices"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+/*
+AnnotateNeighbourhoods sets the Neighbourhood of every ImmoListing to the Grätzl its location lies in,
+with areas such as wienclient.EmbeddedNeighbourhoodAreas.
+Listings without location or outside all areas get none.
+The original ImmoListings is not modified, the annotated copy is returned.
+*/
+func (il ImmoListings) AnnotateNeighbourhoods(areas *dto.NeighbourhoodAreas) ImmoListings {
+	lc := slices.Clone(il)
+	for i := range lc {
+		lc[i].Neighbourhood = nil
+		if lc[i].Location == nil {
+			continue
+		}
+		if n, err := areas.NeighbourhoodFromCoordinates(*lc[i].Location); err == nil {
+			lc[i].Neighbourhood = n
+		}
+	}
+	return lc
+}
+
+/*
+FilterNeighbourhoods returns a filter function that keeps ImmoListings lying in one of the
+given neighbourhoods, see AnnotateNeighbourhoods. The neighbourhoods are given by name, alias
+or insider name (see dto.NeighbourhoodByName); unknown names are ignored.
+Listings without a neighbourhood are removed.
+*/
+func FilterNeighbourhoods(names ...string) ImmoListingsFilter {
+	wanted := make([]string, 0, len(names))
+	for _, name := range names {
+		if n, err := dto.NeighbourhoodByName(name); err == nil {
+			wanted = append(wanted, n.Name)
+		}
+	}
+	return func(il ImmoListing) bool {
+		return il.Neighbourhood != nil && slices.Contains(wanted, il.Neighbourhood.Name)
+	}
+}
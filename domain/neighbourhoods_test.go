@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterNeighbourhoods(t *testing.T) {
+	// simplified shapes, not drawn borders
+	areas, _ := dto.NewNeighbourhoodAreas(map[string]dto.MultiPolygon{
+		"Brunnenviertel":    {{{X: 16.33, Y: 48.205}, {X: 16.34, Y: 48.205}, {X: 16.34, Y: 48.215}, {X: 16.33, Y: 48.215}}},
+		"Karmeliterviertel": {{{X: 16.37, Y: 48.21}, {X: 16.38, Y: 48.21}, {X: 16.38, Y: 48.22}, {X: 16.37, Y: 48.22}}},
+	})
+	listings := ImmoListings{
+		{ID: 1, Location: &dto.Coordinates{X: 16.3372, Y: 48.2108}}, // Brunnenmarkt
+		{ID: 2, Location: &dto.Coordinates{X: 16.3787, Y: 48.2168}}, // Karmelitermarkt
+		{ID: 3, Location: &dto.Coordinates{X: 16.2570, Y: 48.1339}}, // Liesing
+		{ID: 4},
+	}
+	filter := FilterNeighbourhoods("Yppenplatz", "Karmeliterviertel", "Atlantis")
+	assert.Empty(t, listings.ApplyFilter(filter), "not annotated yet")
+
+	annotated := listings.AnnotateNeighbourhoods(areas)
+	assert.Nil(t, listings[1].Neighbourhood, "original not modified")
+	if assert.NotNil(t, annotated[1].Neighbourhood) {
+		assert.Equal(t, "Karmeliterviertel", annotated[1].Neighbourhood.Name)
+	}
+	assert.Nil(t, annotated[2].Neighbourhood, "outside all areas")
+	assert.Nil(t, annotated[3].Neighbourhood, "no location")
+
+	assert.Equal(t, []uint64{1, 2}, ids(annotated.ApplyFilter(filter)))
+	assert.Equal(t, []uint64{1}, ids(annotated.ApplyFilter(FilterNeighbourhoods("Brunnenmarkt"))))
+}
@@ -0,0 +1,323 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+// Normalization tells how the raw values of a ScoreCriterion are mapped to scores between 0 and 1.
+type Normalization int
+
+const (
+	NormalizeNone   Normalization = iota // the value already is a score between 0 and 1
+	NormalizeMinMax                      // linearly between the worst (0) and best (1) value of the ranked listings
+	NormalizeRank                        // by the share of ranked listings with a worse value, robust against outliers
+)
+
+const (
+	UnknownScore        = 0.5            // score of a criterion whose value is unknown, neither rewarded nor punished
+	LineQualityDistance = 800.0          // m, beyond which a stop does not count for LineQuality
+	FreshnessHalfLife   = 72 * time.Hour // age at which Freshness scores 0.5
+)
+
+/*
+ScoreCriterion is a weighted criterion listings are ranked on. Value returns the raw value of a
+listing, e.g. the price per m², with ok false if it is unknown; lower values are better unless
+HigherIsBetter is set.
+*/
+type ScoreCriterion struct {
+	Name           string
+	Weight         float64
+	Value          func(ImmoListing) (value float64, ok bool)
+	HigherIsBetter bool
+	Normalization  Normalization
+}
+
+// WithWeight returns a copy of the criterion with the given weight.
+func (c ScoreCriterion) WithWeight(weight float64) ScoreCriterion {
+	c.Weight = weight
+	return c
+}
+
+// CriterionScore is the share of a criterion in the score of a listing.
+type CriterionScore struct {
+	Criterion    string
+	Weight       float64
+	Value        float64 // raw value
+	Known        bool
+	Score        float64 // normalized, between 0 and 1
+	Contribution float64 // weighted share in the total score; the contributions add up to it
+}
+
+// RankedListing is a listing with its total score between 0 and 1 and its breakdown by criterion.
+type RankedListing struct {
+	Listing   ImmoListing
+	Score     float64
+	Breakdown []CriterionScore
+}
+
+// PricePerSquareMeter rates listings by their monthly cost per m², cheaper is better.
+func PricePerSquareMeter(weight float64) ScoreCriterion {
+	return ScoreCriterion{
+		Name:   "price per m²",
+		Weight: weight,
+		Value: func(il ImmoListing) (float64, bool) {
+			price, ok := il.MonthlyCost()
+			if !ok || il.Area == 0 {
+				return 0, false
+			}
+			return float64(price / il.Area), true
+		},
+		Normalization: NormalizeMinMax,
+	}
+}
+
+/*
+CommuteTime rates listings by the sum of their commute durations in minutes, shorter is better.
+The commutes have to be annotated with ImmoListings.AnnotateCommutes.
+*/
+func CommuteTime(weight float64) ScoreCriterion {
+	return ScoreCriterion{
+		Name:   "commute time",
+		Weight: weight,
+		Value: func(il ImmoListing) (float64, bool) {
+			if len(il.Commutes) == 0 {
+				return 0, false
+			}
+			var total time.Duration
+			for _, c := range il.Commutes {
+				total += c.Duration
+			}
+			return total.Minutes(), true
+		},
+		Normalization: NormalizeMinMax,
+	}
+}
+
+// lineTypeQuality rates how valuable a stop of a line type is; fast, frequent lines are worth more.
+var lineTypeQuality = map[dto.LineType]float64{
+	dto.LineTypeUBahn:      1,
+	dto.LineTypeSBahn:      0.8,
+	dto.LineTypeBadnerBahn: 0.7,
+	dto.LineTypeTram:       0.7,
+	dto.LineTypeBus:        0.5,
+	dto.LineTypeNightBus:   0.2,
+}
+
+/*
+LineQuality rates listings by their best nearest stop: the quality of its line type (1 for U-Bahn,
+0.7 for tram, 0.5 for bus, ...) times how close it is, falling linearly to 0 at LineQualityDistance.
+The nearest stops have to be annotated with ImmoListings.AnnotateNearestStops.
+*/
+func LineQuality(weight float64) ScoreCriterion {
+	return ScoreCriterion{
+		Name:   "line quality",
+		Weight: weight,
+		Value: func(il ImmoListing) (float64, bool) {
+			if len(il.NearestStops) == 0 {
+				return 0, false
+			}
+			best := 0.0
+			for _, ns := range il.NearestStops {
+				q := lineTypeQuality[ns.LineType] * math.Max(0, 1-ns.Distance/LineQualityDistance)
+				best = math.Max(best, q)
+			}
+			return best, true
+		},
+		HigherIsBetter: true,
+		Normalization:  NormalizeNone,
+	}
+}
+
+/*
+FloorScore rates the floor of a listing: the ground floor scores 0.3 (street noise, little light),
+upper floors score better the higher they are if there is a lift, up to 1 from the 5th floor.
+Without lift (or if unknown, see AdvertisesLift) the 2nd floor is best and every floor above costs 0.15.
+*/
+func FloorScore(weight float64) ScoreCriterion {
+	return ScoreCriterion{
+		Name:   "floor",
+		Weight: weight,
+		Value: func(il ImmoListing) (float64, bool) {
+			if il.Floor == nil {
+				return 0, false
+			}
+			floor := *il.Floor
+			if floor <= 0 {
+				return 0.3, true
+			}
+			if hasLift, _ := il.AdvertisesLift(); hasLift {
+				return math.Min(1, 0.6+0.1*float64(floor-1)), true
+			}
+			if floor <= 2 {
+				return 0.6 + 0.4*float64(floor-1), true
+			}
+			return math.Max(0, 1-0.15*float64(floor-2)), true
+		},
+		HigherIsBetter: true,
+		Normalization:  NormalizeNone,
+	}
+}
+
+/*
+OutdoorSpaceScore rates listings by the total area of their outdoor spaces, reaching 1 at 10 m².
+An outdoor space of unknown size counts as 5 m²; listings without any score 0.
+*/
+func OutdoorSpaceScore(weight float64) ScoreCriterion {
+	return ScoreCriterion{
+		Name:   "outdoor space",
+		Weight: weight,
+		Value: func(il ImmoListing) (float64, bool) {
+			var area float64
+			for _, os := range il.OutdoorSpaces {
+				if os.Area == 0 {
+					area += 5
+				} else {
+					area += float64(os.Area)
+				}
+			}
+			return math.Min(1, area/10), true
+		},
+		HigherIsBetter: true,
+		Normalization:  NormalizeNone,
+	}
+}
+
+/*
+Freshness rates listings by the time since they were published, halving the score every halfLife,
+so that new listings, which are still available, rank higher. now is the time of ranking.
+*/
+func Freshness(weight float64, now time.Time, halfLife time.Duration) ScoreCriterion {
+	return ScoreCriterion{
+		Name:   "freshness",
+		Weight: weight,
+		Value: func(il ImmoListing) (float64, bool) {
+			if il.Published == nil {
+				return 0, false
+			}
+			age := math.Max(0, now.Sub(*il.Published).Hours())
+			return math.Pow(0.5, age/halfLife.Hours()), true
+		},
+		HigherIsBetter: true,
+		Normalization:  NormalizeNone,
+	}
+}
+
+// DefaultScoring returns the criteria with default weights; adjust them with WithWeights.
+func DefaultScoring(now time.Time) Scoring {
+	return Scoring{
+		PricePerSquareMeter(3),
+		CommuteTime(3),
+		LineQuality(2),
+		FloorScore(1),
+		OutdoorSpaceScore(1),
+		Freshness(1, now, FreshnessHalfLife),
+	}
+}
+
+// Scoring is a set of criteria to rank listings on.
+type Scoring []ScoreCriterion
+
+/*
+WithWeights returns a copy of the scoring with the weights of the named criteria replaced, e.g.
+for user settings. A weight of 0 disables a criterion. An error is returned for unknown names
+and negative weights.
+*/
+func (s Scoring) WithWeights(weights map[string]float64) (Scoring, error) {
+	res := slices.Clone(s)
+	for name, w := range weights {
+		i := slices.IndexFunc(res, func(c ScoreCriterion) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown criterion %q", name)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("negative weight %g for criterion %q", w, name)
+		}
+		res[i] = res[i].WithWeight(w)
+	}
+	return res, nil
+}
+
+/*
+Rank scores the ImmoListings on the criteria and returns them best first. The score of a listing
+is the weighted mean of the normalized criterion scores; criteria with unknown value count with
+UnknownScore. Listings with equal scores keep their order. If no criterion has a positive weight,
+all listings score 0.
+*/
+func (il ImmoListings) Rank(criteria ...ScoreCriterion) []RankedListing {
+	ranked := make([]RankedListing, len(il))
+	for i, l := range il {
+		ranked[i] = RankedListing{Listing: l, Breakdown: make([]CriterionScore, len(criteria))}
+	}
+	var totalWeight float64
+	for _, c := range criteria {
+		totalWeight += math.Max(0, c.Weight)
+	}
+
+	for ci, c := range criteria {
+		values := make([]float64, 0, len(il))
+		for i, l := range il {
+			v, ok := c.Value(l)
+			ranked[i].Breakdown[ci] = CriterionScore{Criterion: c.Name, Weight: c.Weight, Value: v, Known: ok}
+			if ok {
+				values = append(values, v)
+			}
+		}
+		normalize := normalizer(c, values)
+		for i := range ranked {
+			cs := &ranked[i].Breakdown[ci]
+			cs.Score = UnknownScore
+			if cs.Known {
+				cs.Score = normalize(cs.Value)
+			}
+			if totalWeight > 0 && c.Weight > 0 {
+				cs.Contribution = cs.Score * c.Weight / totalWeight
+			}
+			ranked[i].Score += cs.Contribution
+		}
+	}
+
+	slices.SortStableFunc(ranked, func(a, b RankedListing) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return ranked
+}
+
+// normalizer returns the function mapping the raw values of the criterion to scores, given all known values.
+func normalizer(c ScoreCriterion, values []float64) func(float64) float64 {
+	orient := func(s float64) float64 {
+		if c.HigherIsBetter {
+			return s
+		}
+		return 1 - s
+	}
+	switch c.Normalization {
+	case NormalizeMinMax:
+		lo, hi := slices.Min(append(values, math.Inf(1))), slices.Max(append(values, math.Inf(-1)))
+		return func(v float64) float64 {
+			if hi == lo {
+				return 1
+			}
+			return orient((v - lo) / (hi - lo))
+		}
+	case NormalizeRank:
+		sorted := slices.Sorted(slices.Values(values))
+		return func(v float64) float64 {
+			if len(sorted) < 2 {
+				return 1
+			}
+			// the mean position of v among the values, so that equal values score equally
+			below, _ := slices.BinarySearch(sorted, v)
+			above, _ := slices.BinarySearch(sorted, math.Nextafter(v, math.Inf(1)))
+			return orient((float64(below+above-1) / 2) / float64(len(sorted)-1))
+		}
+	}
+	return func(v float64) float64 {
+		return orient(math.Max(0, math.Min(1, v)))
+	}
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+// rankingFixture returns three listings whose scores are worked out by hand in TestRank.
+func rankingFixture(now time.Time) ImmoListings {
+	third, ground := 3, 0
+	threeDaysAgo := now.Add(-72 * time.Hour)
+	return ImmoListings{
+		{
+			ID: 1, Area: 50, Description: "Altbau mit Lift",
+			Price:        dto.PriceBreakdown{TotalMonthly: dto.EUR(1000)},
+			Commutes:     []dto.Commute{{Target: "work", Duration: 30 * time.Minute}},
+			NearestStops: []dto.NearestStop{{LineType: dto.LineTypeUBahn, Distance: 200}},
+			Published:    &threeDaysAgo,
+			Attributes: dto.Attributes{
+				Floor:         &third,
+				OutdoorSpaces: []dto.OutdoorSpace{{Type: dto.OutdoorSpaceBalcony, Area: 6}},
+			},
+		},
+		{
+			ID: 2, Area: 40,
+			Price:        dto.PriceBreakdown{TotalMonthly: dto.EUR(1200)},
+			Commutes:     []dto.Commute{{Target: "work", Duration: 20 * time.Minute}},
+			NearestStops: []dto.NearestStop{{LineType: dto.LineTypeTram, Distance: 400}},
+			Published:    &now,
+			Attributes:   dto.Attributes{Floor: &ground},
+		},
+		{
+			ID: 3, Area: 60,
+			Price:        dto.PriceBreakdown{TotalMonthly: dto.EUR(1500)},
+			NearestStops: []dto.NearestStop{{LineType: dto.LineTypeBus, Distance: 80}},
+			Attributes: dto.Attributes{
+				OutdoorSpaces: []dto.OutdoorSpace{{Type: dto.OutdoorSpaceTerrace}},
+			},
+		},
+	}
+}
+
+func rankedIDs(ranked []RankedListing) []uint64 {
+	res := make([]uint64, len(ranked))
+	for i, r := range ranked {
+		res[i] = r.Listing.ID
+	}
+	return res
+}
+
+func TestRank(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ranked := rankingFixture(now).Rank(DefaultScoring(now)...)
+
+	/*
+		criterion (weight)    listing 1          listing 2          listing 3
+		price per m² (3)      20 €/m² -> 1       30 €/m² -> 0       25 €/m² -> 0.5
+		commute time (3)      30 min  -> 0       20 min  -> 1       unknown -> 0.5
+		line quality (2)      U 200 m -> 0.75    tram 400 m -> 0.35 bus 80 m -> 0.45
+		floor (1)             3rd, lift -> 0.8   ground -> 0.3      unknown -> 0.5
+		outdoor space (1)     6 m² -> 0.6        none -> 0          terrace -> 0.5
+		freshness (1)         3 days -> 0.5      new -> 1           unknown -> 0.5
+		total / 11            6.4 -> 0.5818      5.0 -> 0.4545      5.4 -> 0.4909
+	*/
+	assert.Equal(t, []uint64{1, 3, 2}, rankedIDs(ranked))
+	assert.InDelta(t, 6.4/11, ranked[0].Score, 1e-6)
+	assert.InDelta(t, 5.4/11, ranked[1].Score, 1e-6)
+	assert.InDelta(t, 5.0/11, ranked[2].Score, 1e-6)
+
+	expected := map[uint64][]float64{
+		1: {1, 0, 0.75, 0.8, 0.6, 0.5},
+		2: {0, 1, 0.35, 0.3, 0, 1},
+		3: {0.5, 0.5, 0.45, 0.5, 0.5, 0.5},
+	}
+	for _, r := range ranked {
+		var sum float64
+		for i, cs := range r.Breakdown {
+			assert.InDelta(t, expected[r.Listing.ID][i], cs.Score, 1e-6, "listing %d, %s", r.Listing.ID, cs.Criterion)
+			sum += cs.Contribution
+		}
+		assert.InDelta(t, r.Score, sum, 1e-9, "the contributions add up to the score")
+	}
+	assert.False(t, ranked[1].Breakdown[1].Known)
+	assert.InDelta(t, 20.0, ranked[0].Breakdown[0].Value, 1e-6)
+}
+
+func TestRankWithWeights(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	scoring, err := DefaultScoring(now).WithWeights(map[string]float64{"commute time": 6})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// totals over 14: listing 1 6.4, listing 2 5.0 + 3, listing 3 5.4 + 1.5
+	ranked := rankingFixture(now).Rank(scoring...)
+	assert.Equal(t, []uint64{2, 3, 1}, rankedIDs(ranked))
+	assert.InDelta(t, 8.0/14, ranked[0].Score, 1e-6)
+
+	_, err = DefaultScoring(now).WithWeights(map[string]float64{"view": 1})
+	assert.EqualError(t, err, `unknown criterion "view"`)
+	_, err = DefaultScoring(now).WithWeights(map[string]float64{"floor": -1})
+	assert.Error(t, err)
+}
+
+func TestNormalizeRank(t *testing.T) {
+	values := map[uint64]float64{1: 10, 2: 20, 3: 20, 4: 40}
+	criterion := ScoreCriterion{
+		Name:          "value",
+		Weight:        1,
+		Value:         func(il ImmoListing) (float64, bool) { return values[il.ID], true },
+		Normalization: NormalizeRank,
+	}
+	ranked := ImmoListings{{ID: 4}, {ID: 2}, {ID: 1}, {ID: 3}}.Rank(criterion)
+	// equal values share their mean position and keep their order
+	assert.Equal(t, []uint64{1, 2, 3, 4}, rankedIDs(ranked))
+	assert.Equal(t, []float64{1, 0.5, 0.5, 0}, []float64{ranked[0].Score, ranked[1].Score, ranked[2].Score, ranked[3].Score})
+}
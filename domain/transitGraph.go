@@ -0,0 +1,317 @@
+package domain
+
+import (
+	"cmp"
+	"container/heap"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	TransferRadius = 300.0 // m, stops closer than this are connected by walking transfers
+	MinHopDistance = 150.0 // m, stops of the same line closer than this are treated as poles of the same stop
+	LineNeighbours = 2     // number of nearest stops of the same line a stop is connected to
+	DwellTime      = 30 * time.Second
+	ReachCacheSize = 64 // number of reachability computations a TransitGraph keeps, the oldest is dropped first
+)
+
+// lineSpeeds are the average travel speeds in m/s of the line types between two stops.
+var lineSpeeds = map[dto.LineType]float64{
+	dto.LineTypeUBahn:          9,
+	dto.LineTypeSBahn:          12,
+	dto.LineTypeBadnerBahn:     8,
+	dto.LineTypeTram:           4.5,
+	dto.LineTypeBus:            4.5,
+	dto.LineTypeGroupTaxi:      5,
+	dto.LineTypeNightBus:       5.5,
+	dto.LineTypeNightGroupTaxi: 5.5,
+	dto.LineTypeOther:          4.5,
+}
+
+// lineHeadways are the usual intervals between two vehicles of the line types.
+var lineHeadways = map[dto.LineType]time.Duration{
+	dto.LineTypeUBahn:          4 * time.Minute,
+	dto.LineTypeSBahn:          15 * time.Minute,
+	dto.LineTypeBadnerBahn:     15 * time.Minute,
+	dto.LineTypeTram:           8 * time.Minute,
+	dto.LineTypeBus:            10 * time.Minute,
+	dto.LineTypeGroupTaxi:      30 * time.Minute,
+	dto.LineTypeNightBus:       30 * time.Minute,
+	dto.LineTypeNightGroupTaxi: 60 * time.Minute,
+	dto.LineTypeOther:          15 * time.Minute,
+}
+
+/*
+lineRuns reports whether lines of the given type are in service at time t.
+Night lines only run between 1:00 and 5:00, when all other lines except the
+U-Bahn on weekend nights are out of service.
+*/
+func lineRuns(lt dto.LineType, t time.Time) bool {
+	night := t.Hour() >= 1 && t.Hour() < 5
+	switch lt {
+	case dto.LineTypeNightBus, dto.LineTypeNightGroupTaxi:
+		return night
+	case dto.LineTypeUBahn:
+		return !night || t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+	default:
+		return !night
+	}
+}
+
+// walkDurationForDistance returns the time needed to walk the given straight-line distance.
+func walkDurationForDistance(distance float64) time.Duration {
+	return time.Duration(distance * WalkingDetour / WalkingSpeed * float64(time.Second))
+}
+
+// transitNode is a stop served by a specific line.
+type transitNode struct {
+	stop *dto.Stop
+	line dto.Line
+}
+
+type transitEdge struct {
+	to       int
+	duration time.Duration
+	walk     bool // walking edges require waiting for the line of the target node
+}
+
+/*
+TransitGraph is a simple routing graph built from the stops of the transit network.
+
+Every stop is represented once per line serving it. Stops of the same line are connected
+by ride edges, and nearby stops are connected by walking transfers. Travel times are
+estimated from distances, line speeds and headways, not from timetables.
+The graph is safe for concurrent use.
+*/
+type TransitGraph struct {
+	nodes []transitNode
+	edges [][]transitEdge
+	index *dto.SpatialIndex[int]
+
+	mu         sync.Mutex
+	cache      map[reachabilityKey]reachability
+	cacheOrder []reachabilityKey // keys of the cache, oldest first
+}
+
+type reachabilityKey struct {
+	origin   dto.Coordinates
+	departAt time.Time
+}
+
+// reachability maps node indices to the time needed to reach them, and is ready to board or alight there.
+type reachability map[int]time.Duration
+
+/*
+NewTransitGraph builds a TransitGraph from the stops per line as returned by wlclient.AggregateStops
+and the routes by line name as returned by wlclient.BuildRoutes, which may be nil.
+
+If there are routes for a line, consecutive stops of its route are connected using the
+distance along the route. Otherwise every stop is connected to its LineNeighbours nearest stops
+of the same line that are at least MinHopDistance away. Closer stops of the same line are
+considered poles of the same stop and connected by walking edges without waiting time.
+*/
+func NewTransitGraph(stopMap map[dto.Line][]*dto.Stop, routes map[string]dto.LineRoutes) *TransitGraph {
+	g := &TransitGraph{
+		index: dto.NewSpatialIndex[int](0),
+		cache: make(map[reachabilityKey]reachability),
+	}
+
+	lineNodes := make(map[dto.Line][]int)
+	for line, stops := range stopMap {
+		for _, s := range stops {
+			g.index.Insert(s.Location, len(g.nodes))
+			lineNodes[line] = append(lineNodes[line], len(g.nodes))
+			g.nodes = append(g.nodes, transitNode{stop: s, line: line})
+		}
+	}
+	g.edges = make([][]transitEdge, len(g.nodes))
+
+	// ride edges
+	for line, nodes := range lineNodes {
+		speed := lineSpeeds[line.Type]
+		ride := func(distance float64) time.Duration {
+			return DwellTime + time.Duration(distance/speed*float64(time.Second))
+		}
+
+		if route, ok := routes[line.Name].Route(dto.DirectionForward); ok && len(route.Stops) > 1 {
+			nodeOfStop := make(map[*dto.Stop]int)
+			for _, i := range nodes {
+				nodeOfStop[g.nodes[i].stop] = i
+			}
+			for k := 1; k < len(route.Stops); k++ {
+				i, iok := nodeOfStop[route.Stops[k-1]]
+				j, jok := nodeOfStop[route.Stops[k]]
+				if !iok || !jok {
+					continue
+				}
+				d := ride(route.Offsets[k] - route.Offsets[k-1])
+				g.addEdge(i, transitEdge{to: j, duration: d})
+				g.addEdge(j, transitEdge{to: i, duration: d})
+			}
+			continue
+		}
+
+		for _, i := range nodes {
+			for _, j := range g.nearestOfLine(i, nodes) {
+				d := ride(g.nodes[i].stop.Location.Distance(g.nodes[j].stop.Location, dto.DistanceFormulaDefault) * TransitDetour)
+				g.addEdge(i, transitEdge{to: j, duration: d})
+				g.addEdge(j, transitEdge{to: i, duration: d})
+			}
+		}
+	}
+
+	// walking transfers
+	for i, n := range g.nodes {
+		for _, nb := range g.index.Within(n.stop.Location, TransferRadius) {
+			j := nb.Item
+			if i == j {
+				continue
+			}
+			g.edges[i] = append(g.edges[i], transitEdge{
+				to:       j,
+				duration: walkDurationForDistance(nb.Distance),
+				walk:     n.line != g.nodes[j].line || nb.Distance > MinHopDistance,
+			})
+		}
+	}
+
+	return g
+}
+
+// addEdge adds the edge unless an edge to the same node already exists.
+func (g *TransitGraph) addEdge(from int, e transitEdge) {
+	for _, o := range g.edges[from] {
+		if o.to == e.to {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], e)
+}
+
+// nearestOfLine returns the LineNeighbours nearest nodes among candidates that are at least MinHopDistance away from node i.
+func (g *TransitGraph) nearestOfLine(i int, candidates []int) []int {
+	distances := make(map[int]float64)
+	nearest := make([]int, 0)
+	for _, j := range candidates {
+		d := g.nodes[i].stop.Location.Distance(g.nodes[j].stop.Location, dto.DistanceFormulaDefault)
+		if d >= MinHopDistance {
+			distances[j] = d
+			nearest = append(nearest, j)
+		}
+	}
+	slices.SortFunc(nearest, func(a, b int) int {
+		return cmp.Compare(distances[a], distances[b])
+	})
+	return nearest[:min(LineNeighbours, len(nearest))]
+}
+
+// wait returns the expected waiting time for the line of node i.
+func (g *TransitGraph) wait(i int) time.Duration {
+	return lineHeadways[g.nodes[i].line.Type] / 2
+}
+
+/*
+reach computes the time needed to reach every node of the graph from origin when leaving at departAt.
+Nodes farther away than MaxWalkingDistance are not used as access stops. The last ReachCacheSize results are cached.
+*/
+func (g *TransitGraph) reach(origin dto.Coordinates, departAt time.Time) reachability {
+	key := reachabilityKey{origin: origin, departAt: departAt}
+	g.mu.Lock()
+	r, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok {
+		return r
+	}
+
+	r = make(reachability)
+	pq := &durationQueue{}
+	for _, nb := range g.index.Within(origin, MaxWalkingDistance) {
+		if !lineRuns(g.nodes[nb.Item].line.Type, departAt) {
+			continue
+		}
+		heap.Push(pq, queueItem{node: nb.Item, duration: walkDurationForDistance(nb.Distance) + g.wait(nb.Item)})
+	}
+
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(queueItem)
+		if _, done := r[item.node]; done {
+			continue
+		}
+		r[item.node] = item.duration
+		for _, e := range g.edges[item.node] {
+			if _, done := r[e.to]; done || !lineRuns(g.nodes[e.to].line.Type, departAt) {
+				continue
+			}
+			d := item.duration + e.duration
+			if e.walk {
+				d += g.wait(e.to)
+			}
+			heap.Push(pq, queueItem{node: e.to, duration: d})
+		}
+	}
+
+	g.mu.Lock()
+	if _, ok := g.cache[key]; !ok {
+		g.cacheOrder = append(g.cacheOrder, key)
+		if len(g.cacheOrder) > ReachCacheSize {
+			delete(g.cache, g.cacheOrder[0])
+			g.cacheOrder = g.cacheOrder[1:]
+		}
+	}
+	g.cache[key] = r
+	g.mu.Unlock()
+	return r
+}
+
+/*
+TravelTime returns the estimated travel time between two coordinates when leaving at departAt.
+It can be used as TravelTimeFunc. As the graph is symmetric, the reachability is computed
+from the destination, so that many listings commuting to the same target share one computation.
+It returns an error if the destination cannot be reached at all.
+*/
+func (g *TransitGraph) TravelTime(from, to dto.Coordinates, departAt time.Time) (time.Duration, error) {
+	best := time.Duration(math.MaxInt64)
+	if d := from.Distance(to, dto.DistanceFormulaDefault); d <= MaxWalkingDistance {
+		best = walkDurationForDistance(d)
+	}
+
+	r := g.reach(to, departAt)
+	for _, nb := range g.index.Within(from, MaxWalkingDistance) {
+		t, ok := r[nb.Item]
+		if !ok {
+			continue
+		}
+		if total := t + walkDurationForDistance(nb.Distance); total < best {
+			best = total
+		}
+	}
+
+	if best == time.Duration(math.MaxInt64) {
+		return 0, errors.New("destination not reachable")
+	}
+	return best, nil
+}
+
+type queueItem struct {
+	node     int
+	duration time.Duration
+}
+
+// durationQueue is a min-heap of queueItems ordered by duration.
+type durationQueue []queueItem
+
+func (q durationQueue) Len() int           { return len(q) }
+func (q durationQueue) Less(i, j int) bool { return q[i].duration < q[j].duration }
+func (q durationQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *durationQueue) Push(x any)        { *q = append(*q, x.(queueItem)) }
+func (q *durationQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testU1 = dto.Line{Name: "U1", Type: dto.LineTypeUBahn}
+	testN1 = dto.Line{Name: "N1", Type: dto.LineTypeNightBus}
+
+	// a tuesday and a saturday
+	tuesday  = time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC)
+	saturday = time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC)
+)
+
+/*
+testNetwork returns four U1 stops A–D from west to east along 48.2° N, about 1484 m apart,
+and a night bus N1 from A to a stop E far off in the north.
+*/
+func testNetwork() (map[dto.Line][]*dto.Stop, []*dto.Stop) {
+	stops := []*dto.Stop{
+		{Name: "A", Location: dto.Coordinates{X: 16.30, Y: 48.2}},
+		{Name: "B", Location: dto.Coordinates{X: 16.32, Y: 48.2}},
+		{Name: "C", Location: dto.Coordinates{X: 16.34, Y: 48.2}},
+		{Name: "D", Location: dto.Coordinates{X: 16.36, Y: 48.2}},
+		{Name: "E", Location: dto.Coordinates{X: 16.30, Y: 48.25}},
+	}
+	return map[dto.Line][]*dto.Stop{
+		testU1: stops[:4],
+		testN1: {stops[0], stops[4]},
+	}, stops
+}
+
+// rideDuration is the time the line type takes for the distance, without dwell time.
+func rideDuration(lt dto.LineType, distance float64) time.Duration {
+	return time.Duration(distance / lineSpeeds[lt] * float64(time.Second))
+}
+
+func TestTransitGraphTravelTime(t *testing.T) {
+	stopMap, stops := testNetwork()
+	g := NewTransitGraph(stopMap, nil)
+	a, d := stops[0].Location, stops[3].Location
+	hop := a.Distance(stops[1].Location, dto.DistanceFormulaDefault)
+
+	// without routes, every stop is connected to its two nearest: A–D takes two rides, A–C and C–D, or A–B and B–D
+	want := lineHeadways[dto.LineTypeUBahn]/2 + 2*DwellTime + rideDuration(dto.LineTypeUBahn, 3*hop*TransitDetour)
+	got, err := g.TravelTime(a, d, tuesday)
+	assert.NoError(t, err)
+	assert.InDelta(t, want.Seconds(), got.Seconds(), 1)
+
+	back, err := g.TravelTime(d, a, tuesday)
+	assert.NoError(t, err)
+	assert.InDelta(t, got.Seconds(), back.Seconds(), 1)
+
+	// close by, walking is faster
+	near := dto.Coordinates{X: 16.302, Y: 48.2}
+	got, err = g.TravelTime(a, near, tuesday)
+	assert.NoError(t, err)
+	assert.Equal(t, walkDurationForDistance(a.Distance(near, dto.DistanceFormulaDefault)), got)
+
+	_, err = g.TravelTime(a, dto.Coordinates{X: 16.6, Y: 48.1}, tuesday)
+	assert.Error(t, err, "no stop near the destination")
+}
+
+func TestTransitGraphServiceTimes(t *testing.T) {
+	stopMap, stops := testNetwork()
+	g := NewTransitGraph(stopMap, nil)
+	a, d, e := stops[0].Location, stops[3].Location, stops[4].Location
+
+	// the U-Bahn runs through the night only on weekends, the night bus only at night
+	_, err := g.TravelTime(a, d, tuesday.Add(-5*time.Hour))
+	assert.Error(t, err)
+	_, err = g.TravelTime(a, d, saturday.Add(-5*time.Hour))
+	assert.NoError(t, err)
+	_, err = g.TravelTime(a, e, tuesday)
+	assert.Error(t, err)
+	_, err = g.TravelTime(a, e, tuesday.Add(-5*time.Hour))
+	assert.NoError(t, err)
+}
+
+func TestTransitGraphRoutes(t *testing.T) {
+	stopMap, stops := testNetwork()
+	shape := []dto.Coordinates{stops[0].Location, stops[3].Location}
+	routes := map[string]dto.LineRoutes{"U1": dto.NewRoutes(shape, nil, stopMap[testU1])}
+	g := NewTransitGraph(stopMap, routes)
+	a, d := stops[0].Location, stops[3].Location
+
+	// along the route, stop by stop and without detour
+	want := lineHeadways[dto.LineTypeUBahn]/2 + 3*DwellTime + rideDuration(dto.LineTypeUBahn, routes["U1"][0].Offsets[3])
+	got, err := g.TravelTime(a, d, tuesday)
+	assert.NoError(t, err)
+	assert.InDelta(t, want.Seconds(), got.Seconds(), 1)
+}
+
+func TestTransitGraphCacheIsBounded(t *testing.T) {
+	stopMap, stops := testNetwork()
+	g := NewTransitGraph(stopMap, nil)
+	for i := range ReachCacheSize + 10 {
+		_, _ = g.TravelTime(stops[0].Location, stops[3].Location, tuesday.Add(time.Duration(i)*time.Minute))
+	}
+	assert.Len(t, g.cache, ReachCacheSize)
+	assert.Len(t, g.cacheOrder, ReachCacheSize)
+	// the oldest were dropped
+	assert.NotContains(t, g.cache, reachabilityKey{origin: stops[3].Location, departAt: tuesday})
+	assert.Contains(t, g.cache, reachabilityKey{origin: stops[3].Location, departAt: tuesday.Add((ReachCacheSize + 9) * time.Minute)})
+}
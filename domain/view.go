@@ -0,0 +1,352 @@
+package domain
+
+import (
+	"cmp"
+	"container/heap"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+/*
+SortKey is a value listings are sorted by, ascending unless Descending is set. Listings where
+the value is unknown sort last in either direction.
+*/
+type SortKey struct {
+	Name       string
+	Value      func(ImmoListing) (value float64, ok bool)
+	Descending bool
+}
+
+// Desc returns the key sorting in descending order.
+func (k SortKey) Desc() SortKey {
+	k.Descending = true
+	return k
+}
+
+// ByPrice sorts by the total monthly cost, see MonthlyCost.
+func ByPrice() SortKey {
+	return SortKey{Name: "price", Value: func(il ImmoListing) (float64, bool) {
+		p, ok := il.MonthlyCost()
+		return float64(p), ok
+	}}
+}
+
+// ByPricePerSquareMeter sorts by the monthly cost per m².
+func ByPricePerSquareMeter() SortKey {
+	return SortKey{Name: "price per m²", Value: PricePerSquareMeter(0).Value}
+}
+
+// ByArea sorts by the living area.
+func ByArea() SortKey {
+	return SortKey{Name: "area", Value: func(il ImmoListing) (float64, bool) {
+		return float64(il.Area), il.Area != 0
+	}}
+}
+
+// ByRooms sorts by the number of rooms.
+func ByRooms() SortKey {
+	return SortKey{Name: "rooms", Value: func(il ImmoListing) (float64, bool) {
+		return float64(il.Rooms), il.Rooms != 0
+	}}
+}
+
+// ByPublished sorts by the time of publication, oldest first; use Desc for newest first.
+func ByPublished() SortKey {
+	return SortKey{Name: "published", Value: func(il ImmoListing) (float64, bool) {
+		if il.Published == nil {
+			return 0, false
+		}
+		return float64(il.Published.UnixNano()), true
+	}}
+}
+
+// ByDistance sorts by the Haversine distance to the given point, nearest first.
+func ByDistance(c dto.Coordinates) SortKey {
+	return SortKey{Name: "distance", Value: func(il ImmoListing) (float64, bool) {
+		if il.Location == nil {
+			return 0, false
+		}
+		return il.Location.Distance(c, dto.DistanceFormulaHaversine), true
+	}}
+}
+
+// ByScore sorts by the score of a ranking, see ImmoListings.Rank, best first.
+func ByScore(ranked []RankedListing) SortKey {
+	scores := make(map[uint64]float64, len(ranked))
+	for _, r := range ranked {
+		scores[r.Listing.ID] = r.Score
+	}
+	return SortKey{Name: "score", Descending: true, Value: func(il ImmoListing) (float64, bool) {
+		s, ok := scores[il.ID]
+		return s, ok
+	}}
+}
+
+// sortValue is the value of a SortKey for one listing.
+type sortValue struct {
+	Value float64 `json:"v"`
+	Known bool    `json:"k"`
+}
+
+func compareSortValues(keys []SortKey, a, b []sortValue) int {
+	for i, k := range keys {
+		switch {
+		case a[i].Known != b[i].Known:
+			if a[i].Known {
+				return -1
+			}
+			return 1
+		case a[i].Value != b[i].Value:
+			if k.Descending {
+				return cmp.Compare(b[i].Value, a[i].Value)
+			}
+			return cmp.Compare(a[i].Value, b[i].Value)
+		}
+	}
+	return 0
+}
+
+/*
+View is a selection of ImmoListings in some order. It refers to the listings by index, so that
+filtering, sorting and paging even very large sets copy no listings until Listings is called.
+The underlying ImmoListings must not be modified while views on it are in use.
+*/
+type View struct {
+	listings ImmoListings
+	idx      []int
+	keys     []SortKey     // the keys the view is sorted by, nil if unsorted
+	values   [][]sortValue // values[i] are the key values of listings[idx[i]]
+}
+
+// View returns a view of all ImmoListings in their order.
+func (il ImmoListings) View() View {
+	idx := make([]int, len(il))
+	for i := range idx {
+		idx[i] = i
+	}
+	return View{listings: il, idx: idx}
+}
+
+// Len returns the number of listings in the view.
+func (v View) Len() int {
+	return len(v.idx)
+}
+
+// At returns the i-th listing of the view. It points into the underlying ImmoListings.
+func (v View) At(i int) *ImmoListing {
+	return &v.listings[v.idx[i]]
+}
+
+// Listings copies the listings of the view, in its order, into new ImmoListings.
+func (v View) Listings() ImmoListings {
+	res := make(ImmoListings, len(v.idx))
+	for i, j := range v.idx {
+		res[i] = v.listings[j]
+	}
+	return res
+}
+
+// Filter returns a view of the listings passing the filter, keeping the order.
+func (v View) Filter(filter ImmoListingsFilter) View {
+	res := View{listings: v.listings, idx: make([]int, 0), keys: v.keys}
+	for i, j := range v.idx {
+		if filter(v.listings[j]) {
+			res.idx = append(res.idx, j)
+			if v.values != nil {
+				res.values = append(res.values, v.values[i])
+			}
+		}
+	}
+	return res
+}
+
+// sortValues computes the values of the keys for the listings of the view, once per listing.
+func (v View) sortValues(keys []SortKey) [][]sortValue {
+	values := make([][]sortValue, len(v.idx))
+	flat := make([]sortValue, len(v.idx)*len(keys))
+	for i, j := range v.idx {
+		values[i] = flat[i*len(keys) : (i+1)*len(keys)]
+		for k, key := range keys {
+			values[i][k].Value, values[i][k].Known = key.Value(v.listings[j])
+		}
+	}
+	return values
+}
+
+/*
+Sort returns a view sorted by the keys, the first key deciding first. The sort is stable: listings
+equal in all keys keep their order in v. Each key is evaluated once per listing.
+*/
+func (v View) Sort(keys ...SortKey) View {
+	values := v.sortValues(keys)
+	perm := make([]int, len(v.idx))
+	for i := range perm {
+		perm[i] = i
+	}
+	slices.SortStableFunc(perm, func(a, b int) int {
+		return compareSortValues(keys, values[a], values[b])
+	})
+	res := View{listings: v.listings, idx: make([]int, len(perm)), keys: keys, values: make([][]sortValue, len(perm))}
+	for i, p := range perm {
+		res.idx[i], res.values[i] = v.idx[p], values[p]
+	}
+	return res
+}
+
+// topK is a max-heap of the best k positions of a view, with the worst on top.
+type topK struct {
+	keys   []SortKey
+	values [][]sortValue
+	pos    []int
+}
+
+// less orders worse first; of equal listings the later one is worse, keeping the sort stable.
+func (h *topK) less(a, b int) bool {
+	if c := compareSortValues(h.keys, h.values[a], h.values[b]); c != 0 {
+		return c > 0
+	}
+	return a > b
+}
+
+func (h *topK) Len() int           { return len(h.pos) }
+func (h *topK) Less(i, j int) bool { return h.less(h.pos[i], h.pos[j]) }
+func (h *topK) Swap(i, j int)      { h.pos[i], h.pos[j] = h.pos[j], h.pos[i] }
+func (h *topK) Push(x any)         { h.pos = append(h.pos, x.(int)) }
+func (h *topK) Pop() any {
+	x := h.pos[len(h.pos)-1]
+	h.pos = h.pos[:len(h.pos)-1]
+	return x
+}
+
+/*
+TopK returns a view of the k first listings by the keys, in order, as Sort(keys...) would return
+them. It takes O(n log k) time and O(k) space besides the key values, instead of sorting all listings.
+*/
+func (v View) TopK(k int, keys ...SortKey) View {
+	k = max(0, min(k, len(v.idx)))
+	values := v.sortValues(keys)
+	h := &topK{keys: keys, values: values, pos: make([]int, 0, k+1)}
+	for i := range v.idx {
+		if h.Len() < k {
+			heap.Push(h, i)
+		} else if k > 0 && h.less(h.pos[0], i) {
+			h.pos[0] = i
+			heap.Fix(h, 0)
+		}
+	}
+	res := View{listings: v.listings, idx: make([]int, h.Len()), keys: keys, values: make([][]sortValue, h.Len())}
+	for i := h.Len() - 1; i >= 0; i-- {
+		p := heap.Pop(h).(int)
+		res.idx[i], res.values[i] = v.idx[p], values[p]
+	}
+	return res
+}
+
+// TopK returns the k first ImmoListings by the keys, see View.TopK.
+func (il ImmoListings) TopK(k int, keys ...SortKey) ImmoListings {
+	return il.View().TopK(k, keys...).Listings()
+}
+
+// SortBy returns the ImmoListings sorted by the keys, see View.Sort. The original ImmoListings is not modified.
+func (il ImmoListings) SortBy(keys ...SortKey) ImmoListings {
+	return il.View().Sort(keys...).Listings()
+}
+
+// cursor is the position after a listing in a view: its ID and, if the view is sorted, its key values.
+type cursor struct {
+	Sort   string      `json:"s,omitempty"`
+	Values []sortValue `json:"v,omitempty"`
+	ID     uint64      `json:"id"`
+}
+
+func (v View) sortName() string {
+	names := make([]string, len(v.keys))
+	for i, k := range v.keys {
+		names[i] = k.Name
+		if k.Descending {
+			names[i] += " desc"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+/*
+Page returns up to limit listings of the view following the position of the cursor, and the
+cursor for the next page, which is empty after the last page. An empty cursor starts at the
+beginning. The cursor holds the sort values of the last listing rather than an offset, so paging
+continues at the right place even if listings were added or removed between calls, as long as
+the view is sorted by the same keys; otherwise ErrInvalidCursor is returned. An unsorted view is
+paged by position: the next page starts after the listing of the cursor, and ErrInvalidCursor is
+returned if that listing is no longer in the view.
+*/
+func (v View) Page(after string, limit int) (View, string, error) {
+	if limit < 1 {
+		return View{}, "", fmt.Errorf("invalid page size %d", limit)
+	}
+	start := 0
+	if after != "" {
+		var err error
+		if start, err = v.cursorPosition(after); err != nil {
+			return View{}, "", err
+		}
+	}
+	end := min(start+limit, len(v.idx))
+	page := View{listings: v.listings, idx: v.idx[start:end], keys: v.keys}
+	if v.values != nil {
+		page.values = v.values[start:end]
+	}
+	if end == len(v.idx) {
+		return page, "", nil
+	}
+	c := cursor{Sort: v.sortName(), ID: v.listings[v.idx[end-1]].ID}
+	if v.values != nil {
+		c.Values = v.values[end-1]
+	}
+	next, err := json.Marshal(c)
+	if err != nil {
+		return View{}, "", err
+	}
+	return page, base64.RawURLEncoding.EncodeToString(next), nil
+}
+
+// cursorPosition returns the index of the first listing after the cursor.
+func (v View) cursorPosition(after string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return 0, errors.Join(ErrInvalidCursor, err)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return 0, errors.Join(ErrInvalidCursor, err)
+	}
+	if c.Sort != v.sortName() || len(c.Values) != len(v.keys) {
+		return 0, errors.Join(ErrInvalidCursor, errors.New("cursor is for a different sort order"))
+	}
+	if v.keys == nil {
+		for i, j := range v.idx {
+			if v.listings[j].ID == c.ID {
+				return i + 1, nil
+			}
+		}
+		return 0, errors.Join(ErrInvalidCursor, fmt.Errorf("listing %d of the cursor is not in the view", c.ID))
+	}
+	// the listings equal to the cursor in all keys, in which the listing of the cursor is looked up
+	from := sort.Search(len(v.idx), func(i int) bool { return compareSortValues(v.keys, v.values[i], c.Values) >= 0 })
+	to := sort.Search(len(v.idx), func(i int) bool { return compareSortValues(v.keys, v.values[i], c.Values) > 0 })
+	for i := from; i < to; i++ {
+		if v.listings[v.idx[i]].ID == c.ID {
+			return i + 1, nil
+		}
+	}
+	// the listing is gone: rather repeat its equals than skip any
+	return from, nil
+}
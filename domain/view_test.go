@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func sortFixture() ImmoListings {
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	return ImmoListings{
+		{ID: 1, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1200)}, Area: 60, Rooms: 2, Published: day(3),
+			Location: &dto.Coordinates{X: 16.37, Y: 48.21}},
+		{ID: 2, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(900)}, Area: 45, Rooms: 2, Published: day(5),
+			Location: &dto.Coordinates{X: 16.40, Y: 48.22}},
+		{ID: 3, Area: 80, Rooms: 3, Published: day(1)},
+		{ID: 4, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1200)}, Area: 40, Rooms: 1,
+			Location: &dto.Coordinates{X: 16.372, Y: 48.208}},
+		{ID: 5, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1500)}, Area: 100, Rooms: 4, Published: day(4)},
+	}
+}
+
+func viewIDs(v View) []uint64 {
+	return ids(v.Listings())
+}
+
+func TestSort(t *testing.T) {
+	il := sortFixture()
+	for name, tc := range map[string]struct {
+		keys []SortKey
+		want []uint64
+	}{
+		// unknown prices last, equal prices keep their order
+		"price":             {[]SortKey{ByPrice()}, []uint64{2, 1, 4, 5, 3}},
+		"price desc":        {[]SortKey{ByPrice().Desc()}, []uint64{5, 1, 4, 2, 3}},
+		"price, area":       {[]SortKey{ByPrice(), ByArea()}, []uint64{2, 4, 1, 5, 3}},
+		"price per m²":      {[]SortKey{ByPricePerSquareMeter()}, []uint64{5, 1, 2, 4, 3}},
+		"rooms desc, price": {[]SortKey{ByRooms().Desc(), ByPrice()}, []uint64{5, 3, 2, 1, 4}},
+		"newest":            {[]SortKey{ByPublished().Desc()}, []uint64{2, 5, 1, 3, 4}},
+		"distance":          {[]SortKey{ByDistance(dto.Coordinates{X: 16.3725, Y: 48.2083})}, []uint64{4, 1, 2, 3, 5}},
+	} {
+		assert.Equal(t, tc.want, ids(il.SortBy(tc.keys...)), name)
+		assert.Equal(t, tc.want, viewIDs(il.View().Sort(tc.keys...)), name)
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, ids(il), "the original is not modified")
+}
+
+func TestSortByScore(t *testing.T) {
+	il := sortFixture()
+	ranked := il.Rank(ScoreCriterion{Name: "area", Weight: 1, Value: ByArea().Value, HigherIsBetter: true, Normalization: NormalizeMinMax})
+	assert.Equal(t, []uint64{5, 3, 1, 2, 4}, ids(il.SortBy(ByScore(ranked))))
+}
+
+func TestTopK(t *testing.T) {
+	il := sortFixture()
+	assert.Equal(t, []uint64{2, 1, 4}, ids(il.TopK(3, ByPrice())))
+	assert.Equal(t, []uint64{5}, ids(il.TopK(1, ByArea().Desc())))
+	assert.Empty(t, il.TopK(0, ByPrice()))
+	assert.Equal(t, []uint64{2, 1, 4, 5, 3}, ids(il.TopK(10, ByPrice())))
+
+	// TopK agrees with Sort, ties included, on a larger random set
+	r := rand.New(rand.NewSource(1))
+	large := make(ImmoListings, 2000)
+	for i := range large {
+		large[i] = ImmoListing{ID: uint64(i), Rooms: float32(r.Intn(5)), Area: float32(r.Intn(50))}
+	}
+	keys := []SortKey{ByRooms().Desc(), ByArea()}
+	assert.Equal(t, ids(large.SortBy(keys...)[:50]), ids(large.TopK(50, keys...)))
+}
+
+func TestPage(t *testing.T) {
+	il := sortFixture()
+	v := il.View().Filter(FilterRooms(2, 0)).Sort(ByPrice())
+
+	page, next, err := v.Page("", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{2, 1}, viewIDs(page))
+	assert.NotEmpty(t, next)
+
+	page, last, err := v.Page(next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5, 3}, viewIDs(page))
+	assert.Empty(t, last)
+
+	// a listing inserted before the cursor does not shift the next page
+	grown := append(sortFixture(), ImmoListing{ID: 6, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(800)}, Rooms: 3})
+	page, _, err = grown.View().Filter(FilterRooms(2, 0)).Sort(ByPrice()).Page(next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5, 3}, viewIDs(page))
+
+	// the cursor is after listing 4, which is equal to listing 1 in price
+	all := il.View().Sort(ByPrice())
+	_, next, _ = all.Page("", 3)
+	page, _, _ = all.Page(next, 10)
+	assert.Equal(t, []uint64{5, 3}, viewIDs(page))
+
+	_, _, err = il.View().Sort(ByArea()).Page(next, 2)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+	_, _, err = all.Page("garbage!", 2)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+	_, _, err = il.View().Page(next, 2)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+	_, _, err = all.Page("", 0)
+	assert.Error(t, err)
+}
+
+func TestPageUnsorted(t *testing.T) {
+	il := sortFixture()
+	v := il.View().Filter(FilterRooms(2, 0))
+
+	page, next, err := v.Page("", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, viewIDs(page))
+
+	page, last, err := v.Page(next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 5}, viewIDs(page))
+	assert.Empty(t, last)
+
+	page, last, err = il.View().Page("", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, viewIDs(page))
+	assert.Empty(t, last)
+
+	// listing 2, after which the cursor points, is filtered out
+	_, _, err = il.View().Filter(FilterRooms(3, 0)).Page(next, 2)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+	_, _, err = v.Sort(ByPrice()).Page(next, 2)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+}
+
+func TestApplyFilterDoesNotModify(t *testing.T) {
+	il := sortFixture()
+	filtered := il.ApplyFilter(FilterRooms(3, 0))
+	assert.Equal(t, []uint64{3, 5}, ids(filtered))
+	filtered[0].ID = 42
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, ids(il))
+}
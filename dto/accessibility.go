@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+/*
+Accessibility describes how a stop can be reached without steps. The OGD stop datasets do not
+carry it, so it is only known where the caller sets it from another source.
+*/
+type Accessibility struct {
+	StepFree bool // all platforms can be reached without steps, using elevators where needed
+}
+
+// ElevatorOutage is an elevator reported out of service by the Wiener Linien.
+type ElevatorOutage struct {
+	Station     string
+	Lines       []string
+	Description string
+	Since       time.Time
+	Until       time.Time // zero if the end of the outage is not known
+}
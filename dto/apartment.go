@@ -1,15 +1,24 @@
 package dto
 
-import "net/url"
+import (
+	"net/url"
+	"time"
+)
 
 type Apartment struct {
-	ID          uint64
-	Title       string
-	Description string
-	Area        float32
-	Rooms       float32
-	Price       float32
-	District    *District
-	Location    *Coordinates
-	URL         url.URL
+	ID              uint64
+	Title           string
+	Description     string
+	Area            float32
+	Rooms           float32
+	Price           PriceBreakdown
+	District        *District // as stated by the listing, nil if its postcode belongs to no district
+	Location        *Coordinates
+	URL             url.URL
+	Published       *time.Time     // when the listing was first published, nil if unknown
+	Commutes        []Commute      // filled by domain.ImmoListings.AnnotateCommutes
+	NearestStops    []NearestStop  // filled by domain.ImmoListings.AnnotateNearestStops
+	LocatedDistrict *District      // the district the location lies in, filled by domain.ImmoListings.AnnotateLocatedDistricts
+	Neighbourhood   *Neighbourhood // the Grätzl the location lies in, filled by domain.ImmoListings.AnnotateNeighbourhoods
+	Attributes
 }
@@ -0,0 +1,266 @@
+package dto
+
+import (
+	"strings"
+	"time"
+)
+
+type OutdoorSpaceType int
+
+const (
+	OutdoorSpaceBalcony OutdoorSpaceType = iota
+	OutdoorSpaceTerrace
+	OutdoorSpaceGarden
+	OutdoorSpaceLoggia
+)
+
+func (t OutdoorSpaceType) String() string {
+	return []string{"Balcony", "Terrace", "Garden", "Loggia"}[t]
+}
+
+// OutdoorSpace is a balcony, terrace, garden or loggia belonging to an apartment.
+type OutdoorSpace struct {
+	Type OutdoorSpaceType
+	Area float32 // in m², 0 if unknown
+}
+
+type ParkingType int
+
+const (
+	ParkingUnknown ParkingType = iota
+	ParkingNone
+	ParkingOutdoor // parking space in the yard or in front of the building
+	ParkingGarage
+)
+
+func (t ParkingType) String() string {
+	return []string{"unknown", "none", "outdoor", "garage"}[t]
+}
+
+type Furnishing int
+
+const (
+	FurnishingUnknown Furnishing = iota
+	Unfurnished
+	PartlyFurnished // usually a fitted kitchen only
+	Furnished
+)
+
+func (f Furnishing) String() string {
+	return []string{"unknown", "unfurnished", "partly furnished", "furnished"}[f]
+}
+
+type HeatingType int
+
+const (
+	HeatingUnknown    HeatingType = iota
+	HeatingDistrict               // Fernwärme
+	HeatingGasCentral             // Gaszentralheizung
+	HeatingGasFloor               // Gasetagenheizung, one gas boiler per apartment
+	HeatingHeatPump
+	HeatingElectric
+	HeatingOther
+)
+
+func (t HeatingType) String() string {
+	return []string{"unknown", "district heating", "central gas heating", "gas floor heating", "heat pump", "electric", "other"}[t]
+}
+
+// EnergyClass is the class of the energy certificate (Energieausweis); lower values are better.
+type EnergyClass int
+
+const (
+	EnergyClassUnknown EnergyClass = iota
+	EnergyClassAPlusPlus
+	EnergyClassAPlus
+	EnergyClassA
+	EnergyClassB
+	EnergyClassC
+	EnergyClassD
+	EnergyClassE
+	EnergyClassF
+	EnergyClassG
+)
+
+var energyClassNames = []string{"unknown", "A++", "A+", "A", "B", "C", "D", "E", "F", "G"}
+
+func (c EnergyClass) String() string {
+	return energyClassNames[c]
+}
+
+// BetterThanOrEqual reports whether the class is known and at least as good as other.
+func (c EnergyClass) BetterThanOrEqual(other EnergyClass) bool {
+	return c != EnergyClassUnknown && c <= other
+}
+
+type BuildingType int
+
+const (
+	BuildingUnknown BuildingType = iota
+	BuildingAltbau               // built before 1945, typically high ceilings
+	BuildingNeubau
+	BuildingGemeindebau    // municipal housing
+	BuildingGenossenschaft // cooperative housing
+)
+
+func (t BuildingType) String() string {
+	return []string{"unknown", "Altbau", "Neubau", "Gemeindebau", "Genossenschaft"}[t]
+}
+
+type Condition int
+
+const (
+	ConditionUnknown        Condition = iota
+	ConditionFirstOccupancy           // Erstbezug
+	ConditionRenovated                // saniert, renoviert
+	ConditionGood
+	ConditionNeedsRenovation
+)
+
+func (c Condition) String() string {
+	return []string{"unknown", "first occupancy", "renovated", "good", "needs renovation"}[c]
+}
+
+// RentalTerm is the duration of the lease.
+type RentalTerm struct {
+	Limited bool
+	Months  int // duration of a limited lease, 0 if unknown
+}
+
+/*
+Attributes are the features apartments are compared on. Pointers and the Unknown values of the
+enumerations mean that the source does not tell; filters on an attribute reject apartments where it is unknown.
+*/
+type Attributes struct {
+	OutdoorSpaces []OutdoorSpace
+	Floor         *int // 0 is the ground floor, nil if unknown
+	Lift          *bool
+	Cellar        *bool // cellar compartment (Kellerabteil)
+	Parking       ParkingType
+	Furnishing    Furnishing
+	Heating       HeatingType
+	EnergyClass   EnergyClass
+	YearBuilt     int // 0 if unknown
+	BuildingType  BuildingType
+	AvailableFrom *time.Time // a time in the past means available immediately
+	RentalTerm    *RentalTerm
+	PetsAllowed   *bool
+	Condition     Condition
+}
+
+// OutdoorSpace returns the outdoor space of the given type, if the apartment has one.
+func (a Attributes) OutdoorSpace(t OutdoorSpaceType) (OutdoorSpace, bool) {
+	for _, os := range a.OutdoorSpaces {
+		if os.Type == t {
+			return os, true
+		}
+	}
+	return OutdoorSpace{}, false
+}
+
+// parseLabel returns the value of the first entry of the table whose keyword occurs in the normalized text.
+func parseLabel[T any](s string, table []struct {
+	keyword string
+	value   T
+}) (T, bool) {
+	// padded, so that keywords can require a word boundary like " ol"
+	normalized := " " + NormalizeName(s) + " "
+	for _, e := range table {
+		if strings.Contains(normalized, e.keyword) {
+			return e.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+var outdoorSpaceLabels = []struct {
+	keyword string
+	value   OutdoorSpaceType
+}{
+	{"balkon", OutdoorSpaceBalcony}, {"balcony", OutdoorSpaceBalcony},
+	{"terrass", OutdoorSpaceTerrace},
+	{"garten", OutdoorSpaceGarden}, {"garden", OutdoorSpaceGarden},
+	{"loggia", OutdoorSpaceLoggia},
+}
+
+// ParseOutdoorSpaceType maps a German or English label like "Dachterrasse" to an outdoor space type.
+func ParseOutdoorSpaceType(s string) (OutdoorSpaceType, bool) {
+	return parseLabel(s, outdoorSpaceLabels)
+}
+
+var furnishingLabels = []struct {
+	keyword string
+	value   Furnishing
+}{
+	{"unmobliert", Unfurnished}, {"nicht mobliert", Unfurnished}, {"unfurnished", Unfurnished},
+	{"teilmobliert", PartlyFurnished}, {"teilweise mobliert", PartlyFurnished}, {"partly furnished", PartlyFurnished},
+	{"vollmobliert", Furnished}, {"mobliert", Furnished}, {"furnished", Furnished},
+}
+
+// ParseFurnishing maps a label like "teilmöbliert" to a furnishing level.
+func ParseFurnishing(s string) (Furnishing, bool) {
+	return parseLabel(s, furnishingLabels)
+}
+
+var heatingLabels = []struct {
+	keyword string
+	value   HeatingType
+}{
+	{"fernwarme", HeatingDistrict}, {"fernheizung", HeatingDistrict}, {"district", HeatingDistrict},
+	{"gasetagen", HeatingGasFloor}, {"etagenheizung", HeatingGasFloor}, {"gastherme", HeatingGasFloor},
+	{"gaszentral", HeatingGasCentral}, {"gas zentral", HeatingGasCentral},
+	{"warmepumpe", HeatingHeatPump}, {"heat pump", HeatingHeatPump},
+	{"elektro", HeatingElectric}, {"nachtspeicher", HeatingElectric}, {"infrarot", HeatingElectric}, {"electric", HeatingElectric},
+	{"pellet", HeatingOther}, {" ol", HeatingOther}, {"holz", HeatingOther},
+}
+
+// ParseHeatingType maps a label like "Fernwärme" or "Gasetagenheizung" to a heating type.
+func ParseHeatingType(s string) (HeatingType, bool) {
+	if NormalizeName(s) == "" {
+		return HeatingUnknown, false
+	}
+	return parseLabel(s, heatingLabels)
+}
+
+// ParseEnergyClass maps a label like "A+" or "HWB-Klasse C" to an energy class.
+func ParseEnergyClass(s string) (EnergyClass, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSpace(s[strings.LastIndexAny(s, " :")+1:])
+	for i, name := range energyClassNames[1:] {
+		if s == name {
+			return EnergyClass(i + 1), true
+		}
+	}
+	return EnergyClassUnknown, false
+}
+
+var buildingTypeLabels = []struct {
+	keyword string
+	value   BuildingType
+}{
+	{"gemeindebau", BuildingGemeindebau}, {"gemeindewohnung", BuildingGemeindebau}, {"wiener wohnen", BuildingGemeindebau},
+	{"genossenschaft", BuildingGenossenschaft}, {"gefordert", BuildingGenossenschaft},
+	{"altbau", BuildingAltbau}, {"grunderzeit", BuildingAltbau},
+	{"neubau", BuildingNeubau},
+}
+
+// ParseBuildingType maps a label like "Altbau" or "Genossenschaftswohnung" to a building type.
+func ParseBuildingType(s string) (BuildingType, bool) {
+	return parseLabel(s, buildingTypeLabels)
+}
+
+var conditionLabels = []struct {
+	keyword string
+	value   Condition
+}{
+	{"erstbezug", ConditionFirstOccupancy}, {"first occupancy", ConditionFirstOccupancy},
+	{"sanierungsbedurftig", ConditionNeedsRenovation}, {"renovierungsbedurftig", ConditionNeedsRenovation}, {"needs renovation", ConditionNeedsRenovation},
+	{"saniert", ConditionRenovated}, {"renoviert", ConditionRenovated}, {"renovated", ConditionRenovated},
+	{"gepflegt", ConditionGood}, {" gut", ConditionGood}, {" good", ConditionGood},
+}
+
+// ParseCondition maps a label like "Erstbezug" or "sanierungsbedürftig" to a condition.
+func ParseCondition(s string) (Condition, bool) {
+	return parseLabel(s, conditionLabels)
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAttributeLabels(t *testing.T) {
+	for label, want := range map[string]OutdoorSpaceType{
+		"Balkon": OutdoorSpaceBalcony, "Dachterrasse": OutdoorSpaceTerrace,
+		"Eigengarten": OutdoorSpaceGarden, "Loggia": OutdoorSpaceLoggia,
+	} {
+		got, ok := ParseOutdoorSpaceType(label)
+		assert.True(t, ok, label)
+		assert.Equal(t, want, got, label)
+	}
+	_, ok := ParseOutdoorSpaceType("Keller")
+	assert.False(t, ok)
+
+	for label, want := range map[string]Furnishing{
+		"unmöbliert": Unfurnished, "teilmöbliert": PartlyFurnished, "vollmöbliert": Furnished, "möbliert": Furnished,
+	} {
+		got, _ := ParseFurnishing(label)
+		assert.Equal(t, want, got, label)
+	}
+
+	for label, want := range map[string]HeatingType{
+		"Fernwärme": HeatingDistrict, "Gasetagenheizung": HeatingGasFloor, "Gaszentralheizung": HeatingGasCentral,
+		"Wärmepumpe": HeatingHeatPump, "Nachtspeicheröfen": HeatingElectric, "Öl": HeatingOther,
+	} {
+		got, _ := ParseHeatingType(label)
+		assert.Equal(t, want, got, label)
+	}
+	_, ok = ParseHeatingType("")
+	assert.False(t, ok)
+
+	for label, want := range map[string]EnergyClass{
+		"A++": EnergyClassAPlusPlus, "a+": EnergyClassAPlus, "HWB-Klasse C": EnergyClassC, "Klasse: G": EnergyClassG,
+	} {
+		got, ok := ParseEnergyClass(label)
+		assert.True(t, ok, label)
+		assert.Equal(t, want, got, label)
+	}
+	_, ok = ParseEnergyClass("H")
+	assert.False(t, ok)
+
+	for label, want := range map[string]BuildingType{
+		"Gründerzeit-Altbau": BuildingAltbau, "Genossenschaftswohnung": BuildingGenossenschaft,
+		"Gemeindebau": BuildingGemeindebau, "Neubau": BuildingNeubau,
+	} {
+		got, _ := ParseBuildingType(label)
+		assert.Equal(t, want, got, label)
+	}
+
+	for label, want := range map[string]Condition{
+		"Erstbezug": ConditionFirstOccupancy, "saniert": ConditionRenovated,
+		"sanierungsbedürftig": ConditionNeedsRenovation, "sehr gut": ConditionGood,
+	} {
+		got, _ := ParseCondition(label)
+		assert.Equal(t, want, got, label)
+	}
+}
+
+func TestEnergyClassBetterThanOrEqual(t *testing.T) {
+	assert.True(t, EnergyClassAPlus.BetterThanOrEqual(EnergyClassB))
+	assert.True(t, EnergyClassB.BetterThanOrEqual(EnergyClassB))
+	assert.False(t, EnergyClassD.BetterThanOrEqual(EnergyClassB))
+	assert.False(t, EnergyClassUnknown.BetterThanOrEqual(EnergyClassG))
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+/*
+Commute is the computed travel time from an apartment to a target such as a workplace.
+Target, Location and DepartAt identify the target the commute was computed for, so that a
+commute is only reused for the same place and departure time.
+*/
+type Commute struct {
+	Target   string
+	Location Coordinates
+	DepartAt time.Time
+	Duration time.Duration
+}
@@ -7,11 +7,15 @@ import (
 )
 
 const (
-	EarthMagicNumber = 111.320
+	EarthMagicNumber     = 111.320 // km per degree of latitude, rough value for quick estimates and bounds
+	HaversineEarthRadius = 6371000 // meters, radius of the sphere HaversineDistance works on
 )
 
+// haversineMetersPerDegree is the length of one degree of a great circle on the sphere of HaversineDistance.
+const haversineMetersPerDegree = HaversineEarthRadius * math.Pi / 180
+
 type Coordinates struct {
-	X, Y float64 //in degrees
+	X, Y float64 //in degrees, WGS84 longitude and latitude; see CRSCoordinates for other systems
 }
 
 func (c *Coordinates) toGeoDistPoint() geodist.Point {
@@ -28,8 +32,27 @@ const (
 	DistanceFormulaDefault = DistanceFormulaHaversine
 )
 
+// DistanceFunc returns the distance in meters between two coordinates, or an error if it cannot tell.
+type DistanceFunc func(from, to Coordinates) (float64, error)
+
+// Func returns the formula as a DistanceFunc, which never returns an error.
+func (formula DistanceFormula) Func() DistanceFunc {
+	return func(from, to Coordinates) (float64, error) {
+		return from.Distance(to, formula), nil
+	}
+}
+
+/*
+WalkingNetwork answers shortest walking distance queries in meters, e.g. along an OpenStreetMap street network.
+Its WalkingDistance method is a DistanceFunc.
+*/
+type WalkingNetwork interface {
+	WalkingDistance(from, to Coordinates) (float64, error)
+}
+
 /*
 Distance() calculates the distance between two coordinates using a given distance formula.
+All formulas return meters.
 If the formula is DistanceFormulaVincenty and an error occurs, it falls back to DistanceFormulaHaversine.
 If no valid formula is given, it defaults to DistanceFormulaDefault.
 */
@@ -55,13 +78,14 @@ func (c Coordinates) Distance(other Coordinates, formula DistanceFormula) float6
 ManhattanDistance() calculates the Manhattan distance between two coordinates.
 
 The Manhattan distance is the sum of the absolute differences of their respective coordinates.
-The result is in meters.
+The result is in meters, using the lengths of a degree on the WGS84 ellipsoid at the mean latitude (see MetersPerDegree).
 Note that the Manhattan distance is a simple approximation of the walking distance in cities and does not take into account the curvature of the Earth.
 For a more accurate approximation, use HaversineDistance() or VincentyDistance().
 */
 func (c Coordinates) ManhattanDistance(other Coordinates) float64 {
-	latitudeDifference := math.Abs(c.Y-other.Y) * EarthMagicNumber
-	longitudeDifference := math.Abs(c.X-other.X) * EarthMagicNumber * math.Cos(c.Y*math.Pi/180)
+	latMeters, lonMeters := MetersPerDegree((c.Y + other.Y) / 2)
+	latitudeDifference := math.Abs(c.Y-other.Y) * latMeters
+	longitudeDifference := math.Abs(c.X-other.X) * lonMeters
 
 	return latitudeDifference + longitudeDifference
 
@@ -74,9 +98,15 @@ The Haversine formula is an approximation to the great-circle distance between t
 The result is in meters.
 */
 func (c Coordinates) HaversineDistance(other Coordinates) float64 {
-	return geodist.HaversineDistance(c.toGeoDistPoint(), other.toGeoDistPoint())
+	return geodist.HaversineDistance(c.toGeoDistPoint(), other.toGeoDistPoint()) * 1000 // geodist works in kilometers
 }
 
+/*
+VincentyDistance() calculates the distance between two coordinates using the Vincenty formula on the WGS84 ellipsoid.
+
+The result is in meters. An error is returned if the formula does not converge.
+*/
 func (c Coordinates) VincentyDistance(other Coordinates) (float64, error) {
-	return geodist.VincentyDistance(c.toGeoDistPoint(), other.toGeoDistPoint())
+	dist, err := geodist.VincentyDistance(c.toGeoDistPoint(), other.toGeoDistPoint())
+	return dist * 1000, err // geodist works in kilometers
 }
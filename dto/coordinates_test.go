@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceIsInMeters(t *testing.T) {
+	// one degree of latitude along the meridian of 16° E, between 48° and 49° N
+	a, b := Coordinates{X: 16, Y: 48}, Coordinates{X: 16, Y: 49}
+	// on the sphere of geodist's radius of 6371 km: 6371000 m * π / 180
+	sphere := 6371000 * math.Pi / 180
+
+	assert.InDelta(t, sphere, a.Distance(b, DistanceFormulaHaversine), 0.01)
+	// the meridian arc on the WGS84 ellipsoid at 48.5° N is about 111.2 km
+	assert.InDelta(t, 111200, a.Distance(b, DistanceFormulaVincenty), 10)
+	assert.InDelta(t, 111200, a.Distance(b, DistanceFormulaManhattan), 10)
+	assert.InDelta(t, sphere, a.Distance(b, DistanceFormulaDefault), 0.01)
+}
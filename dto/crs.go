@@ -0,0 +1,259 @@
+package dto
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CRS is a coordinate reference system used by the Vienna OGD datasets.
+type CRS int
+
+const (
+	CRSWGS84            CRS = iota // EPSG:4326, longitude and latitude in degrees
+	CRSMGIAustriaGKEast            // EPSG:31256, MGI / Austria GK East, easting and northing in meters
+	CRSWebMercator                 // EPSG:3857, Web Mercator as used by web maps, in meters
+)
+
+var crsEPSG = []int{4326, 31256, 3857}
+
+func (crs CRS) String() string {
+	return []string{"WGS84", "MGI / Austria GK East", "Web Mercator"}[crs]
+}
+
+// EPSG returns the EPSG code of the CRS.
+func (crs CRS) EPSG() int {
+	return crsEPSG[crs]
+}
+
+/*
+ParseCRS returns the CRS for an EPSG code given as "EPSG:31256", "urn:ogc:def:crs:EPSG::31256",
+"http://www.opengis.net/def/crs/EPSG/0/31256" or just "31256". EPSG:900913 is accepted for Web Mercator.
+*/
+func ParseCRS(s string) (CRS, error) {
+	s = strings.TrimSpace(s)
+	code := s[strings.LastIndexAny(s, ":/")+1:]
+	epsg, err := strconv.Atoi(code)
+	if err != nil {
+		return 0, fmt.Errorf("invalid CRS %q", s)
+	}
+	if epsg == 900913 {
+		return CRSWebMercator, nil
+	}
+	for i, c := range crsEPSG {
+		if c == epsg {
+			return CRS(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported CRS EPSG:%d", epsg)
+}
+
+/*
+CRSCoordinates are coordinates tagged with the CRS they are given in. X is the longitude or easting,
+Y the latitude or northing. Coordinates are always WGS84; use WGS84 and To to convert between both.
+*/
+type CRSCoordinates struct {
+	X, Y float64
+	CRS  CRS
+}
+
+// WGS84 converts the coordinates to WGS84 longitude and latitude.
+func (c CRSCoordinates) WGS84() Coordinates {
+	switch c.CRS {
+	case CRSMGIAustriaGKEast:
+		lon, lat := transverseMercatorInverse(c.X-gkEastFalseEasting, c.Y-gkEastFalseNorthing, gkEastCentralMeridian, bessel)
+		lon, lat = mgiToWGS84(lon, lat)
+		return Coordinates{X: lon, Y: lat}
+	case CRSWebMercator:
+		return Coordinates{
+			X: c.X / webMercatorRadius * 180 / math.Pi,
+			Y: (2*math.Atan(math.Exp(c.Y/webMercatorRadius)) - math.Pi/2) * 180 / math.Pi,
+		}
+	default:
+		return Coordinates{X: c.X, Y: c.Y}
+	}
+}
+
+// To converts the coordinates to the given CRS.
+func (c CRSCoordinates) To(crs CRS) CRSCoordinates {
+	if c.CRS == crs {
+		return c
+	}
+	return c.WGS84().To(crs)
+}
+
+// To converts WGS84 coordinates to the given CRS.
+func (c Coordinates) To(crs CRS) CRSCoordinates {
+	switch crs {
+	case CRSMGIAustriaGKEast:
+		lon, lat := wgs84ToMGI(c.X, c.Y)
+		x, y := transverseMercatorForward(lon, lat, gkEastCentralMeridian, bessel)
+		return CRSCoordinates{X: x + gkEastFalseEasting, Y: y + gkEastFalseNorthing, CRS: crs}
+	case CRSWebMercator:
+		return CRSCoordinates{
+			X:   webMercatorRadius * c.X * math.Pi / 180,
+			Y:   webMercatorRadius * math.Log(math.Tan(math.Pi/4+c.Y*math.Pi/360)),
+			CRS: crs,
+		}
+	default:
+		return CRSCoordinates{X: c.X, Y: c.Y, CRS: CRSWGS84}
+	}
+}
+
+// ellipsoid is a reference ellipsoid given by its semi-major axis in meters and its flattening.
+type ellipsoid struct {
+	a, f float64
+}
+
+func (e ellipsoid) e2() float64 {
+	return e.f * (2 - e.f)
+}
+
+var (
+	wgs84Ellipsoid = ellipsoid{a: 6378137, f: 1 / 298.257223563}
+	bessel         = ellipsoid{a: 6377397.155, f: 1 / 299.1528128}
+)
+
+const (
+	webMercatorRadius = 6378137.0
+
+	gkEastCentralMeridian = 16 + 20.0/60 // degrees
+	gkEastFalseEasting    = 0.0
+	gkEastFalseNorthing   = -5000000.0
+
+	arcSecond = math.Pi / 180 / 3600
+)
+
+/*
+helmert are the parameters of a position vector transformation between two geocentric systems:
+translations in meters, rotations in arc seconds and the scale in ppm.
+*/
+type helmert struct {
+	tx, ty, tz, rx, ry, rz, s float64
+}
+
+// mgiHelmert transforms from MGI to WGS84 (EPSG:1618).
+var mgiHelmert = helmert{577.326, 90.129, 463.919, 5.137, 1.474, 5.297, 2.4232}
+
+// apply transforms geocentric coordinates from the source to the target system.
+func (h helmert) apply(x, y, z float64) (float64, float64, float64) {
+	s := 1 + h.s*1e-6
+	rx, ry, rz := h.rx*arcSecond, h.ry*arcSecond, h.rz*arcSecond
+	return h.tx + s*(x-rz*y+ry*z),
+		h.ty + s*(rz*x+y-rx*z),
+		h.tz + s*(-ry*x+rx*y+z)
+}
+
+// invert is the inverse of apply; the rotation is inverted by transposing, which is exact to a few millimeters.
+func (h helmert) invert(x, y, z float64) (float64, float64, float64) {
+	s := 1 + h.s*1e-6
+	rx, ry, rz := h.rx*arcSecond, h.ry*arcSecond, h.rz*arcSecond
+	x, y, z = (x-h.tx)/s, (y-h.ty)/s, (z-h.tz)/s
+	return x + rz*y - ry*z,
+		-rz*x + y + rx*z,
+		ry*x - rx*y + z
+}
+
+/*
+MetersPerDegree returns the length in meters of one degree of latitude and of longitude at the given latitude
+on the WGS84 ellipsoid. In Vienna, a degree of latitude is about 111.2 km rather than EarthMagicNumber.
+*/
+func MetersPerDegree(latitude float64) (lat, lon float64) {
+	e2 := wgs84Ellipsoid.e2()
+	phi := latitude * math.Pi / 180
+	w := 1 - e2*math.Sin(phi)*math.Sin(phi)
+	meridional := wgs84Ellipsoid.a * (1 - e2) / math.Pow(w, 1.5)
+	primeVertical := wgs84Ellipsoid.a / math.Sqrt(w)
+	return meridional * math.Pi / 180, primeVertical * math.Cos(phi) * math.Pi / 180
+}
+
+// toECEF converts geodetic coordinates in degrees on the ellipsoid to earth-centered cartesian coordinates.
+func toECEF(lon, lat float64, e ellipsoid) (x, y, z float64) {
+	lambda, phi := lon*math.Pi/180, lat*math.Pi/180
+	n := e.a / math.Sqrt(1-e.e2()*math.Sin(phi)*math.Sin(phi))
+	return n * math.Cos(phi) * math.Cos(lambda), n * math.Cos(phi) * math.Sin(lambda), n * (1 - e.e2()) * math.Sin(phi)
+}
+
+// fromECEF converts earth-centered cartesian coordinates to geodetic coordinates in degrees, iterating the latitude.
+func fromECEF(x, y, z float64, e ellipsoid) (lon, lat float64) {
+	p := math.Hypot(x, y)
+	phi := math.Atan2(z, p*(1-e.e2()))
+	for range 10 {
+		n := e.a / math.Sqrt(1-e.e2()*math.Sin(phi)*math.Sin(phi))
+		h := p/math.Cos(phi) - n
+		next := math.Atan2(z, p*(1-e.e2()*n/(n+h)))
+		if math.Abs(next-phi) < 1e-14 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+	return math.Atan2(y, x) * 180 / math.Pi, phi * 180 / math.Pi
+}
+
+// mgiToWGS84 shifts geodetic coordinates from the MGI datum (Bessel ellipsoid) to WGS84.
+func mgiToWGS84(lon, lat float64) (float64, float64) {
+	x, y, z := mgiHelmert.apply(toECEF(lon, lat, bessel))
+	return fromECEF(x, y, z, wgs84Ellipsoid)
+}
+
+// wgs84ToMGI is the inverse of mgiToWGS84.
+func wgs84ToMGI(lon, lat float64) (float64, float64) {
+	x, y, z := mgiHelmert.invert(toECEF(lon, lat, wgs84Ellipsoid))
+	return fromECEF(x, y, z, bessel)
+}
+
+// meridianArc returns the distance in meters from the equator to the latitude phi (radians) along the meridian.
+func meridianArc(phi float64, e ellipsoid) float64 {
+	e2 := e.e2()
+	e4, e6 := e2*e2, e2*e2*e2
+	return e.a * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+}
+
+/*
+transverseMercatorForward projects geodetic coordinates in degrees with scale factor 1 on the central meridian,
+using the series of Snyder, Map Projections - A Working Manual, p. 61. Within a few degrees of the
+central meridian, the error is well below a millimeter.
+*/
+func transverseMercatorForward(lon, lat, centralMeridian float64, e ellipsoid) (x, y float64) {
+	e2 := e.e2()
+	ep2 := e2 / (1 - e2)
+	phi := lat * math.Pi / 180
+	sin, cos, tan := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+	n := e.a / math.Sqrt(1-e2*sin*sin)
+	t := tan * tan
+	c := ep2 * cos * cos
+	a := (lon - centralMeridian) * math.Pi / 180 * cos
+
+	x = n * (a + (1-t+c)*math.Pow(a, 3)/6 + (5-18*t+t*t+72*c-58*ep2)*math.Pow(a, 5)/120)
+	y = meridianArc(phi, e) + n*tan*(a*a/2+(5-t+9*c+4*c*c)*math.Pow(a, 4)/24+(61-58*t+t*t+600*c-330*ep2)*math.Pow(a, 6)/720)
+	return x, y
+}
+
+// transverseMercatorInverse is the inverse of transverseMercatorForward, after Snyder p. 63.
+func transverseMercatorInverse(x, y, centralMeridian float64, e ellipsoid) (lon, lat float64) {
+	e2 := e.e2()
+	ep2 := e2 / (1 - e2)
+	mu := y / (e.a * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi1 := mu + (3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sin, cos, tan := math.Sin(phi1), math.Cos(phi1), math.Tan(phi1)
+	c := ep2 * cos * cos
+	t := tan * tan
+	n := e.a / math.Sqrt(1-e2*sin*sin)
+	r := e.a * (1 - e2) / math.Pow(1-e2*sin*sin, 1.5)
+	d := x / n
+
+	phi := phi1 - (n*tan/r)*(d*d/2-(5+3*t+10*c-4*c*c-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t+298*c+45*t*t-252*ep2-3*c*c)*math.Pow(d, 6)/720)
+	lambda := (d - (1+2*t+c)*math.Pow(d, 3)/6 + (5-2*c+28*t-3*c*c+8*ep2+24*t*t)*math.Pow(d, 5)/120) / cos
+	return centralMeridian + lambda*180/math.Pi, phi * 180 / math.Pi
+}
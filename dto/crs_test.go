@@ -0,0 +1,85 @@
+package dto
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCRSRoundTrip(t *testing.T) {
+	stephansplatz := Coordinates{X: 16.372504, Y: 48.208354}
+	for _, crs := range []CRS{CRSWGS84, CRSMGIAustriaGKEast, CRSWebMercator} {
+		p := stephansplatz.To(crs)
+		assert.Equal(t, crs, p.CRS)
+		back := p.WGS84()
+		assert.InDelta(t, 0, stephansplatz.HaversineDistance(back), 0.01, crs.String())
+	}
+
+	gk := stephansplatz.To(CRSMGIAustriaGKEast)
+	wm := stephansplatz.To(CRSWebMercator)
+	assert.InDelta(t, 1822578.8, wm.X, 0.1)
+	assert.InDelta(t, 6141587.8, wm.Y, 0.1)
+
+	assert.Equal(t, gk, wm.To(CRSMGIAustriaGKEast).To(CRSMGIAustriaGKEast))
+	assert.InDelta(t, gk.X, wm.To(CRSMGIAustriaGKEast).X, 0.01)
+}
+
+/*
+TestTransverseMercator checks the projection against the ellipsoidal worked example of Snyder,
+Map Projections - A Working Manual (USGS Professional Paper 1395, 1987), p. 269:
+40°30' N, 73°30' W on the Clarke 1866 ellipsoid with central meridian 75° W and scale factor 0.9996
+gives x = 127106.5 m, y = 4484124.4 m.
+*/
+func TestTransverseMercator(t *testing.T) {
+	const k0 = 0.9996
+	clarke1866 := ellipsoid{a: 6378206.4, f: 1 - math.Sqrt(1-0.00676866)}
+
+	x, y := transverseMercatorForward(-73.5, 40.5, -75, clarke1866)
+	assert.InDelta(t, 127106.5, k0*x, 0.05)
+	assert.InDelta(t, 4484124.4, k0*y, 0.05)
+
+	lon, lat := transverseMercatorInverse(127106.5/k0, 4484124.4/k0, -75, clarke1866)
+	assert.InDelta(t, -73.5, lon, 1e-6)
+	assert.InDelta(t, 40.5, lat, 1e-6)
+}
+
+/*
+TestHelmert checks the position vector transformation against the WGS 72 to WGS 84 example of
+IOGP Publication 373-7-2, Geomatics Guidance Note 7 part 2 (EPSG method 9606):
+tZ = 4.5 m, rZ = 0.554", dS = 0.219 ppm transform (3657660.66, 255768.55, 5201382.11)
+to (3657660.78, 255778.43, 5201387.75).
+*/
+func TestHelmert(t *testing.T) {
+	h := helmert{tz: 4.5, rz: 0.554, s: 0.219}
+	x, y, z := h.apply(3657660.66, 255768.55, 5201382.11)
+	assert.InDelta(t, 3657660.78, x, 0.01)
+	assert.InDelta(t, 255778.43, y, 0.01)
+	assert.InDelta(t, 5201387.75, z, 0.01)
+
+	x, y, z = h.invert(x, y, z)
+	assert.InDelta(t, 3657660.66, x, 0.01)
+	assert.InDelta(t, 255768.55, y, 0.01)
+	assert.InDelta(t, 5201382.11, z, 0.01)
+}
+
+func TestParseCRS(t *testing.T) {
+	for s, crs := range map[string]CRS{
+		"EPSG:4326":                   CRSWGS84,
+		"urn:ogc:def:crs:EPSG::31256": CRSMGIAustriaGKEast,
+		"http://www.opengis.net/def/crs/EPSG/0/3857": CRSWebMercator,
+		"900913": CRSWebMercator,
+	} {
+		got, err := ParseCRS(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, crs, got, s)
+	}
+	_, err := ParseCRS("EPSG:31287")
+	assert.Error(t, err)
+}
+
+func TestManhattanDistance(t *testing.T) {
+	a := Coordinates{X: 16.37, Y: 48.20}
+	b := Coordinates{X: 16.37, Y: 48.21}
+	assert.InDelta(t, a.HaversineDistance(b), a.ManhattanDistance(b), 5)
+}
@@ -12,9 +12,14 @@ func (d *District) PostCode() int {
 	return 1000 + d.Number*10
 }
 
+/*
+DistrictFromPostCode infers the district from the postcode 1XX0. This is a guess: postcodes do not
+follow district borders exactly, and some (like 1300 for the airport) belong to no district at all.
+Prefer DistrictBoundaries.DistrictFromCoordinates if the location is known.
+*/
 func DistrictFromPostCode(postcode int) (*District, error) {
 	d, ok := districts[(postcode-1000)/10]
-	if !ok {
+	if !ok || d.Number == 0 {
 		return nil, fmt.Errorf("no district for postcode %d", postcode)
 	}
 	return &d, nil
@@ -157,3 +162,52 @@ func DistrictByNumber(number int) (*District, error) {
 	}, nil
 
 }
+
+// districtBoundary is the area of a district together with its bounding box for quick rejection.
+type districtBoundary struct {
+	area   MultiPolygon
+	sw, ne Coordinates
+}
+
+// DistrictBoundaries are the areas of the districts, used to resolve the district of coordinates.
+type DistrictBoundaries struct {
+	boundaries map[int]districtBoundary
+}
+
+// NewDistrictBoundaries returns the DistrictBoundaries for the areas by district number, as returned by wienclient.ParseDistrictBoundaries.
+func NewDistrictBoundaries(areas map[int]MultiPolygon) *DistrictBoundaries {
+	b := &DistrictBoundaries{boundaries: make(map[int]districtBoundary, len(areas))}
+	for number, area := range areas {
+		sw, ne := area.BoundingBox()
+		b.boundaries[number] = districtBoundary{area: area, sw: sw, ne: ne}
+	}
+	return b
+}
+
+// Boundary returns the area of the district with the given number.
+func (b *DistrictBoundaries) Boundary(number int) (MultiPolygon, bool) {
+	if b == nil {
+		return nil, false
+	}
+	db, ok := b.boundaries[number]
+	return db.area, ok
+}
+
+/*
+DistrictFromCoordinates returns the district whose boundary contains the coordinates.
+An error is returned if there are no boundaries or the coordinates lie outside Vienna.
+*/
+func (b *DistrictBoundaries) DistrictFromCoordinates(c Coordinates) (*District, error) {
+	if b == nil || len(b.boundaries) == 0 {
+		return nil, fmt.Errorf("no district boundaries loaded")
+	}
+	for number, db := range b.boundaries {
+		if c.X < db.sw.X || c.X > db.ne.X || c.Y < db.sw.Y || c.Y > db.ne.Y {
+			continue
+		}
+		if db.area.Contains(c) {
+			return DistrictByNumber(number)
+		}
+	}
+	return nil, fmt.Errorf("no district at %f, %f", c.X, c.Y)
+}
@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const MinDistrictScore = 0.3 // districts matching a query worse than this are not returned by ResolveDistricts
+
+// DistrictMatch is a district found by ResolveDistricts together with how well it matched, from 0 to 1.
+type DistrictMatch struct {
+	District  *District
+	Score     float64
+	MatchedOn string // the name, insider name, number, numeral or postcode that matched
+}
+
+var (
+	romanNumerals = []string{"", "I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X",
+		"XI", "XII", "XIII", "XIV", "XV", "XVI", "XVII", "XVIII", "XIX", "XX", "XXI", "XXII", "XXIII"}
+
+	// "15", "15.", "15. Bezirk", "XV.", "1150", "1150 Wien"
+	districtNumberPattern = regexp.MustCompile(`^(\d{1,2}|[IVXivx]{1,5})\.?(\s*(bezirk|bez\.?))?$`)
+	postCodePattern       = regexp.MustCompile(`^(1\d\d0)(\s+wien)?$`)
+)
+
+// RomanNumeral returns the district number as Roman numeral, as used on street signs ("XV").
+func (d *District) RomanNumeral() string {
+	if d.Number < 1 || d.Number >= len(romanNumerals) {
+		return ""
+	}
+	return romanNumerals[d.Number]
+}
+
+/*
+ResolveDistricts interprets free text as district and returns the matching districts, best first.
+
+A district number ("15", "15."), Roman numeral ("XV") or postcode ("1150") matches exactly.
+Otherwise the text is compared with the names and insider names of the districts using FuzzyScore,
+so diacritics are ignored ("Wahring") and typos tolerated ("Leopoldstat"). Ties are broken by district
+number. Text containing digits only matches exactly. The pseudo-district 0 "Ganz Wien" is never returned. At most limit matches are returned, all of them if limit is not positive.
+*/
+func ResolveDistricts(query string, limit int) []DistrictMatch {
+	query = strings.TrimSpace(query)
+	best := make(map[int]DistrictMatch)
+	consider := func(number int, score float64, matchedOn string) {
+		if m, ok := best[number]; !ok || score > m.Score {
+			best[number] = DistrictMatch{Score: score, MatchedOn: matchedOn}
+		}
+	}
+
+	lower := strings.ToLower(query)
+	if m := districtNumberPattern.FindStringSubmatch(lower); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			if n >= 1 && n <= 23 {
+				consider(n, 1, m[1])
+			}
+		} else if n := slices.Index(romanNumerals, strings.ToUpper(m[1])); n > 0 {
+			consider(n, 1, romanNumerals[n])
+		}
+	}
+	if m := postCodePattern.FindStringSubmatch(lower); m != nil {
+		postcode, _ := strconv.Atoi(m[1])
+		if d, err := DistrictFromPostCode(postcode); err == nil {
+			consider(d.Number, 1, m[1])
+		}
+	}
+
+	// no district name contains digits, and a single digit would be within the typo tolerance of any one-letter word
+	if strings.ContainsAny(query, "0123456789") {
+		return sortDistrictMatches(best, limit)
+	}
+	for number, d := range districts {
+		if number == 0 {
+			continue
+		}
+		for _, name := range append([]string{d.Name}, d.InsiderNames...) {
+			if score := FuzzyScore(query, name); score >= MinDistrictScore {
+				consider(number, score, name)
+			}
+		}
+	}
+
+	return sortDistrictMatches(best, limit)
+}
+
+// sortDistrictMatches returns the matches by district number best first, at most limit of them if limit is positive.
+func sortDistrictMatches(best map[int]DistrictMatch, limit int) []DistrictMatch {
+	matches := make([]DistrictMatch, 0, len(best))
+	for number, m := range best {
+		m.District, _ = DistrictByNumber(number)
+		matches = append(matches, m)
+	}
+	slices.SortFunc(matches, func(a, b DistrictMatch) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.District.Number, b.District.Number)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// ResolveDistrict returns the best match of ResolveDistricts, or nil if nothing matches.
+func ResolveDistrict(query string) *District {
+	matches := ResolveDistricts(query, 1)
+	if len(matches) == 0 {
+		return nil
+	}
+	return matches[0].District
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveDistricts(t *testing.T) {
+	for query, number := range map[string]int{
+		"Leo":                  2,
+		"Mahü":                 6,
+		"mahuf":                6,
+		"X":                    10,
+		"15.":                  15,
+		"15. Bezirk":           15,
+		"XV":                   15,
+		"xxiii":                23,
+		"1150":                 15,
+		"1190 Wien":            19,
+		"Rudolfsheim":          15,
+		"Wahring":              18,
+		"Leopoldstat":          2,
+		"floridsdorf":          21,
+		"  Innere Stadt  ":     1,
+		"Rudolfsheim-Fünfhaus": 15,
+		"Bobostan":             7,
+		"Favoritn":             10,
+		"Donau City":           22,
+	} {
+		d := ResolveDistrict(query)
+		if assert.NotNil(t, d, query) {
+			assert.Equal(t, number, d.Number, query)
+		}
+	}
+
+	assert.Nil(t, ResolveDistrict("Salzburg"))
+	assert.Nil(t, ResolveDistrict("24"))
+	assert.Nil(t, ResolveDistrict(""))
+	// the pseudo-district 0 is not a district
+	for _, query := range []string{"Ganz Wien", "Iwaroi, Gåunze Stådt"} {
+		for _, m := range ResolveDistricts(query, 0) {
+			assert.NotEqual(t, 0, m.District.Number, query)
+		}
+	}
+	assert.Nil(t, ResolveDistrict("0"))
+	assert.Nil(t, ResolveDistrict("1000"))
+
+	matches := ResolveDistricts("15", 0)
+	if assert.NotEmpty(t, matches) {
+		assert.Equal(t, 1.0, matches[0].Score)
+		assert.Equal(t, "15", matches[0].MatchedOn)
+	}
+	assert.Equal(t, "XV", matches[0].District.RomanNumeral())
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistrictFromCoordinates(t *testing.T) {
+	var none *DistrictBoundaries
+	_, err := none.DistrictFromCoordinates(Coordinates{X: 16.37, Y: 48.21})
+	assert.Error(t, err, "no boundaries loaded")
+
+	// simplified shapes, not the real district borders
+	b := NewDistrictBoundaries(map[int]MultiPolygon{
+		1: {{{X: 16.36, Y: 48.20}, {X: 16.38, Y: 48.20}, {X: 16.38, Y: 48.22}, {X: 16.36, Y: 48.22}}},
+		2: {{{X: 16.38, Y: 48.20}, {X: 16.42, Y: 48.20}, {X: 16.42, Y: 48.24}, {X: 16.38, Y: 48.24}}},
+	})
+	_, ok := b.Boundary(1)
+	assert.True(t, ok)
+	_, ok = b.Boundary(3)
+	assert.False(t, ok)
+
+	d, err := b.DistrictFromCoordinates(Coordinates{X: 16.37, Y: 48.21})
+	assert.NoError(t, err)
+	assert.Equal(t, "Innere Stadt", d.Name)
+	d, err = b.DistrictFromCoordinates(Coordinates{X: 16.40, Y: 48.23})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, d.Number)
+	_, err = b.DistrictFromCoordinates(Coordinates{X: 16.57, Y: 48.12})
+	assert.Error(t, err)
+
+	_, err = DistrictFromPostCode(1300)
+	assert.Error(t, err)
+	_, err = DistrictFromPostCode(1000)
+	assert.Error(t, err, "Ganz Wien is no district")
+	d, err = DistrictFromPostCode(1160)
+	assert.NoError(t, err)
+	assert.Equal(t, 16, d.Number)
+}
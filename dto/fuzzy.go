@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"strings"
+	"unicode"
+)
+
+// diacritics maps characters with diacritics to their plain replacement, so that "Wahring" finds "Währing".
+var diacritics = map[rune]string{
+	'ä': "a", 'ö': "o", 'ü': "u", 'ß': "ss",
+	'á': "a", 'à': "a", 'â': "a", 'å': "a", 'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i", 'ó': "o", 'ò': "o", 'ô': "o",
+	'ú': "u", 'ù': "u", 'û': "u", 'ç': "c", 'č': "c", 'š': "s", 'ž': "z", 'ñ': "n",
+}
+
+/*
+NormalizeName prepares a name for fuzzy comparison: it is lowercased, diacritics are removed,
+and everything except letters and digits is collapsed into single spaces.
+*/
+func NormalizeName(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if rep, ok := diacritics[r]; ok {
+			b.WriteString(rep)
+			space = false
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space && b.Len() > 0 {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// EditDistance returns the Levenshtein distance between a and b, counted in runes.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+/*
+FuzzyScore rates how well query matches candidate, from 1 for an exact match down to 0 for no match.
+Both are normalized with NormalizeName first. Prefix and substring matches score high, and typos are
+tolerated with an edit distance of about one per four characters, compared against the whole
+candidate as well as against each of its words.
+*/
+func FuzzyScore(query, candidate string) float64 {
+	q, c := NormalizeName(query), NormalizeName(candidate)
+	if q == "" || c == "" {
+		return 0
+	}
+	switch {
+	case q == c:
+		return 1
+	case strings.HasPrefix(c, q):
+		return 0.9
+	case strings.Contains(c, q):
+		return 0.8
+	}
+
+	tolerance := max(1, len([]rune(q))/4)
+	best := 0.0
+	for _, target := range append([]string{c}, strings.Fields(c)...) {
+		d := EditDistance(q, target)
+		if d <= tolerance {
+			best = max(best, 0.7*(1-float64(d)/float64(tolerance+1)))
+		}
+		// typo within the beginning of a longer word
+		if len([]rune(target)) > len([]rune(q)) {
+			if d := EditDistance(q, string([]rune(target)[:len([]rune(q))])); d <= tolerance {
+				best = max(best, 0.6*(1-float64(d)/float64(tolerance+1)))
+			}
+		}
+	}
+	return best
+}
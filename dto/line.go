@@ -1,5 +1,6 @@
 package dto
 
+// Line is a transit line. Lines are used as map keys; their routes are kept apart, see LineRoutes.
 type Line struct {
 	Name string
 	Type LineType
@@ -16,10 +17,11 @@ const (
 	LineTypeGroupTaxi
 	LineTypeNightBus
 	LineTypeNightGroupTaxi
+	LineTypeOther // transit mode not known to this version, see wlclient.ParseLines
 )
 
 func (lt LineType) String() string {
-	return []string{"U-Bahn", "S-Bahn", "Badner Bahn", "Tram", "Bus", "Group Taxi", "Night Bus", "Night Group Taxi"}[lt]
+	return []string{"U-Bahn", "S-Bahn", "Badner Bahn", "Tram", "Bus", "Group Taxi", "Night Bus", "Night Group Taxi", "Other"}[lt]
 }
 
 func (l *Line) String() string {
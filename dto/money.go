@@ -0,0 +1,117 @@
+package dto
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const CurrencyEUR Currency = "EUR"
+
+// Money is an amount in the minor unit (cents) of its currency. The zero value means no amount.
+type Money struct {
+	Cents    int64
+	Currency Currency
+}
+
+// EUR returns the amount of euros as Money, rounded to cents.
+func EUR(amount float64) Money {
+	return Money{Cents: int64(math.Round(amount * 100)), Currency: CurrencyEUR}
+}
+
+// IsZero reports whether there is no amount.
+func (m Money) IsZero() bool {
+	return m.Cents == 0
+}
+
+// Float returns the amount in the major unit, e.g. euros.
+func (m Money) Float() float64 {
+	return float64(m.Cents) / 100
+}
+
+/*
+Add returns the sum of both amounts. A zero amount takes the currency of the other one;
+an error is returned if two non-zero amounts are in different currencies.
+*/
+func (m Money) Add(other Money) (Money, error) {
+	switch {
+	case other.IsZero():
+		return m, nil
+	case m.IsZero():
+		return other, nil
+	case m.Currency != other.Currency:
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.Currency, m.Currency)
+	}
+	return Money{Cents: m.Cents + other.Cents, Currency: m.Currency}, nil
+}
+
+// String formats the amount the Austrian way, like "€ 1.234,50".
+func (m Money) String() string {
+	cents := m.Cents
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	units := fmt.Sprint(cents / 100)
+	var grouped strings.Builder
+	for i, r := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			grouped.WriteRune('.')
+		}
+		grouped.WriteRune(r)
+	}
+	symbol := string(m.Currency)
+	if m.Currency == CurrencyEUR || m.Currency == "" {
+		symbol = "€"
+	}
+	return fmt.Sprintf("%s%s %s,%02d", sign, symbol, grouped.String(), cents%100)
+}
+
+/*
+PriceBreakdown holds the costs of renting an apartment as far as the source tells them.
+The monthly amounts add up to the gross rent; sources often state only that total.
+Parking is listed separately, as it is usually optional and not part of the rent.
+*/
+type PriceBreakdown struct {
+	NetRent        Money // Hauptmietzins
+	OperatingCosts Money // Betriebskosten
+	VAT            Money
+	Heating        Money
+	Parking        Money
+	TotalMonthly   Money // gross rent as stated by the source, zero if it only gives the components
+
+	Deposit    Money // Kaution, refundable
+	Commission Money // Provision
+	OneOffFees Money // other one-off fees, e.g. contract fees
+}
+
+/*
+Monthly returns the total monthly cost: the stated total if there is one, otherwise the sum of
+net rent, operating costs, VAT and heating. Parking is not included. An error is returned if the
+components are in different currencies.
+*/
+func (pb PriceBreakdown) Monthly() (Money, error) {
+	if !pb.TotalMonthly.IsZero() {
+		return pb.TotalMonthly, nil
+	}
+	return sumMoney(pb.NetRent, pb.OperatingCosts, pb.VAT, pb.Heating)
+}
+
+// OneOff returns the non-refundable costs due when moving in: the commission and other one-off fees.
+func (pb PriceBreakdown) OneOff() (Money, error) {
+	return sumMoney(pb.Commission, pb.OneOffFees)
+}
+
+func sumMoney(amounts ...Money) (Money, error) {
+	var sum Money
+	for _, m := range amounts {
+		var err error
+		if sum, err = sum.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return sum, nil
+}
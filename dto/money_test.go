@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney(t *testing.T) {
+	assert.Equal(t, int64(123456), EUR(1234.555).Cents)
+	assert.Equal(t, "€ 1.234,56", EUR(1234.555).String())
+	assert.Equal(t, "€ 0,05", EUR(0.05).String())
+	assert.Equal(t, "-€ 1.000.000,00", EUR(-1e6).String())
+	assert.Equal(t, "CHF 12,00", Money{Cents: 1200, Currency: "CHF"}.String())
+
+	_, err := EUR(1).Add(Money{Cents: 100, Currency: "CHF"})
+	assert.Error(t, err)
+	sum, err := Money{}.Add(EUR(2))
+	assert.NoError(t, err)
+	assert.Equal(t, EUR(2), sum)
+}
+
+func TestPriceBreakdown(t *testing.T) {
+	pb := PriceBreakdown{
+		NetRent:        EUR(650),
+		OperatingCosts: EUR(180.5),
+		VAT:            EUR(83.05),
+		Parking:        EUR(90),
+		Deposit:        EUR(2700),
+		Commission:     EUR(1800),
+		OneOffFees:     EUR(120),
+	}
+	monthly, err := pb.Monthly()
+	assert.NoError(t, err)
+	assert.Equal(t, EUR(913.55), monthly)
+	oneOff, err := pb.OneOff()
+	assert.NoError(t, err)
+	assert.Equal(t, EUR(1920), oneOff)
+
+	pb.TotalMonthly = EUR(920)
+	monthly, _ = pb.Monthly()
+	assert.Equal(t, EUR(920), monthly, "stated total wins")
+
+	monthly, err = PriceBreakdown{}.Monthly()
+	assert.NoError(t, err)
+	assert.True(t, monthly.IsZero())
+}
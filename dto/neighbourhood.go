@@ -0,0 +1,151 @@
+package dto
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+const MinNeighbourhoodNameScore = 0.3 // neighbourhoods matching a name query worse than this are not returned
+
+/*
+Neighbourhood is a Grätzl, the part of a district people actually choose a flat by.
+Unlike districts, Grätzl have no official borders, so the neighbourhoods only carry names;
+their areas are supplied separately as NeighbourhoodAreas, e.g. the approximate ones of
+wienclient.EmbeddedNeighbourhoodAreas.
+*/
+type Neighbourhood struct {
+	Name         string
+	Aliases      []string // other common names
+	InsiderNames []string
+	District     int // number of the district the neighbourhood (mostly) lies in
+}
+
+// NeighbourhoodMatch is a neighbourhood found by name together with how well it matched, from 0 to 1.
+type NeighbourhoodMatch struct {
+	Neighbourhood *Neighbourhood
+	Score         float64
+}
+
+var neighbourhoods = []Neighbourhood{
+	{Name: "Brunnenviertel", Aliases: []string{"Yppenviertel"}, InsiderNames: []string{"Brunnenmarkt", "Yppenplatz"}, District: 16},
+	{Name: "Karmeliterviertel", Aliases: []string{}, InsiderNames: []string{"Karmeliter", "Karmelitermarkt"}, District: 2},
+	{Name: "Nordbahnviertel", Aliases: []string{}, InsiderNames: []string{"Nordbahnhof", "Bednarpark"}, District: 2},
+	{Name: "Volkertviertel", Aliases: []string{"Alliiertenviertel"}, InsiderNames: []string{"Volkertmarkt"}, District: 2},
+	{Name: "Stuwerviertel", Aliases: []string{}, InsiderNames: []string{"Vorgartenmarkt"}, District: 2},
+	{Name: "Seestadt", Aliases: []string{"Seestadt Aspern", "aspern Seestadt"}, InsiderNames: []string{}, District: 22},
+	{Name: "Servitenviertel", Aliases: []string{}, InsiderNames: []string{"Klein-Paris"}, District: 9},
+	{Name: "Spittelberg", Aliases: []string{}, InsiderNames: []string{}, District: 7},
+	{Name: "Freihausviertel", Aliases: []string{}, InsiderNames: []string{"Freihaus"}, District: 4},
+	{Name: "Sonnwendviertel", Aliases: []string{}, InsiderNames: []string{"Hauptbahnhof-Viertel"}, District: 10},
+	{Name: "Kretaviertel", Aliases: []string{}, InsiderNames: []string{"Kreta"}, District: 10},
+	{Name: "Cottageviertel", Aliases: []string{"Cottage"}, InsiderNames: []string{}, District: 18},
+}
+
+// neighbourhoodArea is the area of a neighbourhood together with its bounding box for quick rejection.
+type neighbourhoodArea struct {
+	neighbourhood int // index into neighbourhoods
+	area          MultiPolygon
+	sw, ne        Coordinates
+}
+
+// NeighbourhoodAreas are the areas of the neighbourhoods, used to resolve the neighbourhood of coordinates.
+type NeighbourhoodAreas struct {
+	areas []neighbourhoodArea // in the order of neighbourhoods
+}
+
+/*
+NewNeighbourhoodAreas returns the NeighbourhoodAreas for the areas by neighbourhood name (compared with
+NormalizeName), as returned by wienclient.ParseNeighbourhoodAreas. Names not matching a known neighbourhood
+are ignored and returned.
+*/
+func NewNeighbourhoodAreas(areas map[string]MultiPolygon) (*NeighbourhoodAreas, []string) {
+	na := &NeighbourhoodAreas{}
+	unknown := make([]string, 0)
+	for name, area := range areas {
+		i := slices.IndexFunc(neighbourhoods, func(n Neighbourhood) bool {
+			return NormalizeName(n.Name) == NormalizeName(name)
+		})
+		if i < 0 {
+			unknown = append(unknown, name)
+			continue
+		}
+		sw, ne := area.BoundingBox()
+		na.areas = append(na.areas, neighbourhoodArea{neighbourhood: i, area: area, sw: sw, ne: ne})
+	}
+	slices.SortFunc(na.areas, func(a, b neighbourhoodArea) int {
+		return cmp.Compare(a.neighbourhood, b.neighbourhood)
+	})
+	slices.Sort(unknown)
+	return na, unknown
+}
+
+/*
+NeighbourhoodFromCoordinates returns the neighbourhood whose area contains the coordinates.
+If areas overlap, the neighbourhood coming first in Neighbourhoods wins. An error is returned
+if there are no areas or the coordinates lie in none of them.
+*/
+func (na *NeighbourhoodAreas) NeighbourhoodFromCoordinates(c Coordinates) (*Neighbourhood, error) {
+	if na == nil || len(na.areas) == 0 {
+		return nil, fmt.Errorf("no neighbourhood areas loaded")
+	}
+	for _, a := range na.areas {
+		if c.X < a.sw.X || c.X > a.ne.X || c.Y < a.sw.Y || c.Y > a.ne.Y {
+			continue
+		}
+		if a.area.Contains(c) {
+			n := neighbourhoods[a.neighbourhood]
+			return &n, nil
+		}
+	}
+	return nil, fmt.Errorf("no neighbourhood at %f, %f", c.X, c.Y)
+}
+
+// Neighbourhoods returns all known neighbourhoods.
+func Neighbourhoods() []Neighbourhood {
+	return slices.Clone(neighbourhoods)
+}
+
+// NeighbourhoodByName returns the neighbourhood whose name or one of its aliases or insider names equals name, ignoring case and diacritics.
+func NeighbourhoodByName(name string) (*Neighbourhood, error) {
+	normalized := NormalizeName(name)
+	for _, n := range neighbourhoods {
+		for _, candidate := range n.names() {
+			if NormalizeName(candidate) == normalized {
+				return &n, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no neighbourhood named %s", name)
+}
+
+/*
+FindNeighbourhoods returns the neighbourhoods whose name, aliases or insider names match the query,
+best matches first. Matching is fuzzy (see FuzzyScore). At most limit matches are returned,
+all of them if limit is not positive.
+*/
+func FindNeighbourhoods(query string, limit int) []NeighbourhoodMatch {
+	matches := make([]NeighbourhoodMatch, 0)
+	for _, n := range neighbourhoods {
+		best := 0.0
+		for _, candidate := range n.names() {
+			best = max(best, FuzzyScore(query, candidate))
+		}
+		if best >= MinNeighbourhoodNameScore {
+			matches = append(matches, NeighbourhoodMatch{Neighbourhood: &n, Score: best})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b NeighbourhoodMatch) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// names returns the name, aliases and insider names of the neighbourhood.
+func (n Neighbourhood) names() []string {
+	names := append([]string{n.Name}, n.Aliases...)
+	return append(names, n.InsiderNames...)
+}
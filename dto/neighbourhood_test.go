@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// square returns a square area with the given south west corner and side in degrees.
+func square(sw Coordinates, side float64) MultiPolygon {
+	return MultiPolygon{{sw, {X: sw.X + side, Y: sw.Y}, {X: sw.X + side, Y: sw.Y + side}, {X: sw.X, Y: sw.Y + side}}}
+}
+
+func TestNeighbourhoodAreas(t *testing.T) {
+	var none *NeighbourhoodAreas
+	_, err := none.NeighbourhoodFromCoordinates(Coordinates{X: 16.3372, Y: 48.2108})
+	assert.Error(t, err, "no areas loaded")
+
+	// simplified shapes, not drawn borders; the Volkertviertel overlaps the Karmeliterviertel
+	areas, unknown := NewNeighbourhoodAreas(map[string]MultiPolygon{
+		"brunnenviertel":    square(Coordinates{X: 16.33, Y: 48.205}, 0.01),
+		"Volkertviertel":    square(Coordinates{X: 16.375, Y: 48.21}, 0.02),
+		"Karmeliterviertel": square(Coordinates{X: 16.37, Y: 48.21}, 0.01),
+		"Atlantis":          square(Coordinates{X: 0, Y: 0}, 1),
+	})
+	assert.Equal(t, []string{"Atlantis"}, unknown)
+
+	n, err := areas.NeighbourhoodFromCoordinates(Coordinates{X: 16.3372, Y: 48.2108}) // Brunnenmarkt
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Brunnenviertel", n.Name)
+		assert.Equal(t, 16, n.District)
+	}
+	n, err = areas.NeighbourhoodFromCoordinates(Coordinates{X: 16.3787, Y: 48.2168})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Karmeliterviertel", n.Name, "the first in the order of Neighbourhoods wins")
+	}
+	_, err = areas.NeighbourhoodFromCoordinates(Coordinates{X: 16.25, Y: 48.13})
+	assert.Error(t, err)
+}
+
+func TestFindNeighbourhoods(t *testing.T) {
+	n, err := NeighbourhoodByName("yppenplatz")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Brunnenviertel", n.Name)
+	}
+	_, err = NeighbourhoodByName("Atlantis")
+	assert.Error(t, err)
+
+	matches := FindNeighbourhoods("Karmelitter", 1)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, "Karmeliterviertel", matches[0].Neighbourhood.Name)
+	}
+	matches = FindNeighbourhoods("aspern", 0)
+	if assert.NotEmpty(t, matches) {
+		assert.Equal(t, "Seestadt", matches[0].Neighbourhood.Name)
+	}
+	assert.Empty(t, FindNeighbourhoods("Schönbrunn", 0))
+}
@@ -0,0 +1,81 @@
+package dto
+
+import "math"
+
+// Polygon is a simple polygon given by its outer ring. The ring is closed implicitly, the first point need not be repeated.
+type Polygon []Coordinates
+
+/*
+Contains reports whether the coordinates lie inside the polygon.
+
+It uses the even-odd rule (ray casting) on the plain degree values, which is accurate
+enough for polygons of city scale. Points exactly on the border may be reported either way.
+*/
+func (p Polygon) Contains(c Coordinates) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Y > c.Y) != (b.Y > c.Y) && c.X < (b.X-a.X)*(c.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// BoundingBox returns the south-west and north-east corners of the smallest box containing the polygon.
+func (p Polygon) BoundingBox() (Coordinates, Coordinates) {
+	if len(p) == 0 {
+		return Coordinates{}, Coordinates{}
+	}
+	sw, ne := p[0], p[0]
+	for _, c := range p[1:] {
+		sw.X, sw.Y = math.Min(sw.X, c.X), math.Min(sw.Y, c.Y)
+		ne.X, ne.Y = math.Max(ne.X, c.X), math.Max(ne.Y, c.Y)
+	}
+	return sw, ne
+}
+
+/*
+Circle approximates a circle with the given radius in meters around center by a regular polygon with the given number of segments.
+The degree offsets are scaled by the latitude of the center, so the result is only accurate for small radii.
+*/
+func Circle(center Coordinates, radius float64, segments int) Polygon {
+	p := make(Polygon, segments)
+	dy := radius / (EarthMagicNumber * 1000)
+	dx := dy / math.Cos(center.Y*math.Pi/180)
+	for i := range p {
+		angle := 2 * math.Pi * float64(i) / float64(segments)
+		p[i] = Coordinates{
+			X: center.X + dx*math.Cos(angle),
+			Y: center.Y + dy*math.Sin(angle),
+		}
+	}
+	return p
+}
+
+/*
+MultiPolygon is an area made of several rings, like a district consisting of separate parts.
+Holes are given as further rings: as Contains uses the even-odd rule over all rings,
+it does not matter which outer ring a hole belongs to.
+*/
+type MultiPolygon []Polygon
+
+// Contains reports whether the coordinates lie inside an odd number of the rings.
+func (mp MultiPolygon) Contains(c Coordinates) bool {
+	inside := false
+	for _, p := range mp {
+		if p.Contains(c) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// BoundingBox returns the south-west and north-east corners of the smallest box containing all rings.
+func (mp MultiPolygon) BoundingBox() (Coordinates, Coordinates) {
+	var all Polygon
+	for _, p := range mp {
+		all = append(all, p...)
+	}
+	return all.BoundingBox()
+}
@@ -0,0 +1,186 @@
+package dto
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	DirectionForward Direction = iota // in the order of the line's shape
+	DirectionBackward
+)
+
+// Direction distinguishes the two directions a line is operated in.
+type Direction int
+
+/*
+Route is the course of a line in one direction: its stops in travel order and the polyline it follows.
+Offsets holds, for each stop, its distance in meters along the shape from the start of the route.
+*/
+type Route struct {
+	Direction Direction
+	Stops     []*Stop
+	Offsets   []float64
+	Shape     []Coordinates
+}
+
+// Length returns the length of the route's shape in meters.
+func (r *Route) Length() float64 {
+	length := 0.0
+	for i := 1; i < len(r.Shape); i++ {
+		length += r.Shape[i-1].HaversineDistance(r.Shape[i])
+	}
+	return length
+}
+
+// indexOf returns the position of the stop in the route, or -1.
+func (r *Route) indexOf(s *Stop) int {
+	return slices.Index(r.Stops, s)
+}
+
+/*
+StopsBetween returns the stops from a to b, both included, in travel order.
+The second return value is false if one of the stops is not on the route or b comes before a in this direction.
+*/
+func (r *Route) StopsBetween(a, b *Stop) ([]*Stop, bool) {
+	i, j := r.indexOf(a), r.indexOf(b)
+	if i < 0 || j < 0 || j < i {
+		return nil, false
+	}
+	return r.Stops[i : j+1], true
+}
+
+/*
+DistanceAlong returns the distance in meters along the route from stop a to stop b.
+The second return value is false if one of the stops is not on the route or b comes before a in this direction.
+*/
+func (r *Route) DistanceAlong(a, b *Stop) (float64, bool) {
+	i, j := r.indexOf(a), r.indexOf(b)
+	if i < 0 || j < 0 || j < i {
+		return 0, false
+	}
+	return r.Offsets[j] - r.Offsets[i], true
+}
+
+/*
+NewRoutes builds the routes of both directions of a line from the shapes of the directions and its stops.
+
+Every stop is projected onto the shape of each direction to find its offset along it, and the stops
+are ordered by that offset. If backward is empty, the backward route uses the reversed forward shape.
+Stops are not required to lie on the shapes, they are ordered by the point of a shape nearest to them.
+*/
+func NewRoutes(forward, backward []Coordinates, stops []*Stop) LineRoutes {
+	if len(forward) < 2 {
+		return nil
+	}
+	if len(backward) < 2 {
+		backward = slices.Clone(forward)
+		slices.Reverse(backward)
+	}
+	return LineRoutes{newRoute(DirectionForward, forward, stops), newRoute(DirectionBackward, backward, stops)}
+}
+
+// newRoute orders the stops along the shape; stops given more than once are used once.
+func newRoute(d Direction, shape []Coordinates, stops []*Stop) Route {
+	r := Route{Direction: d, Shape: shape}
+	offsets := make(map[*Stop]float64)
+	for _, s := range stops {
+		if _, ok := offsets[s]; !ok {
+			offsets[s], _ = ProjectOnPolyline(shape, s.Location)
+			r.Stops = append(r.Stops, s)
+		}
+	}
+	slices.SortStableFunc(r.Stops, func(a, b *Stop) int {
+		return cmp.Compare(offsets[a], offsets[b])
+	})
+	r.Offsets = make([]float64, len(r.Stops))
+	for i, s := range r.Stops {
+		r.Offsets[i] = offsets[s]
+	}
+	return r
+}
+
+/*
+ProjectOnPolyline returns the offset in meters along the polyline of the point nearest to c,
+and the distance in meters between c and that point. It works in a local equirectangular
+projection around c, which is accurate enough at city scale.
+*/
+func ProjectOnPolyline(polyline []Coordinates, c Coordinates) (float64, float64) {
+	metersPerDegree := EarthMagicNumber * 1000
+	scaleX := metersPerDegree * math.Cos(c.Y*math.Pi/180)
+	project := func(p Coordinates) (float64, float64) {
+		return (p.X - c.X) * scaleX, (p.Y - c.Y) * metersPerDegree
+	}
+
+	bestOffset, bestDistance := 0.0, math.Inf(1)
+	offset := 0.0
+	for i := 1; i < len(polyline); i++ {
+		ax, ay := project(polyline[i-1])
+		bx, by := project(polyline[i])
+		dx, dy := bx-ax, by-ay
+		segment := math.Hypot(dx, dy)
+		t := 0.0
+		if segment > 0 {
+			// c is the origin of the projection
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/(segment*segment)))
+		}
+		if d := math.Hypot(ax+t*dx, ay+t*dy); d < bestDistance {
+			bestOffset, bestDistance = offset+t*segment, d
+		}
+		offset += segment
+	}
+	return bestOffset, bestDistance
+}
+
+// LineRoutes are the routes of a line, one per direction. They are kept by line name, apart from the Line.
+type LineRoutes []Route
+
+/*
+Route returns the route in the given direction.
+The second return value is false if there is no route in that direction.
+*/
+func (lr LineRoutes) Route(d Direction) (*Route, bool) {
+	for i := range lr {
+		if lr[i].Direction == d {
+			return &lr[i], true
+		}
+	}
+	return nil, false
+}
+
+// StopByName returns the first stop of the routes with the given name, or nil.
+func (lr LineRoutes) StopByName(name string) *Stop {
+	for _, r := range lr {
+		for _, s := range r.Stops {
+			if s.Name == name {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+/*
+StopsBetween returns the stops from a to b, both included, in travel order,
+using whichever direction travels from a to b.
+*/
+func (lr LineRoutes) StopsBetween(a, b *Stop) ([]*Stop, error) {
+	for _, r := range lr {
+		if stops, ok := r.StopsBetween(a, b); ok {
+			return stops, nil
+		}
+	}
+	return nil, fmt.Errorf("no route from %s to %s", a.Name, b.Name)
+}
+
+// DistanceAlong returns the distance in meters along the routes from stop a to stop b, using whichever direction travels from a to b.
+func (lr LineRoutes) DistanceAlong(a, b *Stop) (float64, error) {
+	for _, r := range lr {
+		if d, ok := r.DistanceAlong(a, b); ok {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("no route from %s to %s", a.Name, b.Name)
+}
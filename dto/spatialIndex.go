@@ -0,0 +1,188 @@
+package dto
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+const DefaultSpatialIndexCellSize = 0.005 // degrees, roughly 550 m north-south and 370 m east-west in Vienna
+
+// Neighbour is an item found by a SpatialIndex query together with its distance in meters to the query point.
+type Neighbour[T any] struct {
+	Item     T
+	Location Coordinates
+	Distance float64
+}
+
+type spatialIndexCell struct{ x, y int }
+
+/*
+SpatialIndex is a grid index over items with a location, supporting radius and nearest-k queries.
+
+Items are bucketed into square cells of a fixed size in degrees. Queries only look at the cells
+that can contain results, so they cost roughly the number of items in the neighbourhood instead
+of the total number of items. Distances are Haversine distances in meters.
+A SpatialIndex is not safe for concurrent writes, but can be queried concurrently once filled.
+*/
+type SpatialIndex[T any] struct {
+	cellSize float64
+	cells    map[spatialIndexCell][]Neighbour[T]
+	min, max spatialIndexCell
+	size     int
+}
+
+// NewSpatialIndex returns an empty SpatialIndex with the given cell size in degrees. If cellSize is not positive, DefaultSpatialIndexCellSize is used.
+func NewSpatialIndex[T any](cellSize float64) *SpatialIndex[T] {
+	if cellSize <= 0 {
+		cellSize = DefaultSpatialIndexCellSize
+	}
+	return &SpatialIndex[T]{
+		cellSize: cellSize,
+		cells:    make(map[spatialIndexCell][]Neighbour[T]),
+	}
+}
+
+func (si *SpatialIndex[T]) cellOf(c Coordinates) spatialIndexCell {
+	return spatialIndexCell{int(math.Floor(c.X / si.cellSize)), int(math.Floor(c.Y / si.cellSize))}
+}
+
+// Insert adds an item at the given location.
+func (si *SpatialIndex[T]) Insert(location Coordinates, item T) {
+	cell := si.cellOf(location)
+	if si.size == 0 {
+		si.min, si.max = cell, cell
+	} else {
+		si.min = spatialIndexCell{min(si.min.x, cell.x), min(si.min.y, cell.y)}
+		si.max = spatialIndexCell{max(si.max.x, cell.x), max(si.max.y, cell.y)}
+	}
+	si.cells[cell] = append(si.cells[cell], Neighbour[T]{Item: item, Location: location})
+	si.size++
+}
+
+// Len returns the number of items in the index.
+func (si *SpatialIndex[T]) Len() int {
+	return si.size
+}
+
+/*
+Within returns all items within radius meters of center, ordered by distance.
+The cells looked at cover the bounding box of the circle on the sphere of HaversineDistance,
+so no item is missed because of a different length of a degree.
+*/
+func (si *SpatialIndex[T]) Within(center Coordinates, radius float64) []Neighbour[T] {
+	angle := radius / HaversineEarthRadius
+	dy := angle * 180 / math.Pi
+	// the widest part of the circle lies poleward of its center, its east-west extent is asin(sin r / cos φ)
+	dx := 180.0
+	if s := math.Sin(angle) / math.Cos(center.Y*math.Pi/180); angle < math.Pi/2 && s < 1 {
+		dx = math.Asin(s) * 180 / math.Pi
+	}
+	sw := si.cellOf(Coordinates{X: center.X - dx, Y: center.Y - dy})
+	ne := si.cellOf(Coordinates{X: center.X + dx, Y: center.Y + dy})
+
+	result := make([]Neighbour[T], 0)
+	for x := max(sw.x, si.min.x); x <= min(ne.x, si.max.x); x++ {
+		for y := max(sw.y, si.min.y); y <= min(ne.y, si.max.y); y++ {
+			for _, n := range si.cells[spatialIndexCell{x, y}] {
+				if n.Distance = center.HaversineDistance(n.Location); n.Distance <= radius {
+					result = append(result, n)
+				}
+			}
+		}
+	}
+	sortNeighbours(result)
+	return result
+}
+
+/*
+InBox returns all items inside the box given by its south-west and north-east corners.
+The distances of the returned neighbours are not set.
+*/
+func (si *SpatialIndex[T]) InBox(sw, ne Coordinates) []Neighbour[T] {
+	from, to := si.cellOf(sw), si.cellOf(ne)
+	result := make([]Neighbour[T], 0)
+	for x := max(from.x, si.min.x); x <= min(to.x, si.max.x); x++ {
+		for y := max(from.y, si.min.y); y <= min(to.y, si.max.y); y++ {
+			for _, n := range si.cells[spatialIndexCell{x, y}] {
+				if n.Location.X >= sw.X && n.Location.X <= ne.X && n.Location.Y >= sw.Y && n.Location.Y <= ne.Y {
+					result = append(result, n)
+				}
+			}
+		}
+	}
+	return result
+}
+
+/*
+Nearest returns the k items nearest to center, ordered by distance.
+Items farther away than maxDistance meters are ignored, a maxDistance of 0 means no limit.
+Fewer than k items are returned if the index does not contain enough items in range.
+*/
+func (si *SpatialIndex[T]) Nearest(center Coordinates, k int, maxDistance float64) []Neighbour[T] {
+	if k <= 0 || si.size == 0 {
+		return nil
+	}
+	if maxDistance <= 0 {
+		maxDistance = math.Inf(1)
+	}
+
+	c := si.cellOf(center)
+	maxRing := max(c.x-si.min.x, si.max.x-c.x, c.y-si.min.y, si.max.y-c.y)
+	// rings that do not touch any filled cell can be skipped
+	minRing := max(0, si.min.x-c.x, c.x-si.max.x, si.min.y-c.y, c.y-si.max.y)
+
+	found := make([]Neighbour[T], 0, k)
+	for ring := minRing; ring <= maxRing; ring++ {
+		// every item in this or outer rings is at least this far away, measured east-west at the latitude farthest from the equator
+		maxLatitude := min(90, math.Abs(center.Y)+float64(ring+1)*si.cellSize)
+		ringDistance := float64(ring-1) * si.cellSize * haversineMetersPerDegree * math.Cos(maxLatitude*math.Pi/180)
+		if ringDistance > maxDistance || (len(found) >= k && ringDistance > found[k-1].Distance) {
+			break
+		}
+		si.eachRingCell(c, ring, func(cell spatialIndexCell) {
+			for _, n := range si.cells[cell] {
+				if n.Distance = center.HaversineDistance(n.Location); n.Distance <= maxDistance {
+					found = append(found, n)
+				}
+			}
+		})
+		sortNeighbours(found)
+		if len(found) > k {
+			found = found[:k]
+		}
+	}
+	return found
+}
+
+// eachRingCell calls fn for every cell of the square ring with the given radius in cells around c that lies within the filled area of the index.
+func (si *SpatialIndex[T]) eachRingCell(c spatialIndexCell, ring int, fn func(spatialIndexCell)) {
+	if ring == 0 {
+		fn(c)
+		return
+	}
+	xFrom, xTo := max(c.x-ring, si.min.x), min(c.x+ring, si.max.x)
+	for _, y := range []int{c.y - ring, c.y + ring} {
+		if y < si.min.y || y > si.max.y {
+			continue
+		}
+		for x := xFrom; x <= xTo; x++ {
+			fn(spatialIndexCell{x, y})
+		}
+	}
+	yFrom, yTo := max(c.y-ring+1, si.min.y), min(c.y+ring-1, si.max.y)
+	for _, x := range []int{c.x - ring, c.x + ring} {
+		if x < si.min.x || x > si.max.x {
+			continue
+		}
+		for y := yFrom; y <= yTo; y++ {
+			fn(spatialIndexCell{x, y})
+		}
+	}
+}
+
+func sortNeighbours[T any](ns []Neighbour[T]) {
+	slices.SortStableFunc(ns, func(a, b Neighbour[T]) int {
+		return cmp.Compare(a.Distance, b.Distance)
+	})
+}
@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// randomViennaCoordinates returns n pseudo-random coordinates in a box around Vienna.
+func randomViennaCoordinates(n int, seed int64) []Coordinates {
+	r := rand.New(rand.NewSource(seed))
+	cs := make([]Coordinates, n)
+	for i := range cs {
+		cs[i] = Coordinates{X: 16.18 + r.Float64()*0.4, Y: 48.12 + r.Float64()*0.2}
+	}
+	return cs
+}
+
+func TestSpatialIndex(t *testing.T) {
+	points := randomViennaCoordinates(8000, 1)
+	index := NewSpatialIndex[int](0)
+	for i, p := range points {
+		index.Insert(p, i)
+	}
+	assert.Equal(t, len(points), index.Len())
+
+	for _, q := range randomViennaCoordinates(50, 2) {
+		// brute force reference
+		distances := make([]float64, len(points))
+		byDistance := make([]int, len(points))
+		for i, p := range points {
+			distances[i] = q.HaversineDistance(p)
+			byDistance[i] = i
+		}
+		slices.SortStableFunc(byDistance, func(a, b int) int {
+			if distances[a] < distances[b] {
+				return -1
+			} else if distances[a] > distances[b] {
+				return 1
+			}
+			return 0
+		})
+
+		nearest := index.Nearest(q, 5, 0)
+		if assert.Len(t, nearest, 5) {
+			for i, n := range nearest {
+				assert.InDelta(t, distances[byDistance[i]], n.Distance, 1e-6)
+			}
+		}
+
+		within := index.Within(q, 500)
+		expected := 0
+		for _, d := range distances {
+			if d <= 500 {
+				expected++
+			}
+		}
+		assert.Len(t, within, expected)
+	}
+
+	assert.Empty(t, index.Nearest(Coordinates{X: 10, Y: 10}, 1, 1000))
+	assert.Len(t, index.Nearest(Coordinates{X: 10, Y: 10}, 1, 0), 1)
+}
+
+func BenchmarkSpatialIndexNearest(b *testing.B) {
+	points := randomViennaCoordinates(8000, 1)
+	index := NewSpatialIndex[int](0)
+	for i, p := range points {
+		index.Insert(p, i)
+	}
+	queries := randomViennaCoordinates(1000, 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Nearest(queries[i%len(queries)], 3, 0)
+	}
+}
+
+func BenchmarkSpatialIndexWithin(b *testing.B) {
+	points := randomViennaCoordinates(8000, 1)
+	index := NewSpatialIndex[int](0)
+	for i, p := range points {
+		index.Insert(p, i)
+	}
+	queries := randomViennaCoordinates(1000, 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Within(queries[i%len(queries)], 500)
+	}
+}
+
+func BenchmarkLinearScanWithin(b *testing.B) {
+	points := randomViennaCoordinates(8000, 1)
+	queries := randomViennaCoordinates(1000, 2)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := queries[i%len(queries)]
+		for _, p := range points {
+			q.Distance(p, DistanceFormulaDefault)
+		}
+	}
+}
+
+func TestSpatialIndexWithinEdge(t *testing.T) {
+	center := Coordinates{X: 16.37, Y: 48.21}
+	for name, tc := range map[string]struct {
+		location Coordinates
+		found    bool
+	}{
+		"9990 m north": {Coordinates{X: center.X, Y: center.Y + 9990/haversineMetersPerDegree}, true},
+		"9996 m east":  {Coordinates{X: center.X + 0.1349, Y: center.Y}, true},
+		"10004 m east": {Coordinates{X: center.X + 0.135, Y: center.Y}, false},
+	} {
+		// tiny cells, so the cells looked at are hardly larger than the box around the circle
+		index := NewSpatialIndex[string](0.00001)
+		index.Insert(tc.location, name)
+		assert.Equal(t, tc.found, len(index.Within(center, 10000)) == 1, name)
+	}
+}
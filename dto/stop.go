@@ -6,6 +6,9 @@ type Stop struct {
 	Name     string
 	Location Coordinates
 	Lines    *[]Line
+	Poles    []Coordinates // locations of the individual poles if the stop has been merged from several
+
+	Accessibility *Accessibility // nil if unknown
 }
 
 func (s *Stop) String() string {
@@ -19,3 +22,23 @@ func (s *Stop) String() string {
 	str += "]"
 	return str
 }
+
+// NearestStop is the stop of a line type nearest to an apartment.
+type NearestStop struct {
+	LineType LineType
+	Stop     *Stop
+	Distance float64 // in meters
+	Lines    []Line  // the lines of LineType serving the stop
+}
+
+// String returns a short description like "U6 Josefstädter Straße, 240 m".
+func (ns NearestStop) String() string {
+	names := ""
+	for i, l := range ns.Lines {
+		if i > 0 {
+			names += "/"
+		}
+		names += l.String()
+	}
+	return fmt.Sprintf("%s %s, %.0f m", names, ns.Stop.Name, ns.Distance)
+}
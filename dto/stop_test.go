@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearestStopString(t *testing.T) {
+	tram2, tram5 := Line{Name: "2", Type: LineTypeTram}, Line{Name: "5", Type: LineTypeTram}
+	stop := &Stop{Name: "Josefstädter Straße", Location: Coordinates{X: 16.3389, Y: 48.2110}, Lines: &[]Line{tram2, tram5}}
+
+	ns := NearestStop{LineType: LineTypeTram, Stop: stop, Distance: 239.6, Lines: []Line{tram2, tram5}}
+	assert.Equal(t, "2/5 Josefstädter Straße, 240 m", ns.String())
+
+	ns.Lines = nil
+	assert.Equal(t, " Josefstädter Straße, 240 m", ns.String())
+}
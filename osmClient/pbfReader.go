@@ -0,0 +1,396 @@
+package osmclient
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+This file contains a minimal reader for the OSM PBF format (https://wiki.openstreetmap.org/wiki/PBF_Format).
+It only decodes what is needed to build a walking network: dense and plain nodes with their coordinates,
+and ways with their tags and node references. Relations and metadata are skipped.
+*/
+
+const (
+	maxBlobHeaderSize = 64 * 1024
+	maxBlobSize       = 32 * 1024 * 1024
+
+	blobTypeHeader = "OSMHeader"
+	blobTypeData   = "OSMData"
+)
+
+// Node is an OSM node reduced to its id and location in degrees.
+type Node struct {
+	ID       int64
+	Lon, Lat float64
+}
+
+// Way is an OSM way with its tags and the ids of its nodes in order.
+type Way struct {
+	ID    int64
+	Tags  map[string]string
+	Nodes []int64
+}
+
+/*
+pbfHandler receives the decoded elements of a PBF file.
+Either function may be nil, in which case the corresponding elements are not decoded.
+*/
+type pbfHandler struct {
+	node func(Node)
+	way  func(Way)
+}
+
+// readPBF reads all blocks of a PBF file from r and passes the decoded elements to h.
+func readPBF(r io.Reader, h pbfHandler) error {
+	var sizeBuf [4]byte
+	for {
+		if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		headerSize := binary.BigEndian.Uint32(sizeBuf[:])
+		if headerSize > maxBlobHeaderSize {
+			return fmt.Errorf("blob header too large: %d bytes", headerSize)
+		}
+		headerBuf := make([]byte, headerSize)
+		if _, err := io.ReadFull(r, headerBuf); err != nil {
+			return err
+		}
+		blobType, blobSize, err := parseBlobHeader(headerBuf)
+		if err != nil {
+			return err
+		}
+		if blobSize > maxBlobSize {
+			return fmt.Errorf("blob too large: %d bytes", blobSize)
+		}
+		blobBuf := make([]byte, blobSize)
+		if _, err := io.ReadFull(r, blobBuf); err != nil {
+			return err
+		}
+
+		switch blobType {
+		case blobTypeHeader:
+			// the header only contains metadata and required features, none of which we support explicitly
+		case blobTypeData:
+			data, err := decodeBlob(blobBuf)
+			if err != nil {
+				return err
+			}
+			if err := parsePrimitiveBlock(data, h); err != nil {
+				return err
+			}
+		default:
+			// unknown blob types must be skipped according to the specification
+		}
+	}
+}
+
+func parseBlobHeader(buf []byte) (string, int, error) {
+	var blobType string
+	var blobSize int
+	err := eachField(buf, func(num int, wt wireType, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			blobType = string(b)
+		case 3:
+			blobSize = int(v)
+		}
+		return nil
+	})
+	return blobType, blobSize, err
+}
+
+// decodeBlob returns the uncompressed content of a blob. Only raw and zlib compressed blobs are supported.
+func decodeBlob(buf []byte) ([]byte, error) {
+	var raw, zlibData []byte
+	var rawSize int
+	err := eachField(buf, func(num int, wt wireType, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			raw = b
+		case 2:
+			rawSize = int(v)
+		case 3:
+			zlibData = b
+		case 4, 5, 6, 7:
+			return errors.New("unsupported blob compression")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		return raw, nil
+	}
+	if zlibData == nil {
+		return nil, errors.New("empty blob")
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(zlibData))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out := bytes.NewBuffer(make([]byte, 0, rawSize))
+	if _, err := io.Copy(out, zr); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// primitiveBlock holds the block-wide parameters needed to decode the elements of its groups.
+type primitiveBlock struct {
+	strings     [][]byte
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+func (pb primitiveBlock) lat(v int64) float64 {
+	return 1e-9 * float64(pb.latOffset+pb.granularity*v)
+}
+
+func (pb primitiveBlock) lon(v int64) float64 {
+	return 1e-9 * float64(pb.lonOffset+pb.granularity*v)
+}
+
+func parsePrimitiveBlock(buf []byte, h pbfHandler) error {
+	pb := primitiveBlock{granularity: 100}
+	groups := make([][]byte, 0)
+	err := eachField(buf, func(num int, wt wireType, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			return eachField(b, func(num int, wt wireType, v uint64, s []byte) error {
+				if num == 1 {
+					pb.strings = append(pb.strings, s)
+				}
+				return nil
+			})
+		case 2:
+			groups = append(groups, b)
+		case 17:
+			pb.granularity = int64(v)
+		case 19:
+			pb.latOffset = int64(v)
+		case 20:
+			pb.lonOffset = int64(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		err := eachField(g, func(num int, wt wireType, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				if h.node != nil {
+					return pb.parseNode(b, h.node)
+				}
+			case 2:
+				if h.node != nil {
+					return pb.parseDenseNodes(b, h.node)
+				}
+			case 3:
+				if h.way != nil {
+					return pb.parseWay(b, h.way)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pb primitiveBlock) parseNode(buf []byte, fn func(Node)) error {
+	var n Node
+	err := eachField(buf, func(num int, wt wireType, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			n.ID = zigzag(v)
+		case 8:
+			n.Lat = pb.lat(zigzag(v))
+		case 9:
+			n.Lon = pb.lon(zigzag(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fn(n)
+	return nil
+}
+
+func (pb primitiveBlock) parseDenseNodes(buf []byte, fn func(Node)) error {
+	var ids, lats, lons []int64
+	err := eachField(buf, func(num int, wt wireType, v uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			ids, err = packedSint64(b)
+		case 8:
+			lats, err = packedSint64(b)
+		case 9:
+			lons, err = packedSint64(b)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) != len(lats) || len(ids) != len(lons) {
+		return errors.New("dense nodes with inconsistent lengths")
+	}
+
+	var id, lat, lon int64
+	for i := range ids {
+		id += ids[i]
+		lat += lats[i]
+		lon += lons[i]
+		fn(Node{ID: id, Lat: pb.lat(lat), Lon: pb.lon(lon)})
+	}
+	return nil
+}
+
+func (pb primitiveBlock) parseWay(buf []byte, fn func(Way)) error {
+	w := Way{Tags: make(map[string]string)}
+	var keys, vals []uint64
+	var refs []int64
+	err := eachField(buf, func(num int, wt wireType, v uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			w.ID = int64(v)
+		case 2:
+			keys, err = packedUvarint(b)
+		case 3:
+			vals, err = packedUvarint(b)
+		case 8:
+			refs, err = packedSint64(b)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(keys) != len(vals) {
+		return fmt.Errorf("way %d has %d keys but %d values", w.ID, len(keys), len(vals))
+	}
+	for i := range keys {
+		if keys[i] >= uint64(len(pb.strings)) || vals[i] >= uint64(len(pb.strings)) {
+			return fmt.Errorf("way %d references unknown string", w.ID)
+		}
+		w.Tags[string(pb.strings[keys[i]])] = string(pb.strings[vals[i]])
+	}
+
+	w.Nodes = make([]int64, len(refs))
+	var ref int64
+	for i, delta := range refs {
+		ref += delta
+		w.Nodes[i] = ref
+	}
+	fn(w)
+	return nil
+}
+
+type wireType int
+
+const (
+	wireVarint  wireType = 0
+	wireFixed64 wireType = 1
+	wireBytes   wireType = 2
+	wireFixed32 wireType = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+/*
+eachField iterates over the fields of a protobuf message and calls fn for every field.
+Varint fields are passed in v, length-delimited fields in b. Fixed size fields are skipped.
+*/
+func eachField(buf []byte, fn func(num int, wt wireType, v uint64, b []byte) error) error {
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return errTruncated
+		}
+		buf = buf[n:]
+		num, wt := int(key>>3), wireType(key&7)
+
+		switch wt {
+		case wireVarint:
+			v, n := binary.Uvarint(buf)
+			if n <= 0 {
+				return errTruncated
+			}
+			buf = buf[n:]
+			if err := fn(num, wt, v, nil); err != nil {
+				return err
+			}
+		case wireBytes:
+			l, n := binary.Uvarint(buf)
+			if n <= 0 || uint64(len(buf)-n) < l {
+				return errTruncated
+			}
+			b := buf[n : n+int(l)]
+			buf = buf[n+int(l):]
+			if err := fn(num, wt, 0, b); err != nil {
+				return err
+			}
+		case wireFixed64:
+			if len(buf) < 8 {
+				return errTruncated
+			}
+			buf = buf[8:]
+		case wireFixed32:
+			if len(buf) < 4 {
+				return errTruncated
+			}
+			buf = buf[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wt)
+		}
+	}
+	return nil
+}
+
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func packedUvarint(buf []byte) ([]uint64, error) {
+	values := make([]uint64, 0)
+	for len(buf) > 0 {
+		v, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		values = append(values, v)
+		buf = buf[n:]
+	}
+	return values, nil
+}
+
+func packedSint64(buf []byte) ([]int64, error) {
+	raw, err := packedUvarint(buf)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int64, len(raw))
+	for i, v := range raw {
+		values[i] = zigzag(v)
+	}
+	return values, nil
+}
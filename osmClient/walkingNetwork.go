@@ -0,0 +1,231 @@
+package osmclient
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const MaxSnapDistance = 250.0 // m, coordinates farther away from the walking network cannot be routed
+
+// walkableHighways are the highway values of ways that can be walked on unless tagged otherwise.
+var walkableHighways = map[string]bool{
+	"footway":       true,
+	"pedestrian":    true,
+	"path":          true,
+	"steps":         true,
+	"living_street": true,
+	"residential":   true,
+	"service":       true,
+	"unclassified":  true,
+	"tertiary":      true,
+	"tertiary_link": true,
+	"secondary":     true,
+	"primary":       true,
+	"track":         true,
+	"cycleway":      true,
+	"bridleway":     true,
+	"corridor":      true,
+	"platform":      true,
+	"road":          true,
+}
+
+/*
+isWalkable decides from the tags of a way whether pedestrians may use it.
+Explicit foot tags take precedence over the highway type and general access restrictions.
+*/
+func isWalkable(tags map[string]string) bool {
+	switch tags["foot"] {
+	case "yes", "designated", "permissive", "destination":
+		return tags["highway"] != ""
+	case "no", "private", "use_sidepath":
+		return false
+	}
+	switch tags["access"] {
+	case "no", "private":
+		return false
+	}
+	return walkableHighways[tags["highway"]]
+}
+
+type walkingEdge struct {
+	to     int32
+	length float32 // m
+}
+
+/*
+WalkingNetwork is a pedestrian graph built from an OSM extract.
+
+It answers shortest walking distance queries between arbitrary coordinates by snapping them
+to the nearest network node and running A* with the straight-line distance as heuristic.
+It implements dto.WalkingNetwork and is safe for concurrent use once loaded.
+*/
+type WalkingNetwork struct {
+	locations []dto.Coordinates
+	edges     [][]walkingEdge
+	index     *dto.SpatialIndex[int32]
+}
+
+/*
+LoadWalkingNetwork builds a WalkingNetwork from the OSM PBF file at path, e.g. a Vienna extract.
+
+The file is read twice: first to collect the walkable ways, then to look up the coordinates
+of only the nodes they reference, which keeps memory usage independent of the extract's node count.
+*/
+func LoadWalkingNetwork(path string) (*WalkingNetwork, error) {
+	ways := make([]Way, 0)
+	needed := make(map[int64]int32)
+	err := readPBFFile(path, pbfHandler{way: func(w Way) {
+		if !isWalkable(w.Tags) || len(w.Nodes) < 2 {
+			return
+		}
+		w.Tags = nil // not needed anymore
+		ways = append(ways, w)
+		for _, id := range w.Nodes {
+			needed[id] = -1
+		}
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	wn := &WalkingNetwork{index: dto.NewSpatialIndex[int32](0)}
+	err = readPBFFile(path, pbfHandler{node: func(n Node) {
+		if _, ok := needed[n.ID]; !ok {
+			return
+		}
+		needed[n.ID] = int32(len(wn.locations))
+		wn.locations = append(wn.locations, dto.Coordinates{X: n.Lon, Y: n.Lat})
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	wn.edges = make([][]walkingEdge, len(wn.locations))
+	for _, w := range ways {
+		for i := 1; i < len(w.Nodes); i++ {
+			a, b := needed[w.Nodes[i-1]], needed[w.Nodes[i]]
+			if a < 0 || b < 0 || a == b {
+				// node missing from the extract, e.g. at its border
+				continue
+			}
+			length := float32(wn.locations[a].Distance(wn.locations[b], dto.DistanceFormulaHaversine))
+			wn.edges[a] = append(wn.edges[a], walkingEdge{to: b, length: length})
+			wn.edges[b] = append(wn.edges[b], walkingEdge{to: a, length: length})
+		}
+	}
+
+	for i, l := range wn.locations {
+		if len(wn.edges[i]) > 0 {
+			wn.index.Insert(l, int32(i))
+		}
+	}
+	return wn, nil
+}
+
+func readPBFFile(path string, h pbfHandler) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return readPBF(f, h)
+}
+
+// ReadNodesAndWays reads all nodes and ways of a PBF stream, e.g. for inspecting small extracts.
+func ReadNodesAndWays(r io.Reader, node func(Node), way func(Way)) error {
+	return readPBF(r, pbfHandler{node: node, way: way})
+}
+
+// Nodes returns the number of nodes in the walking network.
+func (wn *WalkingNetwork) Nodes() int {
+	return len(wn.locations)
+}
+
+// snap returns the network node nearest to c and its distance in meters, or -1 if there is none within MaxSnapDistance.
+func (wn *WalkingNetwork) snap(c dto.Coordinates) (int32, float64) {
+	nearest := wn.index.Nearest(c, 1, MaxSnapDistance)
+	if len(nearest) == 0 {
+		return -1, 0
+	}
+	return nearest[0].Item, nearest[0].Distance
+}
+
+/*
+WalkingDistance returns the length in meters of the shortest walk between two coordinates.
+Both coordinates are snapped to their nearest network node and the snapping distances are added to the result.
+An error is returned if a coordinate is not near the network or the two are not connected.
+*/
+func (wn *WalkingNetwork) WalkingDistance(from, to dto.Coordinates) (float64, error) {
+	start, startSnap := wn.snap(from)
+	if start < 0 {
+		return 0, fmt.Errorf("%v is not near the walking network", from)
+	}
+	goal, goalSnap := wn.snap(to)
+	if goal < 0 {
+		return 0, fmt.Errorf("%v is not near the walking network", to)
+	}
+
+	d, ok := wn.aStar(start, goal)
+	if !ok {
+		return 0, fmt.Errorf("no walking connection between %v and %v", from, to)
+	}
+	return startSnap + d + goalSnap, nil
+}
+
+// aStar returns the shortest path length between two nodes.
+func (wn *WalkingNetwork) aStar(start, goal int32) (float64, bool) {
+	goalLocation := wn.locations[goal]
+	heuristic := func(i int32) float64 {
+		return wn.locations[i].Distance(goalLocation, dto.DistanceFormulaHaversine)
+	}
+
+	distances := map[int32]float64{start: 0}
+	closed := make(map[int32]bool)
+	open := &aStarQueue{{node: start, estimate: heuristic(start)}}
+	for open.Len() > 0 {
+		item := heap.Pop(open).(aStarItem)
+		if item.node == goal {
+			return distances[goal], true
+		}
+		if closed[item.node] {
+			continue
+		}
+		closed[item.node] = true
+
+		for _, e := range wn.edges[item.node] {
+			if closed[e.to] {
+				continue
+			}
+			d := distances[item.node] + float64(e.length)
+			if old, ok := distances[e.to]; ok && old <= d {
+				continue
+			}
+			distances[e.to] = d
+			heap.Push(open, aStarItem{node: e.to, estimate: d + heuristic(e.to)})
+		}
+	}
+	return 0, false
+}
+
+type aStarItem struct {
+	node     int32
+	estimate float64
+}
+
+// aStarQueue is a min-heap of aStarItems ordered by their estimated total distance.
+type aStarQueue []aStarItem
+
+func (q aStarQueue) Len() int           { return len(q) }
+func (q aStarQueue) Less(i, j int) bool { return q[i].estimate < q[j].estimate }
+func (q aStarQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *aStarQueue) Push(x any)        { *q = append(*q, x.(aStarItem)) }
+func (q *aStarQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,175 @@
+package osmclient
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+// protobuf encoding helpers for building test files
+
+func appendVarint(buf []byte, num int, v uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(num)<<3)
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendBytes(buf []byte, num int, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(num)<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func packSint64(values []int64) []byte {
+	buf := make([]byte, 0)
+	for _, v := range values {
+		buf = binary.AppendUvarint(buf, uint64(v<<1)^uint64(v>>63))
+	}
+	return buf
+}
+
+func packUvarint(values []uint64) []byte {
+	buf := make([]byte, 0)
+	for _, v := range values {
+		buf = binary.AppendUvarint(buf, v)
+	}
+	return buf
+}
+
+func deltas(values []int64) []int64 {
+	d := make([]int64, len(values))
+	var last int64
+	for i, v := range values {
+		d[i] = v - last
+		last = v
+	}
+	return d
+}
+
+func appendBlob(file []byte, blobType string, data []byte, compress bool) []byte {
+	blob := make([]byte, 0)
+	if compress {
+		var zb bytes.Buffer
+		zw := zlib.NewWriter(&zb)
+		zw.Write(data)
+		zw.Close()
+		blob = appendVarint(blob, 2, uint64(len(data)))
+		blob = appendBytes(blob, 3, zb.Bytes())
+	} else {
+		blob = appendBytes(blob, 1, data)
+	}
+	header := appendBytes(nil, 1, []byte(blobType))
+	header = appendVarint(header, 3, uint64(len(blob)))
+	file = binary.BigEndian.AppendUint32(file, uint32(len(header)))
+	file = append(file, header...)
+	return append(file, blob...)
+}
+
+type testWay struct {
+	id    int64
+	tags  [][2]string
+	nodes []int64
+}
+
+// buildPBF encodes nodes as dense nodes in one block and the ways in a second block.
+func buildPBF(nodes []Node, ways []testWay) []byte {
+	strs := [][]byte{{}}
+	index := func(s string) uint64 {
+		for i, v := range strs {
+			if string(v) == s {
+				return uint64(i)
+			}
+		}
+		strs = append(strs, []byte(s))
+		return uint64(len(strs) - 1)
+	}
+
+	ids, lats, lons := make([]int64, 0), make([]int64, 0), make([]int64, 0)
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+		lats = append(lats, int64(n.Lat*1e7))
+		lons = append(lons, int64(n.Lon*1e7))
+	}
+	dense := appendBytes(nil, 1, packSint64(deltas(ids)))
+	dense = appendBytes(dense, 8, packSint64(deltas(lats)))
+	dense = appendBytes(dense, 9, packSint64(deltas(lons)))
+	nodeGroup := appendBytes(nil, 2, dense)
+
+	wayGroup := make([]byte, 0)
+	for _, w := range ways {
+		keys, vals := make([]uint64, 0), make([]uint64, 0)
+		for _, t := range w.tags {
+			keys = append(keys, index(t[0]))
+			vals = append(vals, index(t[1]))
+		}
+		way := appendVarint(nil, 1, uint64(w.id))
+		way = appendBytes(way, 2, packUvarint(keys))
+		way = appendBytes(way, 3, packUvarint(vals))
+		way = appendBytes(way, 8, packSint64(deltas(w.nodes)))
+		wayGroup = appendBytes(wayGroup, 3, way)
+	}
+
+	stringTable := make([]byte, 0)
+	for _, s := range strs {
+		stringTable = appendBytes(stringTable, 1, s)
+	}
+
+	nodeBlock := appendBytes(nil, 1, appendBytes(nil, 1, nil))
+	nodeBlock = appendBytes(nodeBlock, 2, nodeGroup)
+	wayBlock := appendBytes(nil, 1, stringTable)
+	wayBlock = appendBytes(wayBlock, 2, wayGroup)
+
+	file := appendBlob(nil, blobTypeHeader, appendBytes(nil, 4, []byte("DenseNodes")), false)
+	file = appendBlob(file, blobTypeData, nodeBlock, true)
+	return appendBlob(file, blobTypeData, wayBlock, false)
+}
+
+func TestWalkingNetwork(t *testing.T) {
+	nodes := []Node{
+		{ID: 1, Lon: 16.3700, Lat: 48.2000},
+		{ID: 2, Lon: 16.3700, Lat: 48.2010},
+		{ID: 3, Lon: 16.3710, Lat: 48.2010},
+		{ID: 4, Lon: 16.3710, Lat: 48.2000}, // only reachable via the motorway
+		{ID: 5, Lon: 16.4000, Lat: 48.2000}, // far away, only on a private way
+		{ID: 6, Lon: 16.4010, Lat: 48.2000},
+	}
+	ways := []testWay{
+		{id: 10, tags: [][2]string{{"highway", "footway"}}, nodes: []int64{1, 2, 3}},
+		{id: 11, tags: [][2]string{{"highway", "motorway"}}, nodes: []int64{3, 4, 1}},
+		{id: 12, tags: [][2]string{{"highway", "residential"}, {"access", "private"}}, nodes: []int64{5, 6}},
+	}
+	path := filepath.Join(t.TempDir(), "test.osm.pbf")
+	if err := os.WriteFile(path, buildPBF(nodes, ways), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wn, err := LoadWalkingNetwork(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, wn.Nodes())
+
+	loc := func(i int) dto.Coordinates {
+		return dto.Coordinates{X: nodes[i].Lon, Y: nodes[i].Lat}
+	}
+
+	d, err := wn.WalkingDistance(loc(0), loc(2))
+	assert.NoError(t, err)
+	expected := loc(0).HaversineDistance(loc(1)) + loc(1).HaversineDistance(loc(2))
+	assert.InDelta(t, expected, d, 1)
+	assert.Greater(t, d, loc(0).HaversineDistance(loc(2)))
+
+	_, err = wn.WalkingDistance(loc(0), loc(4))
+	assert.Error(t, err)
+
+	// the network is passed to the filters as a distance function
+	var distance dto.DistanceFunc = wn.WalkingDistance
+	d, err = distance(loc(0), loc(2))
+	assert.NoError(t, err)
+	assert.InDelta(t, expected, d, 1)
+}
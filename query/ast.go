@@ -0,0 +1,196 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of the syntax tree of a query. String formats it back into a query.
+type Expr interface {
+	Pos() Pos
+	String() string
+	expr()
+}
+
+// FieldKind is the type of a field, which determines the operators and values it accepts.
+type FieldKind int
+
+const (
+	NumberField        FieldKind = iota // price, rooms, area, year: compared with =, !=, <, <=, >, >= and in
+	DistrictField                       // district by number or name: = , != and in
+	NeighbourhoodField                  // Grätzl by name: =, != and in, see domain.ImmoListings.AnnotateNeighbourhoods
+	EnumField                           // heating, furnishing, ...: =, != and in; energy also <, <=, >, >=
+	TextField                           // text: matched with ~
+	FlagField                           // pets, cellar, balcony, ...: used on its own, like "pets and not cellar"
+)
+
+// Field is a listing attribute a query can refer to.
+type Field struct {
+	Name string
+	Kind FieldKind
+}
+
+// fields are the fields of the language by name.
+var fields = map[string]Field{}
+
+func init() {
+	for kind, names := range map[FieldKind][]string{
+		NumberField:        {"price", "rooms", "area", "year"},
+		DistrictField:      {"district"},
+		NeighbourhoodField: {"neighbourhood", "graetzl", "grätzl"},
+		EnumField:          {"heating", "furnishing", "building", "condition", "parking", "energy"},
+		TextField:          {"text"},
+		FlagField:          {"pets", "cellar", "lift", "balcony", "terrace", "garden", "loggia", "outdoor"},
+	} {
+		for _, name := range names {
+			fields[name] = Field{Name: name, Kind: kind}
+		}
+	}
+}
+
+// ordered reports whether the field's values can be compared with <, <=, > and >=.
+func (f Field) ordered() bool {
+	return f.Kind == NumberField || f.Name == "energy"
+}
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	OpEq    Op = "="
+	OpNe    Op = "!="
+	OpLt    Op = "<"
+	OpLe    Op = "<="
+	OpGt    Op = ">"
+	OpGe    Op = ">="
+	OpMatch Op = "~"
+)
+
+// ValueKind tells how a value was written.
+type ValueKind int
+
+const (
+	NumberValue ValueKind = iota
+	StringValue
+	IdentValue // an unquoted word like tram or fernwärme
+)
+
+// Value is a literal in a query.
+type Value struct {
+	At     Pos
+	Kind   ValueKind
+	Text   string  // as written, without quotes
+	Number float64 // for NumberValue
+}
+
+func (v Value) String() string {
+	if v.Kind == StringValue {
+		return strconv.Quote(v.Text)
+	}
+	return v.Text
+}
+
+// AndExpr matches listings matching both sides.
+type AndExpr struct {
+	Left, Right Expr
+}
+
+// OrExpr matches listings matching either side.
+type OrExpr struct {
+	Left, Right Expr
+}
+
+// NotExpr matches listings not matching X.
+type NotExpr struct {
+	At Pos
+	X  Expr
+}
+
+// CompareExpr compares a field with a value, like price <= 1400 or text ~ "befristet".
+type CompareExpr struct {
+	At    Pos
+	Field Field
+	Op    Op
+	Value Value
+}
+
+// InExpr matches listings whose field has one of the values, like district in (7, 8, 9).
+type InExpr struct {
+	At     Pos
+	Field  Field
+	Values []Value
+}
+
+// FlagExpr matches listings having a feature, like pets or balcony.
+type FlagExpr struct {
+	At    Pos
+	Field Field
+}
+
+// NearExpr matches listings within Distance meters of a stop of a line or of a line type, like near U6 within 400m.
+type NearExpr struct {
+	At       Pos
+	Line     Value // line name like U6 or 13A, or line type like tram
+	Distance float64
+}
+
+func (e *AndExpr) Pos() Pos     { return e.Left.Pos() }
+func (e *OrExpr) Pos() Pos      { return e.Left.Pos() }
+func (e *NotExpr) Pos() Pos     { return e.At }
+func (e *CompareExpr) Pos() Pos { return e.At }
+func (e *InExpr) Pos() Pos      { return e.At }
+func (e *FlagExpr) Pos() Pos    { return e.At }
+func (e *NearExpr) Pos() Pos    { return e.At }
+
+func (*AndExpr) expr()     {}
+func (*OrExpr) expr()      {}
+func (*NotExpr) expr()     {}
+func (*CompareExpr) expr() {}
+func (*InExpr) expr()      {}
+func (*FlagExpr) expr()    {}
+func (*NearExpr) expr()    {}
+
+// parenthesize wraps e in parentheses if it is an or expression, which binds weaker than and and not.
+func parenthesize(e Expr) string {
+	if _, ok := e.(*OrExpr); ok {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+func (e *AndExpr) String() string {
+	return parenthesize(e.Left) + " and " + parenthesize(e.Right)
+}
+
+func (e *OrExpr) String() string {
+	return e.Left.String() + " or " + e.Right.String()
+}
+
+func (e *NotExpr) String() string {
+	switch e.X.(type) {
+	case *AndExpr, *OrExpr:
+		return "not (" + e.X.String() + ")"
+	}
+	return "not " + e.X.String()
+}
+
+func (e *CompareExpr) String() string {
+	return fmt.Sprintf("%s %s %s", e.Field.Name, e.Op, e.Value)
+}
+
+func (e *InExpr) String() string {
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = v.String()
+	}
+	return fmt.Sprintf("%s in (%s)", e.Field.Name, strings.Join(values, ", "))
+}
+
+func (e *FlagExpr) String() string {
+	return e.Field.Name
+}
+
+func (e *NearExpr) String() string {
+	return fmt.Sprintf("near %s within %sm", e.Line, strconv.FormatFloat(e.Distance, 'f', -1, 64))
+}
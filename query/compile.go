@@ -0,0 +1,335 @@
+package query
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ehganzlieb/willfahren/domain"
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+/*
+Env holds what compiling a query needs beyond the listings themselves. Stops is the stop map
+as returned by wlclient.AggregateStops and Distance measures the distance to them, e.g.
+dto.DistanceFormulaDefault.Func(); both are only required for near.
+*/
+type Env struct {
+	Stops    map[dto.Line][]*dto.Stop
+	Distance dto.DistanceFunc
+}
+
+// Compile parses the query and compiles it into a filter, see Parse and CompileExpr.
+func Compile(q string, env Env) (domain.ImmoListingsFilter, error) {
+	e, err := Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	return CompileExpr(e, env)
+}
+
+/*
+CompileExpr compiles the syntax tree into a filter. Values are checked here, so an unknown
+district, heating type or line, or all of Vienna given as district, is reported as *Error at its
+position. text ~ matches a substring of title and description, ignoring case and diacritics. As with
+domain.FilterPrice, FilterRooms and FilterArea, listings where a compared attribute is unknown do not
+match; "not" of such a comparison does.
+*/
+func CompileExpr(e Expr, env Env) (domain.ImmoListingsFilter, error) {
+	switch e := e.(type) {
+	case *AndExpr:
+		left, right, err := compileBoth(e.Left, e.Right, env)
+		if err != nil {
+			return nil, err
+		}
+		return domain.MergeFilters(left, right), nil
+	case *OrExpr:
+		left, right, err := compileBoth(e.Left, e.Right, env)
+		if err != nil {
+			return nil, err
+		}
+		return domain.AnyOf(left, right), nil
+	case *NotExpr:
+		x, err := CompileExpr(e.X, env)
+		if err != nil {
+			return nil, err
+		}
+		return domain.InvertImmoListingsFilter(x), nil
+	case *FlagExpr:
+		return compileFlag(e.Field), nil
+	case *CompareExpr:
+		return compileCompare(e)
+	case *InExpr:
+		return compileIn(e)
+	case *NearExpr:
+		return compileNear(e, env)
+	}
+	return nil, errorf(e.Pos(), "unsupported expression %s", e)
+}
+
+func compileBoth(left, right Expr, env Env) (domain.ImmoListingsFilter, domain.ImmoListingsFilter, error) {
+	l, err := CompileExpr(left, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := CompileExpr(right, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
+
+func compileFlag(f Field) domain.ImmoListingsFilter {
+	switch f.Name {
+	case "pets":
+		return domain.FilterPetsAllowed()
+	case "cellar":
+		return domain.FilterCellar()
+	case "lift":
+		return domain.FilterLift(1)
+	case "balcony":
+		return domain.FilterOutdoorSpace(0, dto.OutdoorSpaceBalcony)
+	case "terrace":
+		return domain.FilterOutdoorSpace(0, dto.OutdoorSpaceTerrace)
+	case "garden":
+		return domain.FilterOutdoorSpace(0, dto.OutdoorSpaceGarden)
+	case "loggia":
+		return domain.FilterOutdoorSpace(0, dto.OutdoorSpaceLoggia)
+	}
+	return domain.FilterOutdoorSpace(0)
+}
+
+// compileCompare compiles a comparison; != is compiled as not =.
+func compileCompare(e *CompareExpr) (domain.ImmoListingsFilter, error) {
+	if e.Op == OpNe {
+		eq, err := compileCompare(&CompareExpr{At: e.At, Field: e.Field, Op: OpEq, Value: e.Value})
+		if err != nil {
+			return nil, err
+		}
+		return domain.InvertImmoListingsFilter(eq), nil
+	}
+	switch e.Field.Kind {
+	case TextField:
+		pattern := dto.NormalizeName(e.Value.Text)
+		return func(il domain.ImmoListing) bool {
+			return strings.Contains(dto.NormalizeName(il.Title+"\n"+il.Description), pattern)
+		}, nil
+	case NumberField:
+		if e.Value.Kind != NumberValue {
+			return nil, errorf(e.Value.At, "%s needs a number, found %s", e.Field.Name, e.Value)
+		}
+		get := numberGetter(e.Field)
+		want := e.Value.Number
+		return func(il domain.ImmoListing) bool {
+			v, ok := get(il)
+			return ok && compare(v, want, e.Op)
+		}, nil
+	case EnumField:
+		if e.Op == OpEq {
+			return compileIn(&InExpr{At: e.At, Field: e.Field, Values: []Value{e.Value}})
+		}
+		class, ok := dto.ParseEnergyClass(e.Value.Text)
+		if !ok {
+			return nil, errorf(e.Value.At, "unknown energy class %s", e.Value)
+		}
+		return func(il domain.ImmoListing) bool {
+			// the better the class, the lower its value
+			return il.EnergyClass != dto.EnergyClassUnknown && compare(float64(il.EnergyClass), float64(class), e.Op)
+		}, nil
+	}
+	return compileIn(&InExpr{At: e.At, Field: e.Field, Values: []Value{e.Value}})
+}
+
+func compare(v, want float64, op Op) bool {
+	switch op {
+	case OpEq:
+		return math.Abs(v-want) < 1e-9
+	case OpLt:
+		return v < want
+	case OpLe:
+		return v <= want
+	case OpGt:
+		return v > want
+	case OpGe:
+		return v >= want
+	}
+	return false
+}
+
+// numberGetter returns the value of a number field of a listing; ok is false if it is unknown.
+func numberGetter(f Field) func(domain.ImmoListing) (float64, bool) {
+	switch f.Name {
+	case "price":
+		return func(il domain.ImmoListing) (float64, bool) {
+			p, ok := il.MonthlyCost()
+			return float64(p), ok
+		}
+	case "rooms":
+		return func(il domain.ImmoListing) (float64, bool) { return float64(il.Rooms), il.Rooms != 0 }
+	case "area":
+		return func(il domain.ImmoListing) (float64, bool) { return float64(il.Area), il.Area != 0 }
+	}
+	return func(il domain.ImmoListing) (float64, bool) { return float64(il.YearBuilt), il.YearBuilt != 0 }
+}
+
+func compileIn(e *InExpr) (domain.ImmoListingsFilter, error) {
+	switch e.Field.Kind {
+	case NumberField:
+		get := numberGetter(e.Field)
+		wants := make([]float64, len(e.Values))
+		for i, v := range e.Values {
+			if v.Kind != NumberValue {
+				return nil, errorf(v.At, "%s needs a number, found %s", e.Field.Name, v)
+			}
+			wants[i] = v.Number
+		}
+		return func(il domain.ImmoListing) bool {
+			v, ok := get(il)
+			return ok && slices.ContainsFunc(wants, func(want float64) bool { return compare(v, want, OpEq) })
+		}, nil
+	case DistrictField:
+		numbers := make([]int, len(e.Values))
+		for i, v := range e.Values {
+			d, err := resolveDistrict(v)
+			if err != nil {
+				return nil, err
+			}
+			numbers[i] = d.Number
+		}
+		return func(il domain.ImmoListing) bool {
+			return il.District != nil && slices.Contains(numbers, il.District.Number)
+		}, nil
+	case NeighbourhoodField:
+		names := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			if _, err := dto.NeighbourhoodByName(v.Text); err != nil {
+				return nil, errorf(v.At, "unknown neighbourhood %s", v)
+			}
+			names[i] = v.Text
+		}
+		return domain.FilterNeighbourhoods(names...), nil
+	case EnumField:
+		return compileEnumIn(e)
+	}
+	return nil, errorf(e.At, "%s does not support in", e.Field.Name)
+}
+
+/*
+resolveDistrict resolves a district number like 7, a numeral like VII or a name like Josefstadt.
+The pseudo-district 0 and names of the whole city like "Wien" are rejected, as no listing is in them.
+*/
+func resolveDistrict(v Value) (*dto.District, error) {
+	if v.Kind == NumberValue {
+		if v.Number != math.Trunc(v.Number) {
+			return nil, errorf(v.At, "invalid district number %s", v)
+		}
+		d, err := dto.DistrictByNumber(int(v.Number))
+		if err != nil {
+			return nil, errorf(v.At, "unknown district %s", v)
+		}
+		if d.Number == 0 {
+			return nil, errorf(v.At, "%s is all of Vienna, not a district", v)
+		}
+		return d, nil
+	}
+	matches := dto.ResolveDistricts(v.Text, 1)
+	if city := wholeCity(v.Text); city > 0 && (len(matches) == 0 || city >= matches[0].Score) {
+		return nil, errorf(v.At, "%s is all of Vienna, not a district", v)
+	}
+	if len(matches) == 0 {
+		return nil, errorf(v.At, "unknown district %s", v)
+	}
+	return matches[0].District, nil
+}
+
+// wholeCity rates how well text names all of Vienna, like FuzzyScore; 0 if it is not above dto.MinDistrictScore.
+func wholeCity(text string) float64 {
+	city, _ := dto.DistrictByNumber(0)
+	best := 0.0
+	for _, name := range append([]string{"Wien", "Vienna", city.Name}, city.InsiderNames...) {
+		if score := dto.FuzzyScore(text, name); score >= dto.MinDistrictScore && score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// enumParsers parse the values of the enum fields into the value of the listing they are compared with.
+var enumParsers = map[string]struct {
+	parse func(string) (int, bool)
+	get   func(domain.ImmoListing) int
+}{
+	"heating": {
+		func(s string) (int, bool) { v, ok := dto.ParseHeatingType(s); return int(v), ok },
+		func(il domain.ImmoListing) int { return int(il.Heating) },
+	},
+	"furnishing": {
+		func(s string) (int, bool) { v, ok := dto.ParseFurnishing(s); return int(v), ok },
+		func(il domain.ImmoListing) int { return int(il.Furnishing) },
+	},
+	"building": {
+		func(s string) (int, bool) { v, ok := dto.ParseBuildingType(s); return int(v), ok },
+		func(il domain.ImmoListing) int { return int(il.BuildingType) },
+	},
+	"condition": {
+		func(s string) (int, bool) { v, ok := dto.ParseCondition(s); return int(v), ok },
+		func(il domain.ImmoListing) int { return int(il.Condition) },
+	},
+	"parking": {
+		parseParking,
+		func(il domain.ImmoListing) int { return int(il.Parking) },
+	},
+	"energy": {
+		func(s string) (int, bool) { v, ok := dto.ParseEnergyClass(s); return int(v), ok },
+		func(il domain.ImmoListing) int { return int(il.EnergyClass) },
+	},
+}
+
+func parseParking(s string) (int, bool) {
+	for _, p := range []dto.ParkingType{dto.ParkingNone, dto.ParkingOutdoor, dto.ParkingGarage} {
+		if strings.EqualFold(s, p.String()) {
+			return int(p), true
+		}
+	}
+	return 0, false
+}
+
+func compileEnumIn(e *InExpr) (domain.ImmoListingsFilter, error) {
+	enum := enumParsers[e.Field.Name]
+	wants := make([]int, len(e.Values))
+	for i, v := range e.Values {
+		want, ok := enum.parse(v.Text)
+		if !ok {
+			return nil, errorf(v.At, "unknown %s %s", e.Field.Name, v)
+		}
+		wants[i] = want
+	}
+	return func(il domain.ImmoListing) bool {
+		return slices.Contains(wants, enum.get(il))
+	}, nil
+}
+
+// lineTypes are the line types near accepts by name.
+var lineTypes = map[string][]dto.LineType{
+	"ubahn": {dto.LineTypeUBahn}, "u-bahn": {dto.LineTypeUBahn}, "subway": {dto.LineTypeUBahn},
+	"sbahn": {dto.LineTypeSBahn}, "s-bahn": {dto.LineTypeSBahn},
+	"badnerbahn": {dto.LineTypeBadnerBahn}, "wlb": {dto.LineTypeBadnerBahn},
+	"tram": {dto.LineTypeTram}, "strassenbahn": {dto.LineTypeTram}, "straßenbahn": {dto.LineTypeTram},
+	"bus": {dto.LineTypeBus}, "nightbus": {dto.LineTypeNightBus}, "nightline": {dto.LineTypeNightBus, dto.LineTypeNightGroupTaxi},
+	"rail": {dto.LineTypeUBahn, dto.LineTypeSBahn, dto.LineTypeBadnerBahn, dto.LineTypeTram},
+}
+
+func compileNear(e *NearExpr, env Env) (domain.ImmoListingsFilter, error) {
+	if env.Stops == nil {
+		return nil, errorf(e.At, "near needs the stops of the transit network")
+	}
+	if types, ok := lineTypes[strings.ToLower(e.Line.Text)]; ok {
+		return domain.FilterLineTypes(env.Stops, types, e.Distance, env.Distance), nil
+	}
+	for line := range env.Stops {
+		if strings.EqualFold(line.Name, e.Line.Text) {
+			return domain.FilterLines(env.Stops, []string{line.Name}, e.Distance, env.Distance), nil
+		}
+	}
+	return nil, errorf(e.Line.At, "unknown line or line type %s", e.Line)
+}
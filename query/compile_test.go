@@ -0,0 +1,102 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/domain"
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEnv() Env {
+	u6 := dto.Line{Name: "U6", Type: dto.LineTypeUBahn}
+	tram5 := dto.Line{Name: "5", Type: dto.LineTypeTram}
+	josefstaedter := &dto.Stop{Name: "Josefstädter Straße", Location: dto.Coordinates{X: 16.3387, Y: 48.2114}}
+	westbahnhof := &dto.Stop{Name: "Westbahnhof", Location: dto.Coordinates{X: 16.3375, Y: 48.1967}}
+	return Env{
+		Stops:    map[dto.Line][]*dto.Stop{u6: {josefstaedter}, tram5: {westbahnhof}},
+		Distance: dto.DistanceFormulaHaversine.Func(),
+	}
+}
+
+func listings() domain.ImmoListings {
+	yes := true
+	eighth, _ := dto.DistrictByNumber(8)
+	seventh, _ := dto.DistrictByNumber(7)
+	tenth, _ := dto.DistrictByNumber(10)
+	return domain.ImmoListings{
+		{ID: 1, District: eighth, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1200)}, Rooms: 2,
+			Location: &dto.Coordinates{X: 16.3400, Y: 48.2120}, Description: "Altbau mit Flügeltüren",
+			Attributes: dto.Attributes{Heating: dto.HeatingDistrict, EnergyClass: dto.EnergyClassC, PetsAllowed: &yes}},
+		{ID: 2, District: seventh, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1350)}, Rooms: 3,
+			Location: &dto.Coordinates{X: 16.3380, Y: 48.1975}, Description: "Mietvertrag befristet auf 3 Jahre",
+			Attributes: dto.Attributes{Heating: dto.HeatingGasFloor, EnergyClass: dto.EnergyClassE}},
+		{ID: 3, District: seventh, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(1300)}, Rooms: 2,
+			Location: &dto.Coordinates{X: 16.3500, Y: 48.2030}, Description: "Ruhige Lage"},
+		{ID: 4, District: tenth, Price: dto.PriceBreakdown{TotalMonthly: dto.EUR(900)}, Rooms: 2,
+			Location: &dto.Coordinates{X: 16.3760, Y: 48.1750}},
+		{ID: 5, District: eighth, Rooms: 4, Description: "Preis auf Anfrage"},
+	}
+}
+
+func ids(ls domain.ImmoListings) []uint64 {
+	res := make([]uint64, len(ls))
+	for i, l := range ls {
+		res[i] = l.ID
+	}
+	return res
+}
+
+func TestCompile(t *testing.T) {
+	for q, want := range map[string][]uint64{
+		`district in (7,8,9) and price <= 1400 and (near U6 within 400m or near tram within 200m) and not text ~ "befristet"`: {1},
+		"district in (VII, Josefstadt)":              {1, 2, 3, 5},
+		"district = 10 or rooms >= 4":                {4, 5},
+		"price < 1300":                               {1, 4},
+		"not price < 1300":                           {2, 3, 5},
+		"price != 1300":                              {1, 2, 4, 5},
+		"heating in (Fernwärme, Gasetagenheizung)":   {1, 2},
+		"energy <= D":                                {1},
+		"energy >= D":                                {2},
+		"pets":                                       {1},
+		"near 5 within 200m":                         {2},
+		"near rail within 200m":                      {1, 2},
+		"text ~ \"ALTBAU\"":                          {1},
+		"text ~ \"ruhige\" or text ~ 'preis auf'":    {3, 5},
+		"rooms in (3, 4) and not (district = 8)":     {2},
+		"(price <= 1000 or price >= 1350) and rooms": nil,
+	} {
+		f, err := Compile(q, testEnv())
+		if want == nil {
+			assert.Error(t, err, q)
+			continue
+		}
+		if assert.NoError(t, err, q) {
+			assert.Equal(t, want, ids(listings().ApplyFilter(f)), q)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for q, want := range map[string]Error{
+		"district in (7, 24)":          {17, "unknown district 24"},
+		"district = Atlantis":          {12, "unknown district Atlantis"},
+		"district = Wien":              {12, "Wien is all of Vienna, not a district"},
+		"district in (7, 0)":           {17, "0 is all of Vienna, not a district"},
+		"price <= cheap":               {10, "price needs a number, found cheap"},
+		"heating = coal-fired":         {11, "unknown heating coal-fired"},
+		"energy <= Z":                  {11, "unknown energy class Z"},
+		"neighbourhood = \"Nowhere\"":  {17, `unknown neighbourhood "Nowhere"`},
+		"pets and near U7 within 300m": {15, "unknown line or line type U7"},
+	} {
+		_, err := Compile(q, testEnv())
+		var perr *Error
+		if assert.True(t, errors.As(err, &perr), q) {
+			assert.Equal(t, want, *perr, q)
+		}
+	}
+
+	_, err := Compile("near U6", Env{})
+	assert.EqualError(t, err, "column 1: near needs the stops of the transit network")
+}
@@ -0,0 +1,162 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pos is a position in a query, as 1-based column counted in runes.
+type Pos int
+
+// Error is a syntax or compile error in a query, located at Pos.
+type Error struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos Pos, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber // a number, possibly directly followed by a unit like "400m" or "13A"
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokOp // comparison or match operator
+)
+
+type token struct {
+	kind tokenKind
+	text string // the token as written, without quotes for strings
+	pos  Pos
+	num  float64 // for tokNumber
+	unit string  // for tokNumber, the letters following the number
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// is reports whether the token is the given keyword, ignoring case.
+func (t token) is(keyword string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, keyword)
+}
+
+var operators = []string{"<=", ">=", "!=", "==", "<", ">", "=", "~"}
+
+// lex splits the query into tokens, ending with a tokEOF.
+func lex(q string) ([]token, error) {
+	var tokens []token
+	col := Pos(1)
+	for i := 0; i < len(q); {
+		r, size := utf8.DecodeRuneInString(q[i:])
+		start, startCol := i, col
+		next := func(n int) {
+			col += Pos(utf8.RuneCountInString(q[i : i+n]))
+			i += n
+		}
+		switch {
+		case unicode.IsSpace(r):
+			next(size)
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: col})
+			next(1)
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: col})
+			next(1)
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: col})
+			next(1)
+		case r == '"' || r == '\'':
+			text, n, err := lexString(q[i:], startCol)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: startCol})
+			next(n)
+		case unicode.IsDigit(r):
+			for i < len(q) && (q[i] >= '0' && q[i] <= '9' || q[i] == '.') {
+				next(1)
+			}
+			number := q[start:i]
+			for i < len(q) {
+				r, size := utf8.DecodeRuneInString(q[i:])
+				if !unicode.IsLetter(r) {
+					break
+				}
+				next(size)
+			}
+			num, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return nil, errorf(startCol, "invalid number %q", q[start:i])
+			}
+			// "1.400" is 1400 to a German reader and 1.4 to strconv
+			if dot := strings.IndexByte(number, '.'); dot >= 0 && len(number)-dot-1 == 3 {
+				return nil, errorf(startCol, "ambiguous number %q, write %s or %s", number,
+					strings.Replace(number, ".", "", 1), strings.TrimRight(strings.TrimRight(number, "0"), "."))
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: q[start:i], pos: startCol, num: num, unit: q[start+len(number) : i]})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(q) {
+				r, size := utf8.DecodeRuneInString(q[i:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+					break
+				}
+				next(size)
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: q[start:i], pos: startCol})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(q[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errorf(startCol, "unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: startCol})
+			next(len(op))
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: col}), nil
+}
+
+// lexString reads a string in double or single quotes with backslash escapes, returning its content and length in bytes.
+func lexString(q string, pos Pos) (string, int, error) {
+	quote := q[0]
+	var b strings.Builder
+	for i := 1; i < len(q); i++ {
+		switch q[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(q) {
+				i++
+			}
+		}
+		b.WriteByte(q[i])
+	}
+	return "", 0, errorf(pos, "unterminated string")
+}
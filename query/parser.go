@@ -0,0 +1,232 @@
+package query
+
+import (
+	"slices"
+	"strings"
+)
+
+const DefaultNearDistance = 500.0 // m, distance for near without within
+
+/*
+Parse parses a query into its syntax tree. The grammar is
+
+	expr      = and { "or" and }
+	and       = unary { "and" unary }
+	unary     = "not" unary | "(" expr ")" | predicate
+	predicate = field op value | field "in" "(" value { "," value } ")" | flag
+	          | "near" line [ "within" distance ]
+
+with the fields of the Field kinds, values as numbers, quoted strings or words, and distances
+like 400m or 1.5km. Keywords and field names are case-insensitive. Errors are of type *Error.
+*/
+func Parse(q string) (Expr, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, errorf(p.peek().pos, "empty query")
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.pos, "expected and, or or end of query, found %s", t)
+	}
+	return e, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	t := p.peek()
+	switch {
+	case t.is("not"):
+		p.advance()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{At: t.pos, X: x}, nil
+	case t.kind == tokLParen:
+		p.advance()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokRParen {
+			return nil, errorf(closing.pos, "expected ) to close ( at column %d, found %s", t.pos, closing)
+		}
+		return e, nil
+	case t.is("near"):
+		return p.parseNear()
+	case t.kind == tokIdent:
+		return p.parsePredicate()
+	}
+	return nil, errorf(t.pos, "expected field, near, not or (, found %s", t)
+}
+
+func (p *parser) parsePredicate() (Expr, error) {
+	t := p.advance()
+	field, ok := fields[strings.ToLower(t.text)]
+	if !ok {
+		return nil, errorf(t.pos, "unknown field %s", t)
+	}
+	if field.Kind == FlagField {
+		if op := p.peek(); op.kind == tokOp || op.is("in") {
+			return nil, errorf(op.pos, "%s is a flag and takes no operator; use %s or not %s", field.Name, field.Name, field.Name)
+		}
+		return &FlagExpr{At: t.pos, Field: field}, nil
+	}
+
+	op := p.advance()
+	if op.is("in") {
+		if field.Kind == TextField {
+			return nil, errorf(op.pos, "%s only supports ~", field.Name)
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &InExpr{At: t.pos, Field: field, Values: values}, nil
+	}
+	if op.kind != tokOp {
+		return nil, errorf(op.pos, "expected operator after %s, found %s", field.Name, op)
+	}
+	o := Op(op.text)
+	if o == "==" {
+		o = OpEq
+	}
+	switch {
+	case field.Kind == TextField && o != OpMatch:
+		return nil, errorf(op.pos, "%s only supports ~", field.Name)
+	case field.Kind != TextField && o == OpMatch:
+		return nil, errorf(op.pos, "~ is only supported by text")
+	case !field.ordered() && slices.Contains([]Op{OpLt, OpLe, OpGt, OpGe}, o):
+		return nil, errorf(op.pos, "%s cannot be compared with %s", field.Name, o)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &CompareExpr{At: t.pos, Field: field, Op: o, Value: value}, nil
+}
+
+func (p *parser) parseList() ([]Value, error) {
+	if open := p.advance(); open.kind != tokLParen {
+		return nil, errorf(open.pos, "expected ( after in, found %s", open)
+	}
+	var values []Value
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		switch t := p.advance(); t.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return values, nil
+		default:
+			return nil, errorf(t.pos, "expected , or ), found %s", t)
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokNumber:
+		if t.unit != "" {
+			return Value{}, errorf(t.pos, "unexpected unit in %s", t)
+		}
+		return Value{At: t.pos, Kind: NumberValue, Text: t.text, Number: t.num}, nil
+	case tokString:
+		return Value{At: t.pos, Kind: StringValue, Text: t.text}, nil
+	case tokIdent:
+		return Value{At: t.pos, Kind: IdentValue, Text: t.text}, nil
+	}
+	return Value{}, errorf(t.pos, "expected value, found %s", t)
+}
+
+func (p *parser) parseNear() (Expr, error) {
+	near := p.advance()
+	t := p.advance()
+	var line Value
+	switch t.kind {
+	case tokIdent, tokNumber: // U6, tram, 13A
+		line = Value{At: t.pos, Kind: IdentValue, Text: t.text}
+	case tokString:
+		line = Value{At: t.pos, Kind: StringValue, Text: t.text}
+	default:
+		return nil, errorf(t.pos, "expected line or line type after near, found %s", t)
+	}
+	e := &NearExpr{At: near.pos, Line: line, Distance: DefaultNearDistance}
+	if !p.peek().is("within") {
+		return e, nil
+	}
+	p.advance()
+	d := p.advance()
+	if d.kind != tokNumber {
+		return nil, errorf(d.pos, "expected distance like 400m after within, found %s", d)
+	}
+	switch strings.ToLower(d.unit) {
+	case "", "m":
+		e.Distance = d.num
+	case "km":
+		e.Distance = d.num * 1000
+	default:
+		return nil, errorf(d.pos, "unknown unit %q, use m or km", d.unit)
+	}
+	return e, nil
+}
@@ -0,0 +1,89 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	e, err := Parse(`district in (7,8,9) and price <= 1400 and (near U6 within 400m or near tram within 0.2km) and not text ~ "befristet"`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `district in (7, 8, 9) and price <= 1400 and (near U6 within 400m or near tram within 200m) and not text ~ "befristet"`, e.String())
+
+	and, ok := e.(*AndExpr)
+	if assert.True(t, ok) {
+		not, ok := and.Right.(*NotExpr)
+		if assert.True(t, ok) {
+			match := not.X.(*CompareExpr)
+			assert.Equal(t, OpMatch, match.Op)
+			assert.Equal(t, "befristet", match.Value.Text)
+			assert.Equal(t, Pos(95), not.At)
+		}
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	for q, want := range map[string]string{
+		"pets or cellar and lift":          "pets or cellar and lift",
+		"(pets or cellar) and lift":        "(pets or cellar) and lift",
+		"not pets and lift":                "not pets and lift",
+		"not (pets and lift)":              "not (pets and lift)",
+		"PRICE == 900 AND Rooms >= 2":      "price = 900 and rooms >= 2",
+		"near 13A":                         "near 13A within 500m",
+		"heating in (fernwärme, 'gas')":    `heating in (fernwärme, "gas")`,
+		"energy <= B and year < 1919":      "energy <= B and year < 1919",
+		"grätzl = \"Brunnenviertel\"":      `grätzl = "Brunnenviertel"`,
+		"district != Josefstadt or garden": "district != Josefstadt or garden",
+	} {
+		e, err := Parse(q)
+		if assert.NoError(t, err, q) {
+			assert.Equal(t, want, e.String(), q)
+			again, err := Parse(e.String())
+			assert.NoError(t, err, q)
+			assert.Equal(t, e.String(), again.String(), "formatting is stable: %s", q)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for q, want := range map[string]Error{
+		"":                             {1, "empty query"},
+		"price <=":                     {9, "expected value, found end of query"},
+		"prize <= 1400":                {1, `unknown field "prize"`},
+		"rooms >= 2 and":               {15, "expected field, near, not or (, found end of query"},
+		"(pets or lift":                {14, "expected ) to close ( at column 1, found end of query"},
+		"pets = true":                  {6, "pets is a flag and takes no operator; use pets or not pets"},
+		"heating < district":           {9, "heating cannot be compared with <"},
+		"text = \"x\"":                 {6, "text only supports ~"},
+		"price ~ 3":                    {7, "~ is only supported by text"},
+		"district in 7":                {13, "expected ( after in, found \"7\""},
+		"district in (7 8)":            {16, "expected , or ), found \"8\""},
+		"near U6 within 5 furlongs":    {18, "expected and, or or end of query, found \"furlongs\""},
+		"near U6 within 5mi":           {16, `unknown unit "mi", use m or km`},
+		"text ~ \"unterminated":        {8, "unterminated string"},
+		"price <= 1400 & rooms >= 2":   {15, "unexpected character '&'"},
+		"price <= 1400 rooms":          {15, `expected and, or or end of query, found "rooms"`},
+		"near":                         {5, "expected line or line type after near, found end of query"},
+		"pets and (lift or near U6) )": {28, `expected and, or or end of query, found ")"`},
+		"price <= 1.400":               {10, `ambiguous number "1.400", write 1400 or 1.4`},
+	} {
+		_, err := Parse(q)
+		var perr *Error
+		if assert.True(t, errors.As(err, &perr), q) {
+			assert.Equal(t, want, *perr, q)
+		}
+	}
+}
+
+func TestErrorPositionCountsRunes(t *testing.T) {
+	_, err := Parse(`text ~ "Grätzl" and größe > 3`)
+	var perr *Error
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, Pos(21), perr.Pos)
+		assert.Equal(t, `column 21: unknown field "größe"`, err.Error())
+	}
+}
@@ -38,6 +38,11 @@ const MinAreaField = "ESTATE_SIZE/LIVING_AREA_FROM"
 const MaxAreaField = "ESTATE_SIZE/LIVING_AREA_TO"
 const RoomsField = "NO_OF_ROOMS_BUCKET"
 const UpSellingField = "UPSELLING_AD_SEARCHRESULT"
+
+// attributes of adverts that are mapped to dto.Attributes by the adapter
+const FreeAreaTypeAttribute = "FREE_AREA_TYPE_NAME"
+const FreeAreaAttribute = "FREE_AREA/FREE_AREA_AREA_TOTAL"
+
 const Rooms1 = "1X1"
 const Rooms2 = "2X2"
 const Rooms3 = "3X3"
@@ -65,6 +70,7 @@ type WHAdvert struct {
 	PublishTime  *time.Time
 	Images       []url.URL
 	Upselling    bool
+	Attributes   map[string][]string // all attributes of the advert by name, including those parsed into fields
 }
 
 type WHAdvertMap map[uint64]WHAdvert
@@ -289,9 +295,13 @@ func (wam WHAdvertMap) parseAdvert(rawAd map[string]interface{}) (WHAdvertMap, e
 	//[]map[string]interface{})["attribute"]
 
 	adv.Title = rawAd["description"].(string)
+	adv.Attributes = make(map[string][]string)
 
 	for _, _a := range attrArr {
 		a := _a.(map[string]interface{})
+		if name, ok := a["name"].(string); ok {
+			adv.Attributes[name] = stringVals(a)
+		}
 		switch a["name"] {
 		case "BODY_DYN":
 			adv.Description = firstStringVal(a)
@@ -341,6 +351,14 @@ func (wam WHAdvertMap) parseAdvert(rawAd map[string]interface{}) (WHAdvertMap, e
 			} else {
 				adv.Area = &u
 			}
+		case "NUMBER_OF_ROOMS":
+			// adverts without a room count state 0, which is left unknown
+			f, err := strconv.ParseFloat(firstStringVal(a), 64)
+			if err != nil {
+				log.Println(err)
+			} else if f > 0 {
+				adv.Rooms = &f
+			}
 		case "FLOOR":
 			// FLOOR can also be something weird like "EG", "OG" or "DG", we map these to numeric floors for simplicity's sake.
 			switch firstStringVal(a) {
@@ -396,6 +414,18 @@ func firstStringVal(a map[string]interface{}) string {
 	return a["values"].([]interface{})[0].(string)
 }
 
+// stringVals returns all string values in the "values" key of an attribute, skipping values of other types.
+func stringVals(a map[string]interface{}) []string {
+	values, _ := a["values"].([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
 // toPointerType is a helper function that takes a value of any type and returns a pointer to it.
 func toPointerType[T any](t T) *T {
 	return &t
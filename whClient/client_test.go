@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestBullshit(t *testing.T) {
@@ -55,3 +56,28 @@ func TestBullshit(t *testing.T) {
 	}
 
 }
+
+func TestParseAdvertRooms(t *testing.T) {
+	rawAd := func(rooms string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":          "1287203409",
+			"description": "Gemeinde Wohnung Wien 21.(reserviert)",
+			"attributes": map[string]interface{}{
+				"attribute": []interface{}{
+					map[string]interface{}{"name": "NUMBER_OF_ROOMS", "values": []interface{}{rooms}},
+				},
+			},
+		}
+	}
+
+	wam, err := WHAdvertMap{}.parseAdvert(rawAd("2"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, wam[1287203409].Rooms) {
+		assert.Equal(t, 2.0, *wam[1287203409].Rooms)
+	}
+
+	// adverts without a room count state 0
+	wam, err = WHAdvertMap{}.parseAdvert(rawAd("0"))
+	assert.NoError(t, err)
+	assert.Nil(t, wam[1287203409].Rooms)
+}
@@ -0,0 +1,138 @@
+package wienclient
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	wlclient "github.com/ehganzlieb/willfahren/wlClient"
+)
+
+const (
+	wlDistrictsCSVURL = "https://data.wien.gv.at/daten/geo?service=WFS&request=GetFeature&version=1.1.0&typeName=ogdwien:BEZIRKSGRENZEOGD&srsName=EPSG:4326&outputFormat=csv"
+
+	DistrictNumberField = "BEZNR"
+	DistrictNameField   = "NAMEK"
+
+	polygonPrefix      = "POLYGON"
+	multiPolygonPrefix = "MULTIPOLYGON"
+)
+
+var (
+	DistrictsSchema  = wlclient.Schema{Dataset: "BEZIRKSGRENZEOGD", Required: []string{DistrictNumberField, wlclient.CoordsField}}
+	DistrictsDataset = wlclient.Dataset{
+		Name:     "BEZIRKSGRENZEOGD",
+		URL:      wlDistrictsCSVURL,
+		Comma:    ',',
+		Schema:   DistrictsSchema,
+		CRS:      dto.CRSWGS84,
+		Snapshot: snapshot("BEZIRKSGRENZEOGD"),
+	}
+)
+
+/*
+LoadDistrictBoundaries fetches and parses the district boundaries with the given loader, ready for dto.NewDistrictBoundaries.
+Without download or local copy, the snapshot embedded by go generate is used; if that is missing too, an error is returned.
+*/
+func LoadDistrictBoundaries(ctx context.Context, l *wlclient.Loader) (map[int]dto.MultiPolygon, wlclient.DatasetVersion, error) {
+	content, v, err := l.Fetch(ctx, DistrictsDataset)
+	if err != nil {
+		return nil, v, err
+	}
+	boundaries, err := ParseDistrictBoundaries(strings.NewReader(content), wlclient.ParseOptions{CRS: DistrictsDataset.CRS})
+	if err != nil {
+		return nil, v, fmt.Errorf("parsing %s: %w", DistrictsDataset.Name, err)
+	}
+	return boundaries, v, nil
+}
+
+// wktRing matches the innermost parentheses of a WKT geometry, which hold the coordinates of one ring.
+var wktRing = regexp.MustCompile(`\(([^()]*)\)`)
+
+/*
+ParseDistrictBoundaries parses the CSV of the OGD district boundary dataset (BEZIRKSGRENZEOGD) from r
+and returns the area of every district by its number, ready for dto.NewDistrictBoundaries.
+
+The CSV is expected to have the columns BEZNR and SHAPE, with SHAPE being a WKT or EWKT POLYGON or
+MULTIPOLYGON in opts.CRS. A district spread over several rows gets the rings of all of them.
+Parsing is strict regardless of opts.Mode, as a district with a missing part would swallow its neighbours' listings.
+*/
+func ParseDistrictBoundaries(r io.Reader, opts wlclient.ParseOptions) (map[int]dto.MultiPolygon, error) {
+	cr := csv.NewReader(r)
+
+	//get field names from first line
+	fieldNames, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	indexMap, err := DistrictsSchema.Index(fieldNames)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries := make(map[int]dto.MultiPolygon)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		number, err := strconv.Atoi(strings.TrimSpace(record[indexMap[DistrictNumberField]]))
+		if err != nil || number < 1 || number > 23 {
+			return nil, wlclient.ParseError{Line: line, Field: DistrictNumberField, Reason: fmt.Sprintf("invalid district number %q", record[indexMap[DistrictNumberField]])}
+		}
+		rings, err := parseWKTPolygons(record[indexMap[wlclient.CoordsField]], opts.CRS)
+		if err != nil {
+			return nil, wlclient.ParseError{Line: line, Field: wlclient.CoordsField, Reason: err.Error()}
+		}
+		boundaries[number] = append(boundaries[number], rings...)
+	}
+	return boundaries, nil
+}
+
+// parseWKTPolygons parses a WKT or EWKT POLYGON or MULTIPOLYGON in the given CRS into its rings in WGS84, holes included.
+func parseWKTPolygons(wkt string, crs dto.CRS) (dto.MultiPolygon, error) {
+	wkt, crs, err := wlclient.SplitEWKT(wkt, crs)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(wkt, multiPolygonPrefix) && !strings.HasPrefix(wkt, polygonPrefix) {
+		return nil, fmt.Errorf("unsupported geometry %q", wkt[:min(len(wkt), 20)])
+	}
+
+	rings := make(dto.MultiPolygon, 0)
+	for _, m := range wktRing.FindAllStringSubmatch(wkt, -1) {
+		ring := make(dto.Polygon, 0)
+		for _, point := range strings.Split(m[1], ",") {
+			fields := strings.Fields(point)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("malformed point %q", point)
+			}
+			x, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, err
+			}
+			y, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			ring = append(ring, dto.CRSCoordinates{X: x, Y: y, CRS: crs}.WGS84())
+		}
+		if len(ring) < 3 {
+			return nil, fmt.Errorf("ring with %d points", len(ring))
+		}
+		rings = append(rings, ring)
+	}
+	if len(rings) == 0 {
+		return nil, fmt.Errorf("empty polygon")
+	}
+	return rings, nil
+}
@@ -0,0 +1,32 @@
+package wienclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	wlclient "github.com/ehganzlieb/willfahren/wlClient"
+	"github.com/stretchr/testify/assert"
+)
+
+// simplified shapes, not the real district borders
+const testDistricts = `FID,SHAPE,NAMEK,BEZNR
+BEZIRKSGRENZEOGD.1,"POLYGON ((16.36 48.20, 16.38 48.20, 16.38 48.22, 16.36 48.22, 16.36 48.20))",Innere Stadt,1
+BEZIRKSGRENZEOGD.2,"MULTIPOLYGON (((16.38 48.20, 16.42 48.20, 16.42 48.24, 16.38 48.24, 16.38 48.20), (16.39 48.21, 16.40 48.21, 16.40 48.22, 16.39 48.22, 16.39 48.21)), ((16.50 48.20, 16.51 48.20, 16.51 48.21, 16.50 48.20)))",Leopoldstadt,2
+`
+
+func TestParseDistrictBoundaries(t *testing.T) {
+	boundaries, err := ParseDistrictBoundaries(strings.NewReader(testDistricts), wlclient.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, boundaries[1], 1)
+	assert.Len(t, boundaries[2], 3)
+	assert.True(t, boundaries[2].Contains(dto.Coordinates{X: 16.41, Y: 48.23}))
+	assert.False(t, boundaries[2].Contains(dto.Coordinates{X: 16.395, Y: 48.215}), "inside the hole")
+
+	_, err = ParseDistrictBoundaries(strings.NewReader("BEZNR,SHAPE\n24,\"POLYGON ((0 0, 1 0, 1 1))\"\n"), wlclient.ParseOptions{})
+	assert.Error(t, err)
+	_, err = ParseDistrictBoundaries(strings.NewReader("BEZNR,SHAPE\n1,\"POINT (0 0)\"\n"), wlclient.ParseOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package wienclient
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	wlclient "github.com/ehganzlieb/willfahren/wlClient"
+)
+
+const NeighbourhoodNameField = "NAME"
+
+var NeighbourhoodsSchema = wlclient.Schema{Dataset: "neighbourhoods", Required: []string{NeighbourhoodNameField, wlclient.CoordsField}}
+
+//go:embed neighbourhoods.csv
+var embeddedNeighbourhoods string
+
+/*
+EmbeddedNeighbourhoodAreas returns the areas of all neighbourhoods of dto.Neighbourhoods as embedded into the binary.
+They are drawn by hand along the streets, parks and tracks that are commonly taken as the borders of the Grätzl,
+and are approximate: a listing close to a border may be put into the wrong Grätzl or into none.
+*/
+func EmbeddedNeighbourhoodAreas() (*dto.NeighbourhoodAreas, error) {
+	areas, err := ParseNeighbourhoodAreas(strings.NewReader(embeddedNeighbourhoods), wlclient.ParseOptions{CRS: dto.CRSWGS84})
+	if err != nil {
+		return nil, fmt.Errorf("parsing embedded neighbourhoods: %w", err)
+	}
+	na, unknown := dto.NewNeighbourhoodAreas(areas)
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("embedded neighbourhoods %s are not in the catalogue", strings.Join(unknown, ", "))
+	}
+	return na, nil
+}
+
+/*
+ParseNeighbourhoodAreas parses a CSV with the columns NAME and SHAPE, a WKT or EWKT POLYGON or MULTIPOLYGON in opts.CRS,
+and returns the areas by neighbourhood name, ready for dto.NewNeighbourhoodAreas.
+There is no official dataset of Grätzl borders; EmbeddedNeighbourhoodAreas parses the hand-drawn ones
+shipped with the package.
+*/
+func ParseNeighbourhoodAreas(r io.Reader, opts wlclient.ParseOptions) (map[string]dto.MultiPolygon, error) {
+	cr := csv.NewReader(r)
+
+	//get field names from first line
+	fieldNames, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	indexMap, err := NeighbourhoodsSchema.Index(fieldNames)
+	if err != nil {
+		return nil, err
+	}
+
+	areas := make(map[string]dto.MultiPolygon)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		name := strings.TrimSpace(record[indexMap[NeighbourhoodNameField]])
+		if name == "" {
+			return nil, wlclient.ParseError{Line: line, Field: NeighbourhoodNameField, Reason: "empty neighbourhood name"}
+		}
+		rings, err := parseWKTPolygons(record[indexMap[wlclient.CoordsField]], opts.CRS)
+		if err != nil {
+			return nil, wlclient.ParseError{Line: line, Field: wlclient.CoordsField, Reason: err.Error()}
+		}
+		areas[name] = append(areas[name], rings...)
+	}
+	return areas, nil
+}
@@ -0,0 +1,55 @@
+package wienclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	wlclient "github.com/ehganzlieb/willfahren/wlClient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNeighbourhoodAreas(t *testing.T) {
+	areas, err := ParseNeighbourhoodAreas(strings.NewReader(`NAME,SHAPE
+Brunnenviertel,"POLYGON ((16.33 48.205, 16.34 48.205, 16.34 48.215, 16.33 48.215, 16.33 48.205))"
+Brunnenviertel,"POLYGON ((16.35 48.205, 16.36 48.205, 16.36 48.215, 16.35 48.205))"
+`), wlclient.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, areas["Brunnenviertel"], 2, "rows of the same name are merged")
+	assert.True(t, areas["Brunnenviertel"].Contains(dto.Coordinates{X: 16.3372, Y: 48.2108}))
+
+	_, err = ParseNeighbourhoodAreas(strings.NewReader("NAME,SHAPE\n,\"POLYGON ((0 0, 1 0, 1 1))\"\n"), wlclient.ParseOptions{})
+	assert.Error(t, err)
+}
+
+func TestEmbeddedNeighbourhoodAreas(t *testing.T) {
+	areas, err := EmbeddedNeighbourhoodAreas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string]dto.Coordinates{
+		"Brunnenviertel":    {X: 16.3365, Y: 48.2125}, // Yppenplatz
+		"Karmeliterviertel": {X: 16.3790, Y: 48.2165}, // Karmelitermarkt
+		"Nordbahnviertel":   {X: 16.3935, Y: 48.2275}, // Bednarpark
+		"Seestadt":          {X: 16.5070, Y: 48.2255}, // the lake
+		"Spittelberg":       {X: 16.3535, Y: 48.2025},
+	} {
+		n, err := areas.NeighbourhoodFromCoordinates(c)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, name, n.Name)
+		}
+	}
+	_, err = areas.NeighbourhoodFromCoordinates(dto.Coordinates{X: 16.3725, Y: 48.2085}) // Stephansplatz
+	assert.Error(t, err)
+
+	// every neighbourhood of the catalogue has an area
+	for _, n := range dto.Neighbourhoods() {
+		found := false
+		for _, line := range strings.Split(embeddedNeighbourhoods, "\n") {
+			found = found || strings.HasPrefix(line, n.Name+",")
+		}
+		assert.True(t, found, n.Name)
+	}
+}
@@ -0,0 +1,22 @@
+package wienclient
+
+import (
+	"embed"
+	"path"
+)
+
+//go:generate curl -fsSL -o snapshot/BEZIRKSGRENZEOGD.csv "https://data.wien.gv.at/daten/geo?service=WFS&request=GetFeature&version=1.1.0&typeName=ogdwien:BEZIRKSGRENZEOGD&srsName=EPSG:4326&outputFormat=csv"
+
+// snapshots are the copies of the datasets embedded as fallback, as downloaded by go generate.
+//
+//go:embed all:snapshot
+var snapshots embed.FS
+
+// snapshot returns the embedded copy of the dataset with the given name, or "" if there is none.
+func snapshot(name string) string {
+	b, err := snapshots.ReadFile(path.Join("snapshot", name+".csv"))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
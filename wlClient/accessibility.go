@@ -0,0 +1,80 @@
+package wlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	wlElevatorInfoURL = "https://www.wienerlinien.at/ogd_realtime/trafficInfoList?name=aufzugsinfo"
+
+	realtimeTimeFormat = "2006-01-02T15:04:05.000-0700"
+)
+
+// trafficInfoList is the part of the response of the realtime traffic info API that is used.
+type trafficInfoList struct {
+	Data struct {
+		TrafficInfos []struct {
+			Title        string   `json:"title"`
+			Description  string   `json:"description"`
+			RelatedLines []string `json:"relatedLines"`
+			Time         struct {
+				Start string `json:"start"`
+				End   string `json:"end"`
+			} `json:"time"`
+			Attributes struct {
+				Status  string `json:"status"`
+				Station string `json:"station"`
+			} `json:"attributes"`
+		} `json:"trafficInfos"`
+	} `json:"data"`
+}
+
+// ParseElevatorOutages parses the response of the realtime traffic info API for the category aufzugsinfo.
+func ParseElevatorOutages(r io.Reader) ([]dto.ElevatorOutage, error) {
+	var list trafficInfoList
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+	outages := make([]dto.ElevatorOutage, 0, len(list.Data.TrafficInfos))
+	for _, ti := range list.Data.TrafficInfos {
+		station := ti.Attributes.Station
+		if station == "" {
+			station = ti.Title
+		}
+		// unparsable times are left zero, the outage itself is still relevant
+		since, _ := time.Parse(realtimeTimeFormat, ti.Time.Start)
+		until, _ := time.Parse(realtimeTimeFormat, ti.Time.End)
+		outages = append(outages, dto.ElevatorOutage{
+			Station:     station,
+			Lines:       ti.RelatedLines,
+			Description: ti.Description,
+			Since:       since,
+			Until:       until,
+		})
+	}
+	return outages, nil
+}
+
+// FetchElevatorOutages requests the elevators currently out of service from the Wiener Linien realtime API.
+func FetchElevatorOutages(ctx context.Context, client *http.Client) ([]dto.ElevatorOutage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wlElevatorInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ParseElevatorOutages(resp.Body)
+}
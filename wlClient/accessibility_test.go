@@ -0,0 +1,26 @@
+package wlclient
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testElevatorInfo = `{"data":{"trafficInfos":[{"name":"a1","title":"Schottentor","description":"Aufzug Bahnsteig U2 außer Betrieb",
+"relatedLines":["U2"],"time":{"start":"2024-03-01T07:30:00.000+0100","end":""},
+"attributes":{"status":"außer Betrieb","station":"Schottentor"}}]},"message":{"value":"OK"}}`
+
+func TestParseElevatorOutages(t *testing.T) {
+	outages, err := ParseElevatorOutages(strings.NewReader(testElevatorInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, outages, 1) {
+		assert.Equal(t, "Schottentor", outages[0].Station)
+		assert.Equal(t, []string{"U2"}, outages[0].Lines)
+		assert.Equal(t, time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC), outages[0].Since.UTC())
+		assert.True(t, outages[0].Until.IsZero())
+	}
+}
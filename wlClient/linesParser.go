@@ -2,9 +2,9 @@ package wlclient
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"strings"
 
 	"github.com/ehganzlieb/willfahren/dto"
@@ -24,65 +24,140 @@ const (
 	NightGroupTaxiString = "Pt_RufbusNacht"
 )
 
+// lineTypes maps the known VERKEHRSMITTEL values to line types.
+var lineTypes = map[string]dto.LineType{
+	TramString:           dto.LineTypeTram,
+	UBahnString:          dto.LineTypeUBahn,
+	SBahnString:          dto.LineTypeSBahn,
+	BusString:            dto.LineTypeBus,
+	NightBusString:       dto.LineTypeNightBus,
+	GroupTaxiString:      dto.LineTypeGroupTaxi,
+	BadnerBahnString:     dto.LineTypeBadnerBahn,
+	NightGroupTaxiString: dto.LineTypeNightGroupTaxi,
+}
+
+// lineTypeHints guess the line type of unknown VERKEHRSMITTEL values, checked in order against the lowercased value.
+var lineTypeHints = []struct {
+	substring string
+	lineType  dto.LineType
+}{
+	{"rufbusnacht", dto.LineTypeNightGroupTaxi},
+	{"rufbus", dto.LineTypeGroupTaxi},
+	{"busnight", dto.LineTypeNightBus},
+	{"bus", dto.LineTypeBus},
+	{"tram", dto.LineTypeTram},
+	{"metro", dto.LineTypeUBahn},
+	{"trains", dto.LineTypeSBahn},
+	{"badner", dto.LineTypeBadnerBahn},
+}
+
+/*
+ParseLineType maps a VERKEHRSMITTEL value to a line type. Values that are not known exactly,
+e.g. because the Wiener Linien introduced a new one, are guessed from their name, and if that
+fails mapped to dto.LineTypeOther. ok is false if the value was not known exactly.
+*/
+func ParseLineType(s string) (lt dto.LineType, ok bool) {
+	if lt, ok := lineTypes[strings.TrimSpace(s)]; ok {
+		return lt, true
+	}
+	lower := strings.ToLower(s)
+	for _, h := range lineTypeHints {
+		if strings.Contains(lower, h.substring) {
+			return h.lineType, false
+		}
+	}
+	return dto.LineTypeOther, false
+}
+
 /*
 ParseLinesCSV parses a CSV string and returns a slice of dto.Lines.
 
-The CSV string is expected to have the columns BEZEICHNUNG and VERKEHRSMITTEL, and to contain lines with the values ptTram, ptMetro, ptTrainS, ptBusCity, ptBusNight, pt_RufbusTag, ptBadner_Bahn, and pt_RufbusNacht for VERKEHRSMITTEL.
-The function will return an error if the CSV string is malformed, or if a line contains an unknown VERKEHRSMITTEL value.
+The CSV string is expected to have the columns BEZEICHNUNG and VERKEHRSMITTEL, or LineText and MeansOfTransport
+in the English version of the header (see LinesSchema), and to contain lines with the values ptTram, ptMetro, ptTrainS, ptBusCity, ptBusNight, pt_RufbusTag, ptBadner_Bahn, and pt_RufbusNacht for VERKEHRSMITTEL.
+Unknown VERKEHRSMITTEL values are mapped by ParseLineType and logged, invalid records are skipped and logged.
+The function will return an error if the header is missing one of the columns.
 The returned slice of dto.Lines will contain the parsed lines, and will not contain the stops as they are not contained in this file.
 */
 func ParseLinesCSV(input string) ([]dto.Line, error) {
-	r := csv.NewReader(strings.NewReader(input))
-	r.Comma = ';'
+	lines, report, err := ParseLines(strings.NewReader(input), ParseOptions{Mode: ParseLenient})
+	if err != nil {
+		return nil, err
+	}
+	logReport(LinesSchema.Dataset, report)
+	return lines, nil
+}
+
+/*
+ParseLines parses the lines CSV from r, which is separated by semicolons.
+Unknown VERKEHRSMITTEL values do not abort parsing, they are mapped by ParseLineType and reported as warnings.
+Records without a name or that cannot be read are reported as errors; in strict mode the first of them is returned.
+*/
+func ParseLines(r io.Reader, opts ParseOptions) ([]dto.Line, *ParseReport, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = ';'
+	cr.FieldsPerRecord = -1
+	report := &ParseReport{}
 
 	//get field names from first line
-	fieldNames, err := r.Read()
+	fieldNames, err := cr.Read()
 	if err != nil {
-		return nil, err
+		return nil, report, err
 	}
 
-	indexMap := make(map[string]int)
-	for i, v := range fieldNames {
-		indexMap[v] = i
+	indexMap, err := LinesSchema.Index(fieldNames)
+	if err != nil {
+		return nil, report, err
 	}
 
 	lines := make([]dto.Line, 0)
 
 	//parse all lines
 	for {
-		record, err := r.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				log.Print(err)
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var pe *csv.ParseError
+		if err != nil && !errors.As(err, &pe) {
+			return nil, report, err
+		}
+		report.Records++
+
+		var perr *ParseError
+		switch {
+		case pe != nil:
+			perr = &ParseError{Line: pe.Line, Reason: "malformed CSV record"}
+		case len(record) <= max(indexMap[LineNameField], indexMap[LineTypeField]):
+			line, _ := cr.FieldPos(0)
+			perr = &ParseError{Line: line, Reason: fmt.Sprintf("record has %d fields, header has %d", len(record), len(fieldNames))}
+		case strings.TrimSpace(record[indexMap[LineNameField]]) == "":
+			line, _ := cr.FieldPos(indexMap[LineNameField])
+			perr = &ParseError{Line: line, Field: LineNameField, Reason: "empty line name"}
+		}
+		if perr != nil {
+			report.Skipped++
+			report.Errors = append(report.Errors, *perr)
+			if opts.Mode == ParseStrict {
+				return nil, report, *perr
 			}
+			continue
 		}
+
 		l := dto.Line{
-			Name: record[indexMap[LineNameField]],
+			Name: strings.TrimSpace(record[indexMap[LineNameField]]),
 		}
-		switch record[indexMap[LineTypeField]] {
-		case TramString:
-			l.Type = dto.LineTypeTram
-		case UBahnString:
-			l.Type = dto.LineTypeUBahn
-		case SBahnString:
-			l.Type = dto.LineTypeSBahn
-		case BusString:
-			l.Type = dto.LineTypeBus
-		case NightBusString:
-			l.Type = dto.LineTypeNightBus
-		case GroupTaxiString:
-			l.Type = dto.LineTypeGroupTaxi
-		case BadnerBahnString:
-			l.Type = dto.LineTypeBadnerBahn
-		case NightGroupTaxiString:
-			l.Type = dto.LineTypeNightGroupTaxi
-		default:
-			return nil, fmt.Errorf("unknown line type %s", record[indexMap[LineTypeField]])
+		typeString := record[indexMap[LineTypeField]]
+		var known bool
+		if l.Type, known = ParseLineType(typeString); !known {
+			line, _ := cr.FieldPos(indexMap[LineTypeField])
+			report.Warnings = append(report.Warnings, ParseError{
+				Line:   line,
+				Field:  LineTypeField,
+				Reason: fmt.Sprintf("unknown value %q for line %s, mapped to %s", typeString, l.Name, l.Type),
+			})
 		}
 		lines = append(lines, l)
 	}
-	return lines, nil
+	return lines, report, nil
 
 }
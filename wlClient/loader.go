@@ -0,0 +1,286 @@
+package wlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	DefaultKeepVersions = 5 // number of local copies kept per dataset
+
+	metaFileName      = "meta.json"
+	versionTimeFormat = "20060102T150405Z"
+	maxDatasetSize    = 64 * 1024 * 1024
+)
+
+// Source tells where the content returned by Loader.Fetch comes from.
+type Source int
+
+const (
+	SourceDownload    Source = iota // freshly downloaded
+	SourceNotModified               // the server confirmed that the local copy is current
+	SourceLocalCopy                 // the download failed, the latest local copy is used
+	SourceSnapshot                  // neither download nor local copy available, the embedded snapshot is used
+)
+
+func (s Source) String() string {
+	return []string{"download", "not modified", "local copy", "snapshot"}[s]
+}
+
+// DatasetVersion describes the local copy of a dataset and the validators needed for conditional requests.
+type DatasetVersion struct {
+	File         string    `json:"file"` // name of the version file within the dataset directory
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+	Schema       string    `json:"schema,omitempty"` // name of the SchemaVersion the header matched, if the schema has versions
+	Source       Source    `json:"-"`
+}
+
+/*
+Loader downloads OGD datasets and keeps versioned local copies of them.
+
+Downloads are conditional (If-None-Match / If-Modified-Since), so unchanged datasets are not
+transferred again. Every new download is checked against the dataset's schema before it is
+stored as a new version in Dir/<dataset name>/. If the download fails, the latest local copy is
+used, and if there is none, the snapshot embedded into the binary.
+*/
+type Loader struct {
+	Client       *http.Client
+	Dir          string
+	KeepVersions int
+}
+
+// NewLoader returns a Loader storing its copies in dir, using http.DefaultClient and DefaultKeepVersions.
+func NewLoader(dir string) *Loader {
+	return &Loader{Client: http.DefaultClient, Dir: dir, KeepVersions: DefaultKeepVersions}
+}
+
+func (l *Loader) datasetDir(ds Dataset) string {
+	return filepath.Join(l.Dir, ds.Name)
+}
+
+// current returns the version of the latest local copy of the dataset, or nil if there is none.
+func (l *Loader) current(ds Dataset) *DatasetVersion {
+	b, err := os.ReadFile(filepath.Join(l.datasetDir(ds), metaFileName))
+	if err != nil {
+		return nil
+	}
+	var v DatasetVersion
+	if err := json.Unmarshal(b, &v); err != nil {
+		log.Printf("ignoring corrupt metadata of %s: %v", ds.Name, err)
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(l.datasetDir(ds), v.File)); err != nil {
+		return nil
+	}
+	return &v
+}
+
+func (l *Loader) readVersion(ds Dataset, v *DatasetVersion) (string, error) {
+	b, err := os.ReadFile(filepath.Join(l.datasetDir(ds), v.File))
+	return string(b), err
+}
+
+/*
+Fetch returns the content of the dataset and the version it comes from.
+Errors of the download are logged and lead to the fallbacks described at Loader; an error is
+only returned if no content is available at all.
+*/
+func (l *Loader) Fetch(ctx context.Context, ds Dataset) (string, DatasetVersion, error) {
+	current := l.current(ds)
+
+	content, version, err := l.download(ctx, ds, current)
+	if err == nil {
+		return content, version, nil
+	}
+	log.Printf("download of %s failed: %v", ds.Name, err)
+
+	if current != nil {
+		content, rerr := l.readVersion(ds, current)
+		if rerr == nil {
+			current.Source = SourceLocalCopy
+			return content, *current, nil
+		}
+		log.Printf("reading local copy of %s failed: %v", ds.Name, rerr)
+	}
+	if ds.Snapshot != "" {
+		return ds.Snapshot, DatasetVersion{Source: SourceSnapshot}, nil
+	}
+	return "", DatasetVersion{}, fmt.Errorf("no data available for %s: %w", ds.Name, err)
+}
+
+// download performs the conditional request and stores a new version if the dataset changed.
+func (l *Loader) download(ctx context.Context, ds Dataset, current *DatasetVersion) (string, DatasetVersion, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ds.URL, nil)
+	if err != nil {
+		return "", DatasetVersion{}, err
+	}
+	if current != nil {
+		if current.ETag != "" {
+			req.Header.Set("If-None-Match", current.ETag)
+		}
+		if current.LastModified != "" {
+			req.Header.Set("If-Modified-Since", current.LastModified)
+		}
+	}
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return "", DatasetVersion{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && current != nil:
+		content, err := l.readVersion(ds, current)
+		if err != nil {
+			return "", DatasetVersion{}, err
+		}
+		current.Source = SourceNotModified
+		return content, *current, nil
+	case resp.StatusCode != http.StatusOK:
+		return "", DatasetVersion{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxDatasetSize+1))
+	if err != nil {
+		return "", DatasetVersion{}, err
+	}
+	if len(b) > maxDatasetSize {
+		return "", DatasetVersion{}, fmt.Errorf("dataset larger than %d bytes", maxDatasetSize)
+	}
+	content := string(b)
+	schema, err := validateHeader(content, ds)
+	if err != nil {
+		return "", DatasetVersion{}, err
+	}
+
+	version := DatasetVersion{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now().UTC(),
+		Schema:       schema.Name,
+		Source:       SourceDownload,
+	}
+	if err := l.store(ds, content, &version); err != nil {
+		// the content is fine, it just cannot be cached
+		log.Printf("storing %s failed: %v", ds.Name, err)
+	}
+	return content, version, nil
+}
+
+// validateHeader checks that the header of content has the columns of the dataset's schema and returns the matched version.
+func validateHeader(content string, ds Dataset) (SchemaVersion, error) {
+	return ds.Schema.ValidateHeader(content, ds.Comma)
+}
+
+// store writes content as a new version, updates the metadata and removes versions beyond KeepVersions.
+func (l *Loader) store(ds Dataset, content string, version *DatasetVersion) error {
+	dir := l.datasetDir(ds)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	version.File = version.Fetched.Format(versionTimeFormat) + ".csv"
+	if err := writeFileAtomic(filepath.Join(dir, version.File), []byte(content)); err != nil {
+		return err
+	}
+	meta, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, metaFileName), meta); err != nil {
+		return err
+	}
+	return l.prune(dir)
+}
+
+// prune removes all but the newest KeepVersions version files in dir.
+func (l *Loader) prune(dir string) error {
+	if l.KeepVersions <= 0 {
+		return nil
+	}
+	versions, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return err
+	}
+	// version file names sort chronologically
+	slices.Sort(versions)
+	var errs []error
+	for _, v := range versions[:max(0, len(versions)-l.KeepVersions)] {
+		errs = append(errs, os.Remove(v))
+	}
+	return errors.Join(errs...)
+}
+
+// writeFileAtomic writes to a temporary file first so that readers never see partial content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Network is the parsed transit network together with the versions of the datasets it was built from.
+type Network struct {
+	Lines    []dto.Line
+	Stops    map[dto.Line][]*dto.Stop
+	Routes   map[string]dto.LineRoutes // by line name, empty if the line shapes are unavailable
+	Versions map[string]DatasetVersion // by dataset name
+}
+
+/*
+LoadNetwork fetches the lines, stops and line shapes and builds the transit network from them.
+The line shapes are optional: if they cannot be fetched or parsed, the lines have no routes.
+*/
+func (l *Loader) LoadNetwork(ctx context.Context) (*Network, error) {
+	n := &Network{Routes: make(map[string]dto.LineRoutes), Versions: make(map[string]DatasetVersion)}
+
+	linesCSV, v, err := l.Fetch(ctx, LinesDataset)
+	if err != nil {
+		return nil, err
+	}
+	n.Versions[LinesDataset.Name] = v
+	if n.Lines, err = ParseLinesCSV(linesCSV); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", LinesDataset.Name, err)
+	}
+
+	stopsCSV, v, err := l.Fetch(ctx, StopsDataset)
+	if err != nil {
+		return nil, err
+	}
+	n.Versions[StopsDataset.Name] = v
+	stops, report, err := ParseStops(strings.NewReader(stopsCSV), ParseOptions{CRS: StopsDataset.CRS})
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", StopsDataset.Name, err)
+	}
+	logReport(StopsDataset.Name, report)
+	n.Stops = AggregateStops(stops, n.Lines)
+
+	shapesCSV, v, err := l.Fetch(ctx, LineShapesDataset)
+	if err != nil {
+		log.Printf("lines will have no routes: %v", err)
+		return n, nil
+	}
+	n.Versions[LineShapesDataset.Name] = v
+	shapes, err := ParseLineShapes(strings.NewReader(shapesCSV), ParseOptions{CRS: LineShapesDataset.CRS})
+	if err != nil {
+		log.Printf("lines will have no routes: parsing %s: %v", LineShapesDataset.Name, err)
+		return n, nil
+	}
+	n.Routes = BuildRoutes(n.Stops, shapes)
+	return n, nil
+}
@@ -0,0 +1,65 @@
+package wlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLoaderCSV = "\"LINIEN_ID\";\"BEZEICHNUNG\";\"VERKEHRSMITTEL\"\n1;\"U6\";\"ptMetro\"\n"
+
+func TestLoaderFetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testLoaderCSV))
+	}))
+
+	ds := LinesDataset
+	ds.URL = server.URL
+	l := NewLoader(t.TempDir())
+
+	content, v, err := l.Fetch(context.Background(), ds)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceDownload, v.Source)
+	assert.Equal(t, "German", v.Schema)
+	assert.Equal(t, testLoaderCSV, content)
+
+	content, v, err = l.Fetch(context.Background(), ds)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceNotModified, v.Source)
+	assert.Equal(t, testLoaderCSV, content)
+	assert.Equal(t, 2, requests)
+
+	server.Close()
+	content, v, err = l.Fetch(context.Background(), ds)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceLocalCopy, v.Source)
+	assert.Equal(t, testLoaderCSV, content)
+
+	content, v, err = NewLoader(t.TempDir()).Fetch(context.Background(), ds)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceSnapshot, v.Source)
+	assert.Equal(t, snapshotLines, content)
+}
+
+func TestLoaderRejectsInvalidHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>maintenance</html>\n"))
+	}))
+	defer server.Close()
+
+	ds := LinesDataset
+	ds.URL = server.URL
+	_, v, err := NewLoader(t.TempDir()).Fetch(context.Background(), ds)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceSnapshot, v.Source)
+}
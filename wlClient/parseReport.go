@@ -0,0 +1,65 @@
+package wlclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+// ParseMode decides how parsers react to invalid records.
+type ParseMode int
+
+const (
+	ParseLenient ParseMode = iota // invalid records are skipped and reported
+	ParseStrict                   // the first invalid record aborts parsing
+)
+
+// ParseOptions configure the streaming parsers.
+type ParseOptions struct {
+	Mode    ParseMode
+	Workers int     // number of concurrent workers, runtime.NumCPU() if not positive
+	CRS     dto.CRS // of the coordinates in the input unless given per geometry as EWKT (SRID=31256;POINT ...), WGS84 by default
+}
+
+// ParseError describes why a record of a CSV file could not be parsed.
+type ParseError struct {
+	Line   int    // line number in the file, starting at 1 with the header
+	Field  string // name of the offending column, empty if the whole record is affected
+	Reason string
+}
+
+func (e ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+	}
+	return fmt.Sprintf("line %d, field %s: %s", e.Line, e.Field, e.Reason)
+}
+
+// ParseReport summarizes a parse run.
+type ParseReport struct {
+	Records  int // number of records read, not counting the header
+	Skipped  int // number of records that were skipped because of errors
+	Errors   []ParseError
+	Warnings []ParseError // problems of records that were kept, e.g. unknown values mapped to a fallback
+}
+
+// Err returns all errors of the report joined into one, or nil if there were none.
+func (r *ParseReport) Err() error {
+	errs := make([]error, len(r.Errors))
+	for i, e := range r.Errors {
+		errs[i] = e
+	}
+	return errors.Join(errs...)
+}
+
+// logReport logs the warnings and a summary of the errors of a lenient parse run.
+func logReport(dataset string, report *ParseReport) {
+	for _, w := range report.Warnings {
+		log.Printf("%s: %v", dataset, w)
+	}
+	if len(report.Errors) > 0 {
+		log.Printf("%s: skipped %d of %d records, first error: %v", dataset, report.Skipped, report.Records, report.Errors[0])
+	}
+}
@@ -0,0 +1,257 @@
+package wlclient
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const (
+	LineShapeNameField = "LBEZEICHNUNG"
+
+	DirectionOverlap = 40.0 // m, a part whose middle lies this close to a chain runs alongside it, i.e. in the other direction
+
+	lineStringPrefix      = "LINESTRING"
+	multiLineStringPrefix = "MULTILINESTRING"
+)
+
+// LineShape is the geometry of a line, one polyline per direction. Backward is nil if the data has only one.
+type LineShape struct {
+	Forward, Backward []dto.Coordinates
+}
+
+/*
+ParseLineShapesCSV parses the CSV of the OGD line shape dataset (OEFFLINIENOGD) and returns the route geometry per line name.
+
+The CSV string is expected to have the columns LBEZEICHNUNG and SHAPE, with SHAPE being a WKT LINESTRING or MULTILINESTRING
+in WGS84. A line may be split over several rows and parts, these are chained into one polyline per direction (see chainDirections).
+The function will return an error if the CSV string is malformed or a shape cannot be parsed.
+*/
+func ParseLineShapesCSV(input string) (map[string]LineShape, error) {
+	return ParseLineShapes(strings.NewReader(input), ParseOptions{CRS: dto.CRSWGS84})
+}
+
+/*
+ParseLineShapes is ParseLineShapesCSV for a reader and shapes in any CRS, which are converted from opts.CRS
+to WGS84. Parsing is strict regardless of opts.Mode, as a route with a missing part would be misleading.
+*/
+func ParseLineShapes(r io.Reader, opts ParseOptions) (map[string]LineShape, error) {
+	cr := csv.NewReader(r)
+
+	//get field names from first line
+	fieldNames, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	indexMap, err := LineShapesSchema.Index(fieldNames)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make(map[string][][]dto.Coordinates)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(record[indexMap[LineShapeNameField]])
+		lineParts, err := parseWKTLines(record[indexMap[CoordsField]], opts.CRS)
+		if err != nil {
+			return nil, fmt.Errorf("line %s: %w", name, err)
+		}
+		parts[name] = append(parts[name], lineParts...)
+	}
+
+	shapes := make(map[string]LineShape)
+	for name, p := range parts {
+		shapes[name] = chainDirections(p)
+	}
+	return shapes, nil
+}
+
+/*
+SplitEWKT separates an optional EWKT SRID prefix like "SRID=31256;" from a WKT geometry and returns
+the geometry with the CRS it is given in, which is crs if there is no prefix.
+*/
+func SplitEWKT(wkt string, crs dto.CRS) (string, dto.CRS, error) {
+	wkt = strings.TrimSpace(wkt)
+	prefix, geometry, ok := strings.Cut(wkt, ";")
+	if !ok || !strings.HasPrefix(strings.ToUpper(prefix), "SRID=") {
+		return wkt, crs, nil
+	}
+	crs, err := dto.ParseCRS(prefix[len("SRID="):])
+	return strings.TrimSpace(geometry), crs, err
+}
+
+// parseWKTLines parses a WKT or EWKT LINESTRING or MULTILINESTRING in the given CRS into its parts in WGS84.
+func parseWKTLines(wkt string, crs dto.CRS) ([][]dto.Coordinates, error) {
+	wkt, crs, err := SplitEWKT(wkt, crs)
+	if err != nil {
+		return nil, err
+	}
+	var body string
+	switch {
+	case strings.HasPrefix(wkt, multiLineStringPrefix):
+		body = strings.TrimSpace(strings.TrimPrefix(wkt, multiLineStringPrefix))
+		if !strings.HasPrefix(body, "((") || !strings.HasSuffix(body, "))") {
+			return nil, fmt.Errorf("malformed %s", multiLineStringPrefix)
+		}
+		body = body[1 : len(body)-1]
+	case strings.HasPrefix(wkt, lineStringPrefix):
+		body = strings.TrimSpace(strings.TrimPrefix(wkt, lineStringPrefix))
+	default:
+		return nil, fmt.Errorf("unsupported geometry %q", wkt[:min(len(wkt), 20)])
+	}
+
+	parts := make([][]dto.Coordinates, 0)
+	for _, part := range strings.Split(body, "),") {
+		part = strings.Trim(strings.TrimSpace(part), "()")
+		coords := make([]dto.Coordinates, 0)
+		for _, point := range strings.Split(part, ",") {
+			fields := strings.Fields(point)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("malformed point %q", point)
+			}
+			x, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, err
+			}
+			y, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			coords = append(coords, dto.CRSCoordinates{X: x, Y: y, CRS: crs}.WGS84())
+		}
+		parts = append(parts, coords)
+	}
+	return parts, nil
+}
+
+/*
+chainDirections chains the parts of a line into one polyline per direction. The first chain starts
+with the longest part and grows by the parts that continue it (see chainParts); parts running
+alongside it belong to the other direction and are chained into the second one, which is oriented
+against the first. Further chains, e.g. of branches, are dropped.
+*/
+func chainDirections(parts [][]dto.Coordinates) LineShape {
+	forward, rest := chainParts(parts)
+	if len(rest) == 0 {
+		return LineShape{Forward: forward}
+	}
+	backward, _ := chainParts(rest)
+	if len(backward) > 0 && backward[0].HaversineDistance(forward[0]) < backward[0].HaversineDistance(forward[len(forward)-1]) {
+		slices.Reverse(backward)
+	}
+	return LineShape{Forward: forward, Backward: backward}
+}
+
+/*
+chainParts joins parts of a line into a single polyline and returns the parts it did not use.
+Starting with the longest part, it repeatedly appends or prepends the remaining part whose end is
+closest to either end of the chain, reversing it if necessary. Parts whose middle lies within
+DirectionOverlap of the chain are not joined, as they run alongside it in the other direction;
+joining them would fold the chain back onto itself.
+*/
+func chainParts(parts [][]dto.Coordinates) ([]dto.Coordinates, [][]dto.Coordinates) {
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	remaining := slices.Clone(parts)
+	longest := 0
+	for i, p := range remaining {
+		if len(p) > len(remaining[longest]) {
+			longest = i
+		}
+	}
+	chain := slices.Clone(remaining[longest])
+	remaining = slices.Delete(remaining, longest, longest+1)
+
+	var other [][]dto.Coordinates
+	for len(remaining) > 0 {
+		best, bestDistance := 0, -1.0
+		var prepend, reverse bool
+		for i, p := range remaining {
+			candidates := []struct {
+				d                float64
+				prepend, reverse bool
+			}{
+				{chain[len(chain)-1].HaversineDistance(p[0]), false, false},
+				{chain[len(chain)-1].HaversineDistance(p[len(p)-1]), false, true},
+				{chain[0].HaversineDistance(p[len(p)-1]), true, false},
+				{chain[0].HaversineDistance(p[0]), true, true},
+			}
+			for _, c := range candidates {
+				if bestDistance < 0 || c.d < bestDistance {
+					best, bestDistance, prepend, reverse = i, c.d, c.prepend, c.reverse
+				}
+			}
+		}
+		part := slices.Clone(remaining[best])
+		remaining = slices.Delete(remaining, best, best+1)
+		if alongside(chain, part) {
+			other = append(other, part)
+			continue
+		}
+		if reverse {
+			slices.Reverse(part)
+		}
+		if prepend {
+			chain = append(part, chain...)
+		} else {
+			chain = append(chain, part...)
+		}
+	}
+	return chain, other
+}
+
+// alongside reports whether the middle of the part lies within DirectionOverlap of the chain.
+func alongside(chain, part []dto.Coordinates) bool {
+	if len(chain) < 2 {
+		return false
+	}
+	_, d := dto.ProjectOnPolyline(chain, polylineMiddle(part))
+	return d <= DirectionOverlap
+}
+
+// polylineMiddle returns the point halfway along the polyline.
+func polylineMiddle(p []dto.Coordinates) dto.Coordinates {
+	r := dto.Route{Shape: p}
+	half := r.Length() / 2
+	for i := 1; i < len(p); i++ {
+		d := p[i-1].HaversineDistance(p[i])
+		if d >= half && d > 0 {
+			t := half / d
+			return dto.Coordinates{X: p[i-1].X + t*(p[i].X-p[i-1].X), Y: p[i-1].Y + t*(p[i].Y-p[i-1].Y)}
+		}
+		half -= d
+	}
+	return p[len(p)-1]
+}
+
+/*
+BuildRoutes orders the stops of every line along its shape and returns the routes of both
+directions by line name. stopMap is the map returned by AggregateStops, shapes the result of
+ParseLineShapesCSV. Lines without a shape have no routes.
+*/
+func BuildRoutes(stopMap map[dto.Line][]*dto.Stop, shapes map[string]LineShape) map[string]dto.LineRoutes {
+	routes := make(map[string]dto.LineRoutes)
+	for line, stops := range stopMap {
+		shape, ok := shapes[line.Name]
+		if !ok {
+			continue
+		}
+		if r := dto.NewRoutes(shape.Forward, shape.Backward, stops); r != nil {
+			routes[line.Name] = r
+		}
+	}
+	return routes
+}
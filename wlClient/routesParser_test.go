@@ -0,0 +1,116 @@
+package wlclient
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+// T1 runs from 16.3 to 16.33 along 48.2, split into parts given in either orientation.
+// The backward direction is drawn separately, about 10 m to the south, from 16.33 back to 16.3.
+const testShapes = `FID,OBJECTID,SHAPE,LBEZEICHNUNG
+OEFFLINIENOGD.1,1,"MULTILINESTRING ((16.3 48.2, 16.31 48.2), (16.33 48.2, 16.32 48.2))",T1
+OEFFLINIENOGD.2,2,"LINESTRING (16.31 48.2, 16.32 48.2)",T1
+OEFFLINIENOGD.3,3,"LINESTRING (16.33 48.1999, 16.3 48.1999)",T1
+OEFFLINIENOGD.4,4,"LINESTRING (16.4 48.3, 16.41 48.3)",T2
+`
+
+func TestParseLineShapes(t *testing.T) {
+	shapes, err := ParseLineShapesCSV(testShapes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := shapes["T1"]
+	assert.Len(t, t1.Forward, 6)
+	assert.Equal(t, dto.Coordinates{X: 16.3, Y: 48.2}, t1.Forward[0])
+	assert.Equal(t, dto.Coordinates{X: 16.33, Y: 48.2}, t1.Forward[5])
+	// the backward direction is not folded into the forward one
+	assert.Equal(t, []dto.Coordinates{{X: 16.33, Y: 48.1999}, {X: 16.3, Y: 48.1999}}, t1.Backward)
+
+	assert.Len(t, shapes["T2"].Forward, 2)
+	assert.Nil(t, shapes["T2"].Backward)
+}
+
+func TestChainDirectionsOrientsBackward(t *testing.T) {
+	// the backward direction given in the forward orientation is reversed
+	shape := chainDirections([][]dto.Coordinates{
+		{{X: 16.3, Y: 48.2}, {X: 16.31, Y: 48.2}, {X: 16.32, Y: 48.2}},
+		{{X: 16.3, Y: 48.1999}, {X: 16.32, Y: 48.1999}},
+	})
+	assert.Equal(t, []dto.Coordinates{{X: 16.32, Y: 48.1999}, {X: 16.3, Y: 48.1999}}, shape.Backward)
+}
+
+func TestBuildRoutes(t *testing.T) {
+	shapes, err := ParseLineShapesCSV(testShapes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []dto.Line{{Name: "T1", Type: dto.LineTypeTram}, {Name: "T3", Type: dto.LineTypeTram}}
+	stops := []*Stop{
+		{Name: "C", Location: dto.Coordinates{X: 16.32, Y: 48.2001}, Lines: []string{"T1"}},
+		{Name: "A", Location: dto.Coordinates{X: 16.3, Y: 48.2001}, Lines: []string{"T1"}},
+		{Name: "D", Location: dto.Coordinates{X: 16.33, Y: 48.1999}, Lines: []string{"T1"}},
+		{Name: "B", Location: dto.Coordinates{X: 16.31, Y: 48.1999}, Lines: []string{"T1"}},
+		{Name: "X", Location: dto.Coordinates{X: 16.5, Y: 48.1}, Lines: []string{"T3"}},
+	}
+	stopMap := AggregateStops(stops, lines)
+	routes := BuildRoutes(stopMap, shapes)
+	assert.NotContains(t, routes, "T3", "line without shape")
+
+	// the lines still work as map keys
+	assert.Len(t, stopMap[dto.Line{Name: "T1", Type: dto.LineTypeTram}], 4)
+
+	lr := routes["T1"]
+	stopNames := func(r *dto.Route) []string {
+		names := make([]string, 0)
+		for _, s := range r.Stops {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+	forward, ok := lr.Route(dto.DirectionForward)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, []string{"A", "B", "C", "D"}, stopNames(forward))
+	backward, ok := lr.Route(dto.DirectionBackward)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, []string{"D", "C", "B", "A"}, stopNames(backward))
+	assert.InDelta(t, 0, backward.Offsets[0], 5)
+
+	a, c := lr.StopByName("A"), lr.StopByName("C")
+	between, err := lr.StopsBetween(c, a)
+	assert.NoError(t, err)
+	assert.Len(t, between, 3)
+	assert.Equal(t, "B", between[1].Name)
+
+	d, err := lr.DistanceAlong(a, c)
+	assert.NoError(t, err)
+	assert.InDelta(t, a.Location.HaversineDistance(c.Location), d, 5)
+	back, err := lr.DistanceAlong(c, a)
+	assert.NoError(t, err)
+	assert.InDelta(t, d, back, 5)
+}
+
+func TestParseLineShapesCRS(t *testing.T) {
+	schottentor := dto.Coordinates{X: 16.3623, Y: 48.2153}
+	gk := schottentor.To(dto.CRSMGIAustriaGKEast)
+	wm := schottentor.To(dto.CRSWebMercator)
+	input := "LBEZEICHNUNG,SHAPE\n" +
+		fmt.Sprintf("U2,\"LINESTRING (%f %f, %f %f)\"\n", gk.X, gk.Y, gk.X+100, gk.Y) +
+		fmt.Sprintf("U4,\"SRID=3857;LINESTRING (%f %f, %f %f)\"\n", wm.X, wm.Y, wm.X, wm.Y+100)
+
+	shapes, err := ParseLineShapes(strings.NewReader(input), ParseOptions{CRS: dto.CRSMGIAustriaGKEast})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u2, u4 := shapes["U2"].Forward, shapes["U4"].Forward
+	assert.InDelta(t, 0, schottentor.HaversineDistance(u2[0]), 0.01)
+	assert.InDelta(t, 100, u2[0].HaversineDistance(u2[1]), 0.5)
+	assert.InDelta(t, 0, schottentor.HaversineDistance(u4[0]), 0.01)
+}
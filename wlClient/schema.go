@@ -0,0 +1,132 @@
+package wlclient
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+/*
+Schema describes the columns of an OGD dataset that the parsers need. Further columns are ignored,
+so a header matches as long as it contains the required ones, wherever they are.
+
+Datasets whose columns were renamed over time list their known layouts as Versions, newest first.
+A header matches the first version it has all columns of. Without Versions, the Required names
+are the column names.
+*/
+type Schema struct {
+	Dataset  string
+	Required []string // by the names the parsers use
+	Versions []SchemaVersion
+}
+
+// SchemaVersion is a known layout of a dataset's header.
+type SchemaVersion struct {
+	Name    string
+	Columns map[string]string // column in the header by required name, for those named differently
+}
+
+// column returns the name of the required column in headers of this version.
+func (v SchemaVersion) column(required string) string {
+	if c, ok := v.Columns[required]; ok {
+		return c
+	}
+	return required
+}
+
+// SchemaError is returned if a header matches no version of a schema.
+type SchemaError struct {
+	Dataset string
+	Header  []string
+	Missing []string // columns missing in the header, for the version it comes closest to
+}
+
+func (e SchemaError) Error() string {
+	return fmt.Sprintf("%s: header %q lacks required columns %s", e.Dataset, e.Header, strings.Join(e.Missing, ", "))
+}
+
+var (
+	StopsSchema = Schema{Dataset: "OEFFHALTESTOGD", Required: []string{StopNameField, StopShortNameField, CoordsField, LinesField}}
+	// LinesSchema knows the German header of the embedded file and the English one the Wiener Linien replaced it with.
+	LinesSchema = Schema{
+		Dataset:  "wienerlinien-ogd-linien",
+		Required: []string{LineNameField, LineTypeField},
+		Versions: []SchemaVersion{
+			{Name: "English", Columns: map[string]string{LineNameField: "LineText", LineTypeField: "MeansOfTransport"}},
+			{Name: "German"},
+		},
+	}
+	LineShapesSchema = Schema{Dataset: "OEFFLINIENOGD", Required: []string{LineShapeNameField, CoordsField}}
+)
+
+// versions returns the known versions of the schema, a single unnamed one if it lists none.
+func (s Schema) versions() []SchemaVersion {
+	if len(s.Versions) == 0 {
+		return []SchemaVersion{{}}
+	}
+	return s.Versions
+}
+
+/*
+Validate checks that the header contains all required columns of a version of the schema and
+returns that version. Column names are compared after trimming spaces and a byte order mark.
+If no version matches, a SchemaError naming the columns missing for the closest version is returned.
+*/
+func (s Schema) Validate(header []string) (SchemaVersion, error) {
+	present := make(map[string]bool, len(header))
+	for _, h := range header {
+		present[normalizeColumn(h)] = true
+	}
+	var closest *SchemaError
+	for _, v := range s.versions() {
+		err := SchemaError{Dataset: s.Dataset, Header: header}
+		for _, c := range s.Required {
+			if !present[v.column(c)] {
+				err.Missing = append(err.Missing, v.column(c))
+			}
+		}
+		if len(err.Missing) == 0 {
+			return v, nil
+		}
+		if closest == nil || len(err.Missing) < len(closest.Missing) {
+			closest = &err
+		}
+	}
+	return SchemaVersion{}, *closest
+}
+
+/*
+Index validates the header and returns the position of every column in it. The required columns
+are also listed by the names the parsers use, whatever they are called in the matched version.
+*/
+func (s Schema) Index(header []string) (map[string]int, error) {
+	v, err := s.Validate(header)
+	if err != nil {
+		return nil, err
+	}
+	indexMap := make(map[string]int, len(header)+len(s.Required))
+	for i, h := range header {
+		indexMap[normalizeColumn(h)] = i
+	}
+	for _, c := range s.Required {
+		indexMap[c] = indexMap[v.column(c)]
+	}
+	return indexMap, nil
+}
+
+// ValidateHeader reads the header of the CSV content and checks it against the schema, see Validate.
+func (s Schema) ValidateHeader(content string, comma rune) (SchemaVersion, error) {
+	r := csv.NewReader(strings.NewReader(content))
+	if comma != 0 {
+		r.Comma = comma
+	}
+	header, err := r.Read()
+	if err != nil {
+		return SchemaVersion{}, fmt.Errorf("reading header: %w", err)
+	}
+	return s.Validate(header)
+}
+
+func normalizeColumn(c string) string {
+	return strings.TrimSpace(strings.TrimPrefix(c, "\ufeff"))
+}
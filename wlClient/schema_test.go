@@ -0,0 +1,81 @@
+package wlclient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	// the header of the embedded dataset, with a byte order mark
+	v, err := LinesSchema.Validate([]string{"\ufeffLINIEN_ID", "BEZEICHNUNG", "REIHENFOLGE", "ECHTZEIT", "VERKEHRSMITTEL", "STAND"})
+	assert.NoError(t, err)
+	assert.Equal(t, "German", v.Name)
+	// columns the parsers do not need may be missing or reordered
+	_, err = LinesSchema.Validate([]string{" VERKEHRSMITTEL", "BEZEICHNUNG"})
+	assert.NoError(t, err)
+	v, err = LinesSchema.Validate([]string{"LineID", "LineText", "SortingHelp", "Realtime", "MeansOfTransport"})
+	assert.NoError(t, err)
+	assert.Equal(t, "English", v.Name)
+
+	// the error names what is missing for the closest version
+	_, err = LinesSchema.Validate([]string{"LineID", "LineText"})
+	var se SchemaError
+	if assert.True(t, errors.As(err, &se)) {
+		assert.Equal(t, []string{"MeansOfTransport"}, se.Missing)
+	}
+
+	_, err = StopsSchema.Validate([]string{"FID", "HTXT", "HLINIEN"})
+	if assert.True(t, errors.As(err, &se)) {
+		assert.Equal(t, []string{StopShortNameField, CoordsField}, se.Missing)
+	}
+}
+
+func TestParseLinesEnglishHeader(t *testing.T) {
+	lines, err := ParseLinesCSV("LineID;LineText;SortingHelp;Realtime;MeansOfTransport\n" +
+		"301;U6;6;1;ptMetro\n" +
+		"105;D;104;1;ptTram\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.Line{{Name: "U6", Type: dto.LineTypeUBahn}, {Name: "D", Type: dto.LineTypeTram}}, lines)
+}
+
+func TestParseLinesSchemaErrors(t *testing.T) {
+	_, err := ParseLinesCSV("\"LINIEN_ID\";\"NAME\";\"VERKEHRSMITTEL\"\n1;\"U6\";\"ptMetro\"\n")
+	assert.Error(t, err)
+
+	_, err = ParseStopsCSV("FID,HTXT,HLINIEN\n1,A,1\n")
+	assert.Error(t, err)
+}
+
+func TestParseLinesUnknownType(t *testing.T) {
+	const input = "\"LINIEN_ID\";\"BEZEICHNUNG\";\"VERKEHRSMITTEL\"\n" +
+		"1;\"U6\";\"ptMetro\"\n" +
+		"2;\"WLB\";\"ptTrainLocal\"\n" +
+		"3;\"E1\";\"ptBusElectric\"\n" +
+		"4;\"\";\"ptTram\"\n" +
+		"5;\"D\";\"ptTram\"\n"
+
+	lines, report, err := ParseLines(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []dto.Line{
+		{Name: "U6", Type: dto.LineTypeUBahn},
+		{Name: "WLB", Type: dto.LineTypeOther},
+		{Name: "E1", Type: dto.LineTypeBus},
+		{Name: "D", Type: dto.LineTypeTram},
+	}, lines)
+	assert.Equal(t, 5, report.Records)
+	assert.Equal(t, 1, report.Skipped)
+	if assert.Len(t, report.Warnings, 2) {
+		assert.Equal(t, 3, report.Warnings[0].Line)
+		assert.Equal(t, LineTypeField, report.Warnings[0].Field)
+	}
+	assert.Equal(t, []ParseError{{Line: 5, Field: LineNameField, Reason: "empty line name"}}, report.Errors)
+
+	_, _, err = ParseLines(strings.NewReader(input), ParseOptions{Mode: ParseStrict})
+	assert.Error(t, err)
+}
@@ -1,8 +1,77 @@
 package wlclient
 
-import "github.com/ehganzlieb/willfahren/dto"
+import (
+	"slices"
+	"strings"
 
-// AggregateStops() combines the list of stops with the list of lines from the parse functions into a dto map with unique stop and line objects that correctly reference each other.
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
+const StationRadius = 300.0 // m, poles with the same name closer than this to the first pole of a station belong to it
+
+// station collects the poles that make up one logical stop.
+type station struct {
+	name  string
+	poles []dto.Coordinates // distinct pole locations
+	lines []string
+}
+
+// centroid returns the mean location of the station's poles.
+func (s *station) centroid() dto.Coordinates {
+	var c dto.Coordinates
+	for _, p := range s.poles {
+		c.X += p.X
+		c.Y += p.Y
+	}
+	c.X /= float64(len(s.poles))
+	c.Y /= float64(len(s.poles))
+	return c
+}
+
+/*
+groupStations merges the poles of the stops CSV into logical stations.
+
+The CSV contains one row per pole and direction, so a stop like "Willergasse/Schule" appears
+several times. Poles with the same name that lie within StationRadius of the first pole of a
+station are merged into it, and the lines of all its poles are combined. The stations are
+returned in the order of their first pole.
+*/
+func groupStations(stops []*Stop) []*station {
+	stations := make([]*station, 0)
+	index := dto.NewSpatialIndex[*station](0)
+	for _, stop := range stops {
+		name := strings.TrimSpace(stop.Name)
+		var st *station
+		for _, n := range index.Within(stop.Location, StationRadius) {
+			if n.Item.name == name {
+				st = n.Item
+				break
+			}
+		}
+		if st == nil {
+			st = &station{name: name}
+			stations = append(stations, st)
+			index.Insert(stop.Location, st)
+		}
+		if !slices.Contains(st.poles, stop.Location) {
+			st.poles = append(st.poles, stop.Location)
+		}
+		for _, l := range stop.Lines {
+			if l = strings.TrimSpace(l); l != "" && !slices.Contains(st.lines, l) {
+				st.lines = append(st.lines, l)
+			}
+		}
+	}
+	return stations
+}
+
+/*
+AggregateStops() combines the list of stops with the list of lines from the parse functions into a dto map with unique stop and line objects that correctly reference each other.
+
+The poles of a stop are merged into one dto.Stop located at their centroid (see groupStations).
+Every dto.Stop is allocated once and shared by all lines serving it, so stops can be compared by pointer.
+Lines of a stop that are not in the list of lines are dropped.
+*/
 func AggregateStops(stops []*Stop, lines []dto.Line) map[dto.Line][]*dto.Stop {
 	stopMap := make(map[dto.Line][]*dto.Stop)
 
@@ -15,28 +84,25 @@ func AggregateStops(stops []*Stop, lines []dto.Line) map[dto.Line][]*dto.Stop {
 		lineMap[l.Name] = l
 	}
 
-	for _, stop := range stops {
-		for _, line := range stop.Lines {
-			// compare line dto line names with stop line names
-			for _, l := range lines {
-				if l.Name == line {
-					stopLines := make([]dto.Line, 0)
-					for _, l := range stop.Lines {
-						nl, ok := lineMap[l]
-						if ok {
-							stopLines = append(stopLines, nl)
-						}
-
-					}
-					stopMap[l] = append(stopMap[l], &dto.Stop{
-						Name:     stop.Name,
-						Location: stop.Location,
-						Lines:    &stopLines,
-					})
-
-				}
+	for _, st := range groupStations(stops) {
+		stopLines := make([]dto.Line, 0)
+		for _, name := range st.lines {
+			if l, ok := lineMap[name]; ok {
+				stopLines = append(stopLines, l)
 			}
+		}
+		if len(stopLines) == 0 {
+			continue
+		}
 
+		s := &dto.Stop{
+			Name:     st.name,
+			Location: st.centroid(),
+			Lines:    &stopLines,
+			Poles:    st.poles,
+		}
+		for _, l := range stopLines {
+			stopMap[l] = append(stopMap[l], s)
 		}
 	}
 
@@ -3,6 +3,9 @@ package wlclient
 import (
 	_ "embed"
 	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAggregateStops(t *testing.T) {
@@ -28,4 +31,33 @@ func TestAggregateStops(t *testing.T) {
 			t.Logf("\t%+v", s)
 		}
 	}
+
+	// all poles of Willergasse/Schule are merged into one stop shared by its lines
+	lineByName := make(map[string]dto.Line)
+	for _, l := range lines {
+		lineByName[l.Name] = l
+	}
+	willergasse := make(map[*dto.Stop]bool)
+	for _, name := range []string{"60A", "N60", "N61"} {
+		for _, s := range aggregatedStops[lineByName[name]] {
+			if s.Name == "Willergasse/Schule" {
+				willergasse[s] = true
+			}
+		}
+	}
+	assert.Len(t, willergasse, 1)
+	for s := range willergasse {
+		assert.Len(t, s.Poles, 4)
+		assert.InDelta(t, 16.2572, s.Location.X, 0.0001)
+		assert.InDelta(t, 48.1339, s.Location.Y, 0.0001)
+	}
+
+	// no stop appears twice on a line
+	for l, ss := range aggregatedStops {
+		seen := make(map[*dto.Stop]bool)
+		for _, s := range ss {
+			assert.False(t, seen[s], "%s appears twice on %s", s.Name, l.Name)
+			seen[s] = true
+		}
+	}
 }
@@ -1,10 +1,12 @@
 package wlclient
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"runtime"
 	"strings"
 	"sync"
 
@@ -30,79 +32,208 @@ const (
 /*
 ParseStopsCSV parses a CSV string and returns a slice of Stop.
 
-The CSV string is expected to have the columns HTXT, HTXTK, SHAPE, and HLINIEN. Other columns are ignored.
-The function will return an error if the CSV string is malformed.
-The returned slice of Stop will contain the parsed stops.
+The CSV string is expected to have the columns HTXT, HTXTK, SHAPE (a WKT POINT in WGS84), and HLINIEN. Other columns are ignored.
+The function will return an error if the header cannot be read or lacks one of these columns. Invalid records are skipped and logged,
+use ParseStops for the detailed report or strict parsing.
+The returned slice of Stop will contain the parsed stops in the order of the file.
 The stops contain lines with line names
 */
 func ParseStopsCSV(input string) ([]*Stop, error) {
-	//TODO: concurrency
+	stops, report, err := ParseStops(strings.NewReader(input), ParseOptions{Mode: ParseLenient})
+	if err != nil {
+		return nil, err
+	}
+	logReport(StopsSchema.Dataset, report)
+	return stops, nil
+}
+
+// stopRecord is a record of the stops CSV waiting to be parsed.
+type stopRecord struct {
+	seq    int
+	line   int
+	record []string
+}
 
-	r := csv.NewReader(strings.NewReader(input))
+type stopResult struct {
+	seq  int
+	stop *Stop
+	errs []ParseError
+}
+
+/*
+ParseStops parses the stops CSV from r and returns the stops in the order of the file.
+
+The records are read sequentially and parsed by a pool of opts.Workers workers. Every problem is
+recorded in the returned report with its line number, column and reason. In lenient mode, invalid
+records are skipped; in strict mode, the first invalid record aborts parsing and is returned as error.
+The locations are converted from opts.CRS to WGS84.
+An error is also returned if the header cannot be read or does not match StopsSchema.
+*/
+func ParseStops(r io.Reader, opts ParseOptions) ([]*Stop, *ParseReport, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = false
+	report := &ParseReport{}
 
-	stops := make([]*Stop, 0)
 	//get field names from first line
-	fieldNames, err := r.Read()
+	fieldNames, err := cr.Read()
 	if err != nil {
-		return nil, err
+		return nil, report, err
+	}
+
+	indexMap, err := StopsSchema.Index(fieldNames)
+	if err != nil {
+		return nil, report, err
 	}
 
-	indexMap := make(map[string]int)
-	for i, v := range fieldNames {
-		indexMap[v] = i
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
 	}
 
-	//parse all lines
-	ch := make(chan *Stop, 50)
-	var wg sync.WaitGroup
-	for {
-		record, err := r.Read()
-		if err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	records := make(chan stopRecord, workers*4)
+	results := make(chan stopResult, workers*4)
+	readErr := make(chan error, 1)
+
+	// reader
+	go func() {
+		defer close(records)
+		for seq := 0; ; seq++ {
+			record, err := cr.Read()
 			if err == io.EOF {
-				break
+				readErr <- nil
+				return
+			}
+			var pe *csv.ParseError
+			if err != nil && !errors.As(err, &pe) {
+				readErr <- err
+				return
+			}
+			var line int
+			if pe != nil {
+				// passed on as nil record so the error is reported in file order
+				line = pe.Line
+				record = nil
 			} else {
-				log.Print(err)
+				line, _ = cr.FieldPos(0)
+			}
+			select {
+			case records <- stopRecord{seq: seq, line: line, record: record}:
+			case <-ctx.Done():
+				readErr <- nil
+				return
 			}
 		}
-		go parseStop(record, indexMap, ch, &wg)
+	}()
+
+	// workers
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rec := range records {
+				stop, errs := parseStop(rec, fieldNames, indexMap, opts.CRS)
+				select {
+				case results <- stopResult{seq: rec.seq, stop: stop, errs: errs}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
 	}
 	go func() {
 		wg.Wait()
-		close(ch)
+		close(results)
 	}()
 
-	for stop := range ch {
-		if stop != nil {
-			stops = append(stops, stop)
+	// collect results in file order
+	pending := make(map[int]stopResult)
+	stops := make([]*Stop, 0)
+	next := 0
+	var strictErr error
+	for res := range results {
+		pending[res.seq] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			report.Records++
+			if len(res.errs) > 0 {
+				report.Skipped++
+				report.Errors = append(report.Errors, res.errs...)
+				if opts.Mode == ParseStrict && strictErr == nil {
+					strictErr = res.errs[0]
+					cancel()
+				}
+				continue
+			}
+			if strictErr == nil {
+				stops = append(stops, res.stop)
+			}
 		}
 	}
 
-	return stops, nil
+	if strictErr != nil {
+		return nil, report, strictErr
+	}
+	if err := <-readErr; err != nil {
+		return nil, report, err
+	}
+	return stops, report, nil
 }
 
-func parseStop(record []string, indexMap map[string]int, ch chan *Stop, wg *sync.WaitGroup) {
-	wg.Add(1)
-	defer wg.Done()
+// parseStop parses one record. A nil record stands for a record the CSV reader could not parse.
+func parseStop(rec stopRecord, fieldNames []string, indexMap map[string]int, crs dto.CRS) (*Stop, []ParseError) {
+	if rec.record == nil {
+		return nil, []ParseError{{Line: rec.line, Reason: "malformed CSV record"}}
+	}
+	if len(rec.record) != len(fieldNames) {
+		return nil, []ParseError{{Line: rec.line, Reason: fmt.Sprintf("record has %d fields, header has %d", len(rec.record), len(fieldNames))}}
+	}
 
-	name := record[indexMap[StopNameField]]
-	shortName := record[indexMap[StopShortNameField]]
-	coordsString := record[indexMap[CoordsField]]
-	linesString := record[indexMap[LinesField]]
+	errs := make([]ParseError, 0)
+	name := strings.TrimSpace(rec.record[indexMap[StopNameField]])
+	if name == "" {
+		errs = append(errs, ParseError{Line: rec.line, Field: StopNameField, Reason: "empty stop name"})
+	}
+	shortName := rec.record[indexMap[StopShortNameField]]
+	coordsString := rec.record[indexMap[CoordsField]]
+	linesString := rec.record[indexMap[LinesField]]
+
+	var location dto.Coordinates
+	if wkt, crs, err := SplitEWKT(coordsString, crs); err != nil {
+		errs = append(errs, ParseError{Line: rec.line, Field: CoordsField, Reason: err.Error()})
+	} else {
+		p := dto.CRSCoordinates{CRS: crs}
+		if _, err := fmt.Sscanf(wkt, CoordsFormatString, &p.X, &p.Y); err != nil {
+			errs = append(errs, ParseError{Line: rec.line, Field: CoordsField, Reason: fmt.Sprintf("invalid point %q: %v", coordsString, err)})
+		}
+		location = p.WGS84()
+	}
 
-	var xCoord, yCoord float64
-	if _, err := fmt.Sscanf(coordsString, CoordsFormatString, &xCoord, &yCoord); err != nil {
-		ch <- nil
-		return
+	lines := make([]string, 0)
+	for _, l := range strings.Split(linesString, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		errs = append(errs, ParseError{Line: rec.line, Field: LinesField, Reason: "no lines"})
 	}
 
-	lines := strings.Split(linesString, ",")
-	ch <- &Stop{
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &Stop{
 		Name:      name,
 		ShortName: shortName,
-		Location: dto.Coordinates{
-			X: xCoord,
-			Y: yCoord,
-		},
-		Lines: lines,
-	}
+		Location:  location,
+		Lines:     lines,
+	}, nil
 }
@@ -2,6 +2,12 @@ package wlclient
 
 import (
 	_ "embed"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ehganzlieb/willfahren/dto"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -9,14 +15,84 @@ var (
 	testStops string
 )
 
-/*
+const brokenStops = `FID,SHAPE,HTXT,HTXTK,HLINIEN
+1,POINT (16.1 48.1),A,A,"1,2"
+2,POINT (x y),B,B,3
+3,POINT (16.3 48.3),,C,
+4,POINT (16.4 48.4),D,D,4
+5,POINT (16.5 48.5),E
+6,POINT (16.6 48.6),F,F,"6 , 7"
+`
+
 func TestParseStopsCSV(t *testing.T) {
-	stops, err := parseStopsCSV(testStops)
+	stops, err := ParseStopsCSV(testStops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Count(strings.TrimSpace(testStops), "\n"), len(stops))
+	assert.Equal(t, "Willergasse/Schule", stops[0].Name)
+	assert.Equal(t, []string{"250", "253", "60A", "N60", "N61"}, stops[0].Lines)
+	assert.InDelta(t, 16.25702731217803, stops[0].Location.X, 1e-9)
+}
+
+func TestParseStopsDeterministic(t *testing.T) {
+	first, _, err := ParseStops(strings.NewReader(testStops), ParseOptions{Workers: 1})
 	if err != nil {
 		t.Fatal(err)
 	}
-	for _, v := range stops {
-		 t.Logf("%+v", v)
+	for range 5 {
+		stops, _, err := ParseStops(strings.NewReader(testStops), ParseOptions{Workers: 8})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, first, stops)
+	}
+}
+
+func TestParseStopsLenient(t *testing.T) {
+	stops, report, err := ParseStops(strings.NewReader(brokenStops), ParseOptions{Mode: ParseLenient, Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(stops))
+	for i, s := range stops {
+		names[i] = s.Name
+	}
+	assert.Equal(t, []string{"A", "D", "F"}, names)
+	assert.Equal(t, []string{"6", "7"}, stops[2].Lines)
+
+	assert.Equal(t, 6, report.Records)
+	assert.Equal(t, 3, report.Skipped)
+	if assert.Len(t, report.Errors, 4) {
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Equal(t, CoordsField, report.Errors[0].Field)
+		assert.Equal(t, ParseError{Line: 4, Field: StopNameField, Reason: "empty stop name"}, report.Errors[1])
+		assert.Equal(t, ParseError{Line: 4, Field: LinesField, Reason: "no lines"}, report.Errors[2])
+		assert.Equal(t, 6, report.Errors[3].Line)
+	}
+	assert.Contains(t, report.Err().Error(), "line 4, field HTXT: empty stop name")
+}
+
+func TestParseStopsStrict(t *testing.T) {
+	stops, report, err := ParseStops(strings.NewReader(brokenStops), ParseOptions{Mode: ParseStrict, Workers: 3})
+	assert.Nil(t, stops)
+	var pe ParseError
+	if assert.True(t, errors.As(err, &pe)) {
+		assert.Equal(t, 3, pe.Line)
+		assert.Equal(t, CoordsField, pe.Field)
+	}
+	assert.NotEmpty(t, report.Errors)
+}
+
+func TestParseStopsCRS(t *testing.T) {
+	const input = "HTXT,HTXTK,SHAPE,HLINIEN\n" +
+		"Stephansplatz,Stephansplatz,POINT (3000.47 341106.45),\"U1,U3\"\n" +
+		"Karlsplatz,Karlsplatz,SRID=4326;POINT (16.3699 48.2003),\"U1,U2,U4\"\n"
+	stops, _, err := ParseStops(strings.NewReader(input), ParseOptions{Mode: ParseStrict, CRS: dto.CRSMGIAustriaGKEast})
+	if err != nil {
+		t.Fatal(err)
 	}
+	assert.InDelta(t, 16.3725, stops[0].Location.X, 0.0001)
+	assert.InDelta(t, 48.2084, stops[0].Location.Y, 0.0001)
+	assert.Equal(t, dto.Coordinates{X: 16.3699, Y: 48.2003}, stops[1].Location)
 }
-*/
@@ -1,7 +1,58 @@
 package wlclient
 
+import (
+	_ "embed"
+
+	"github.com/ehganzlieb/willfahren/dto"
+)
+
 const (
 	wlStopsCSVURL = "https://data.wien.gv.at/daten/geo?service=WFS&request=GetFeature&version=1.1.0&typeName=ogdwien:OEFFHALTESTOGD&srsName=EPSG:4326&outputFormat=csv"
 
 	wlLinesCSVURL = "https://www.wienerlinien.at/ogd_realtime/doku/ogd/wienerlinien-ogd-linien.csv"
+
+	wlLineShapesCSVURL = "https://data.wien.gv.at/daten/geo?service=WFS&request=GetFeature&version=1.1.0&typeName=ogdwien:OEFFLINIENOGD&srsName=EPSG:4326&outputFormat=csv"
+)
+
+var (
+	//go:embed OEFFHALTESTOGD.csv
+	snapshotStops string
+
+	//go:embed wienerlinien-ogd-linien.csv
+	snapshotLines string
+)
+
+// Dataset describes an OGD CSV file that can be downloaded by a Loader.
+type Dataset struct {
+	Name     string // used as directory name for the local copies
+	URL      string
+	Comma    rune    // field separator of the CSV
+	Schema   Schema  // checked against the header of every download
+	CRS      dto.CRS // of the coordinates, as requested by the srsName parameter of the URL
+	Snapshot string  // embedded copy used if neither a download nor a local copy is available, may be empty
+}
+
+var (
+	StopsDataset = Dataset{
+		Name:     "OEFFHALTESTOGD",
+		URL:      wlStopsCSVURL,
+		Comma:    ',',
+		Schema:   StopsSchema,
+		CRS:      dto.CRSWGS84,
+		Snapshot: snapshotStops,
+	}
+	LinesDataset = Dataset{
+		Name:     "wienerlinien-ogd-linien",
+		URL:      wlLinesCSVURL,
+		Comma:    ';',
+		Schema:   LinesSchema,
+		Snapshot: snapshotLines,
+	}
+	LineShapesDataset = Dataset{
+		Name:   "OEFFLINIENOGD",
+		URL:    wlLineShapesCSVURL,
+		Comma:  ',',
+		Schema: LineShapesSchema,
+		CRS:    dto.CRSWGS84,
+	}
 )